@@ -0,0 +1,127 @@
+// Command pricecsv imports and exports stored price history as CSV.
+//
+//	pricecsv import -ticker BITCOIN -timeframe D1 -file btc.csv [-time-column date -price-column close] [-overwrite]
+//	pricecsv export -ticker BITCOIN -timeframe D1 [-file btc.csv]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/marketprice"
+	"github.com/signalb/internal/timeframe"
+	_ "github.com/tursodatabase/libsql-client-go/libsql"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: pricecsv <import|export> [flags]")
+	}
+
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(cmd string, args []string) error {
+	flags := flag.NewFlagSet(cmd, flag.ExitOnError)
+
+	tickerSymbol := flags.String("ticker", "", "ticker symbol")
+	tf := flags.String("timeframe", "", fmt.Sprintf("one of %v", timeframe.AllowedTimeframes))
+	file := flags.String("file", "", "csv file, defaults to stdin for import and stdout for export")
+	timeColumn := flags.String("time-column", marketprice.DefaultCSVTimeColumn, "name of the time column")
+	priceColumn := flags.String("price-column", marketprice.DefaultCSVPriceColumn, "name of the price column")
+	timeLayout := flags.String("time-layout", "", "Go time layout of the time column, detected when empty")
+	overwrite := flags.Bool("overwrite", false, "replace rows already stored instead of skipping them")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *tickerSymbol == "" || !slices.Contains(timeframe.AllowedTimeframes, *tf) {
+		flags.Usage()
+		return fmt.Errorf("-ticker and -timeframe (one of %v) are required", timeframe.AllowedTimeframes)
+	}
+
+	calendar.InitCalendars()
+	database.InitDB()
+	defer database.Client.Close()
+
+	ctx := context.Background()
+
+	switch cmd {
+	case "import":
+		return runImport(ctx, *tickerSymbol, *tf, *file,
+			marketprice.NewCSVColumnMapping(*timeColumn, *priceColumn, *timeLayout), *overwrite)
+	case "export":
+		return runExport(ctx, *tickerSymbol, *tf, *file)
+	default:
+		return fmt.Errorf("unknown command %q, expected import or export", cmd)
+	}
+}
+
+func runImport(ctx context.Context, tickerSymbol, tf, file string, mapping *marketprice.CSVColumnMapping, overwrite bool) error {
+	class, err := database.Client.GetTickerClassBySymbol(ctx, tickerSymbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s is not registered", tickerSymbol)
+	}
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := marketprice.ParsePriceCSV(r, tickerSymbol, mapping)
+	if err != nil {
+		return err
+	}
+
+	if err := marketprice.ValidateCandleTimes(class, tf, data); err != nil {
+		return err
+	}
+
+	res, err := marketprice.ImportPriceHistory(ctx, tickerSymbol, tf, data, overwrite)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Imported %d rows for %s %s, overwrote %d and skipped %d already stored",
+		res.Imported, tickerSymbol, tf, res.Overwritten, res.Skipped)
+	return nil
+}
+
+func runExport(ctx context.Context, tickerSymbol, tf, file string) error {
+	data, err := marketprice.ExportPriceHistory(ctx, tickerSymbol, tf)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return marketprice.WritePriceCSV(w, data)
+}
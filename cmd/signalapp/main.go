@@ -4,9 +4,13 @@ import (
 	"log"
 
 	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/botcommand"
+	"github.com/signalb/internal/calendar"
 	"github.com/signalb/internal/database"
 	"github.com/signalb/internal/marketprice"
+	"github.com/signalb/internal/notifier"
 	"github.com/signalb/internal/strategy"
+	"github.com/signalb/internal/stream"
 	"github.com/signalb/internal/telegram"
 	_ "github.com/tursodatabase/libsql-client-go/libsql"
 )
@@ -18,9 +22,13 @@ func main() {
 	InitRoutes(router)
 	telegram.InitBot()
 	strategy.InitStrategies()
+	calendar.InitCalendars()
 	marketprice.InitFetchers()
 	database.InitDB()
 	defer database.Client.Close()
+	notifier.InitNotifiers()
+	stream.InitStream()
+	botcommand.InitCommands()
 
 	if err := router.Run(":8080"); err != nil {
 		log.Println(err)
@@ -3,9 +3,14 @@ package main
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/signalb/internal/binding"
+	"github.com/signalb/internal/chart"
 	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/indicators"
 	"github.com/signalb/internal/marketprice"
+	"github.com/signalb/internal/notifier"
+	"github.com/signalb/internal/retention"
 	"github.com/signalb/internal/strategy"
+	"github.com/signalb/internal/stream"
 	"github.com/signalb/internal/ticker"
 	"github.com/signalb/internal/timeframe"
 )
@@ -20,11 +25,13 @@ func InitRoutes(router *gin.Engine) {
 	{
 		tickers.POST("", ticker.RegisterTicker)
 		tickers.GET("", ticker.GetTickers)
+		tickers.GET("/:ticker/corporate-actions", ticker.GetCorporateActions)
 	}
 
 	bindings := router.Group("/api/bindings")
 	{
 		bindings.POST("", binding.RegisterBindingController)
+		bindings.DELETE("", binding.DeleteBindingController)
 		bindings.GET("/tickers/:ticker", binding.GetBindingsForTickerController)
 		bindings.GET("/timeframes/:timeframe", binding.GetBindingsForTimeframeController)
 	}
@@ -34,12 +41,56 @@ func InitRoutes(router *gin.Engine) {
 		data.POST("/:timeframe/:ticker", marketprice.RefreshPriceByTickerTimeframeController)
 		data.POST("/:timeframe", marketprice.RefreshMarketpriceByTimeframeController)
 		data.GET("/:timeframe/:ticker", marketprice.GetMarketpriceDataByTickerTimeframeController)
+		data.GET("/quota", marketprice.GetProviderQuotaController)
+		data.POST("/:timeframe/:ticker/import", marketprice.ImportPriceCSVController)
+		data.GET("/:timeframe/:ticker/export", marketprice.ExportPriceCSVController)
+		data.GET("/:timeframe/:ticker/quarantine", marketprice.GetQuarantinedCandlesController)
+	}
+
+	charts := router.Group("/api/charts")
+	{
+		charts.GET("/:timeframe/:ticker", chart.GetChartController)
+	}
+
+	indicatorSeries := router.Group("/api/indicators")
+	{
+		indicatorSeries.GET("/:timeframe/:ticker", indicators.GetIndicatorController)
+	}
+
+	retentionPolicies := router.Group("/api/retention")
+	{
+		retentionPolicies.POST("", retention.SetRetentionPolicyController)
+		retentionPolicies.GET("", retention.GetRetentionPoliciesController)
 	}
 
 	strategies := router.Group("/api/strategies")
 	{
 		strategies.GET("", strategy.GetStrategiesController)
 		strategies.GET("/:timeframe/evaluate", strategy.EvaluateTickerStrategiesByTimeframeController)
+		strategies.POST("/:timeframe/notify", strategy.NotifyTickerStrategiesByTimeframeController)
+	}
+
+	streams := router.Group("/api/stream")
+	{
+		streams.GET("", stream.GetStreamPricesController)
+		streams.GET("/:ticker", stream.GetStreamPriceByTickerController)
+	}
+
+	alerts := router.Group("/api/alerts")
+	{
+		alerts.POST("", stream.CreatePriceAlertController)
+		alerts.GET("", stream.GetPriceAlertsController)
+		alerts.DELETE("/:id", stream.DeletePriceAlertController)
+	}
+
+	notifiers := router.Group("/api/notifiers")
+	{
+		notifiers.POST("/channels", notifier.UpsertChannelController)
+		notifiers.GET("/channels", notifier.GetChannelsController)
+		notifiers.POST("/routes", notifier.AddRouteController)
+		notifiers.GET("/routes", notifier.GetRoutesController)
+		notifiers.DELETE("/routes", notifier.DeleteRouteController)
+		notifiers.GET("/subscribers", notifier.GetSubscribersController)
 	}
 
 	router.GET("/ping", database.PingController)
@@ -2,11 +2,14 @@ package binding
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"slices"
 	"time"
 
+	errorsStdLib "errors"
+
 	"github.com/gin-gonic/gin"
 	"github.com/signalb/internal/database"
 	"github.com/signalb/internal/errors"
@@ -23,48 +26,95 @@ func RegisterBindingController(c *gin.Context) {
 		return
 	}
 
+	if err := ValidateRegisterBindingReq(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(err))
+		return
+	}
+
+	err := InsertBinding(c.Request.Context(), req.TickerSymbol, req.Timeframe, req.Strategy, req.PriceSeries)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("insert binding: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("binding of %+v inserted successfully", req),
+	})
+}
+
+// ValidateRegisterBindingReq checks the strategy, timeframe and price series of a binding, defaulting
+// to the adjusted series.
+func ValidateRegisterBindingReq(req *RegisterBindingReq) error {
 	strategyInstance, ok := strategy.StrategyManager.NameToStrategyMap[req.Strategy]
 	if !ok {
-		c.JSON(http.StatusBadRequest,
-			errors.NewErrorResp(fmt.Errorf("valid strategies: %v", strategy.StrategyManager.GetStrategies())))
-		return
+		return fmt.Errorf("valid strategies: %v", strategy.StrategyManager.GetStrategies())
 	}
 
 	if !slices.Contains(timeframe.AllowedTimeframes, req.Timeframe) {
-		c.JSON(http.StatusBadRequest,
-			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
-		return
+		return fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)
 	}
 
 	if whitelistedTickerSymbols := strategyInstance.GetWhitelistedTickerSymbols(); whitelistedTickerSymbols != nil {
 		if !slices.Contains(whitelistedTickerSymbols, req.TickerSymbol) {
-			c.JSON(http.StatusBadRequest,
-				errors.NewErrorResp(fmt.Errorf("valid symbols for strategy %s: %v", req.Strategy, whitelistedTickerSymbols)))
-			return
+			return fmt.Errorf("valid symbols for strategy %s: %v", req.Strategy, whitelistedTickerSymbols)
 		}
 	}
 
-	err := insertBinding(c.Request.Context(), req.TickerSymbol, req.Timeframe, req.Strategy)
+	if req.PriceSeries == "" {
+		req.PriceSeries = database.AdjustedSeries
+	}
+
+	if !slices.Contains(database.AllowedPriceSeries, req.PriceSeries) {
+		return fmt.Errorf("valid price series: %v", database.AllowedPriceSeries)
+	}
+
+	return nil
+}
+
+func InsertBinding(c context.Context, tickerSymbol, timeframe, strategy, priceSeries string) error {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	if !database.Client.IsTickerRegistered(ctx, tickerSymbol) {
+		return fmt.Errorf("%s is not registered", tickerSymbol)
+	}
+
+	return database.Client.InsertBinding(ctx, tickerSymbol, timeframe, strategy, priceSeries)
+}
+
+func DeleteBindingController(c *gin.Context) {
+	var req DeleteBindingReq
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
+		return
+	}
+
+	err := DeleteBinding(c.Request.Context(), req.TickerSymbol, req.Timeframe, req.Strategy)
+	if errorsStdLib.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, errors.NewErrorResp(fmt.Errorf("binding of %+v not found", req)))
+		return
+	}
+
 	if err != nil {
 		c.JSON(http.StatusInternalServerError,
-			errors.NewErrorResp(fmt.Errorf("insert binding: %w", err)))
+			errors.NewErrorResp(fmt.Errorf("delete binding: %w", err)))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("binding of %+v inserted successfully", req),
+		"message": fmt.Sprintf("binding of %+v deleted successfully", req),
 	})
 }
 
-func insertBinding(c context.Context, tickerSymbol, timeframe, strategy string) error {
+// DeleteBinding returns sql.ErrNoRows when the ticker isn't bound to the strategy in the timeframe.
+func DeleteBinding(c context.Context, tickerSymbol, timeframe, strategy string) error {
 	ctx, cancel := context.WithTimeout(c, 2*time.Second)
 	defer cancel()
 
-	if !database.Client.IsTickerRegistered(ctx, tickerSymbol) {
-		return fmt.Errorf("%s is not registered", tickerSymbol)
-	}
-
-	return database.Client.InsertBinding(ctx, tickerSymbol, timeframe, strategy)
+	return database.Client.DeleteBinding(ctx, tickerSymbol, timeframe, strategy)
 }
 
 func GetBindingsForTickerController(c *gin.Context) {
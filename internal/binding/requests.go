@@ -4,4 +4,11 @@ type RegisterBindingReq struct {
 	TickerSymbol string `json:"tickerSymbol"`
 	Timeframe    string `json:"timeframe"`
 	Strategy     string `json:"strategy"`
+	PriceSeries  string `json:"priceSeries"` // adjusted (default) or raw
+}
+
+type DeleteBindingReq struct {
+	TickerSymbol string `json:"tickerSymbol"`
+	Timeframe    string `json:"timeframe"`
+	Strategy     string `json:"strategy"`
 }
@@ -0,0 +1,291 @@
+// Package botcommand lets admin chats manage tickers and bindings and every chat keep its own watchlist
+// through Telegram commands, with the same validation as the HTTP API.
+package botcommand
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+
+	errorsStdLib "errors"
+
+	"github.com/signalb/internal/binding"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/strategy"
+	"github.com/signalb/internal/telegram"
+	"github.com/signalb/internal/ticker"
+	"github.com/signalb/internal/timeframe"
+)
+
+// InitCommands registers the commands on the bot. Needs the bot, the strategies and the database.
+func InitCommands() {
+	bot := telegram.Bot
+
+	// Admins manage the tickers and bindings
+	bot.HandleCommand("addticker", "register a ticker: /addticker SYMBOL class", telegram.AdminAccess, addTickerCommand)
+	bot.HandleCommand("bind", "bind a strategy: /bind SYMBOL TF strategy", telegram.AdminAccess, bindCommand)
+	bot.HandleCommand("unbind", "remove a binding: /unbind SYMBOL TF strategy", telegram.AdminAccess, unbindCommand)
+	bot.HandleCommand("evaluate", "evaluate and notify a timeframe: /evaluate TF", telegram.AdminAccess, evaluateCommand)
+
+	// Every chat can look around and keep its own watchlist
+	bot.HandleCommand("tickers", "list the registered tickers", telegram.PublicAccess, tickersCommand)
+	bot.HandleCommand("strategies", "list the strategies", telegram.PublicAccess, strategiesCommand)
+	bot.HandleCommand("price", "latest stored price: /price SYMBOL TF", telegram.PublicAccess, priceCommand)
+	bot.HandleCommand("register", "subscribe this chat to signals", telegram.PublicAccess, registerCommand)
+	bot.HandleCommand("unregister", "unsubscribe and drop the watchlist", telegram.PublicAccess, unregisterCommand)
+	bot.HandleCommand("watch", "add to the watchlist: /watch SYMBOL TF strategy", telegram.PublicAccess, watchCommand)
+	bot.HandleCommand("unwatch", "remove from the watchlist: /unwatch SYMBOL TF strategy", telegram.PublicAccess, unwatchCommand)
+	bot.HandleCommand("watchlist", "show the watchlist", telegram.PublicAccess, watchlistCommand)
+}
+
+func tickersCommand(c context.Context, _ *telegram.CommandRequest) (*telegram.Reply, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	tickers, err := database.Client.GetTickers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tickers: %w", err)
+	}
+
+	if len(tickers) == 0 {
+		return &telegram.Reply{Text: "No tickers registered yet, add one with /addticker SYMBOL class."}, nil
+	}
+
+	var builder strings.Builder
+	for _, t := range tickers {
+		builder.WriteString(fmt.Sprintf("<b>%s</b> %s\n", html.EscapeString(t.Symbol), t.Class))
+	}
+
+	return &telegram.Reply{Text: builder.String()}, nil
+}
+
+func addTickerCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	if len(cmd.Args) == 0 {
+		return usage("/addticker SYMBOL class"), nil
+	}
+
+	symbol := cmd.Args[0]
+	if len(cmd.Args) == 1 {
+		return choose(fmt.Sprintf("Class of %s?", symbol), "/addticker "+symbol, ticker.AllowedClasses), nil
+	}
+
+	req := &ticker.RegisterTickerReq{Symbol: symbol, Class: cmd.Args[1]}
+	if err := ticker.ResolveTickerClass(c, req); err != nil {
+		return nil, err
+	}
+
+	if err := ticker.ValidateRegisterTickerReq(req); err != nil {
+		return nil, err
+	}
+
+	created, err := ticker.UpsertTicker(c, req.Symbol, req.Class, req.ProviderSymbols)
+	if err != nil {
+		return nil, fmt.Errorf("register ticker: %w", err)
+	}
+
+	if !created {
+		return reply("Ticker %s is already registered.", symbol), nil
+	}
+
+	return reply("Ticker %s of class %s created successfully.", symbol, req.Class), nil
+}
+
+func bindCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	switch len(cmd.Args) {
+	case 0:
+		return usage("/bind SYMBOL TF strategy"), nil
+	case 1:
+		return choose("Timeframe?", "/bind "+cmd.Args[0], timeframe.AllowedTimeframes), nil
+	case 2:
+		return choose("Strategy?", fmt.Sprintf("/bind %s %s", cmd.Args[0], cmd.Args[1]), getStrategies()), nil
+	}
+
+	req := &binding.RegisterBindingReq{TickerSymbol: cmd.Args[0], Timeframe: cmd.Args[1], Strategy: cmd.Args[2]}
+	if err := binding.ValidateRegisterBindingReq(req); err != nil {
+		return nil, err
+	}
+
+	if err := binding.InsertBinding(c, req.TickerSymbol, req.Timeframe, req.Strategy, req.PriceSeries); err != nil {
+		return nil, fmt.Errorf("insert binding: %w", err)
+	}
+
+	return reply("Bound %s to %s on %s.", req.Strategy, req.TickerSymbol, req.Timeframe), nil
+}
+
+// unbindCommand only offers the bindings the ticker has.
+func unbindCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	if len(cmd.Args) == 0 {
+		return usage("/unbind SYMBOL TF strategy"), nil
+	}
+
+	if len(cmd.Args) < 3 {
+		bindings, err := getBindings(c, cmd.Args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		var options []string
+		for _, b := range bindings {
+			option := b.Strategy
+			if len(cmd.Args) == 1 {
+				option = b.Timeframe
+			} else if b.Timeframe != cmd.Args[1] {
+				continue
+			}
+
+			if !slices.Contains(options, option) {
+				options = append(options, option)
+			}
+		}
+
+		if len(options) == 0 {
+			return reply("%s has no bindings to remove.", strings.Join(cmd.Args, " ")), nil
+		}
+
+		slices.Sort(options)
+		if len(cmd.Args) == 1 {
+			return choose("Timeframe?", "/unbind "+cmd.Args[0], options), nil
+		}
+		return choose("Strategy?", fmt.Sprintf("/unbind %s %s", cmd.Args[0], cmd.Args[1]), options), nil
+	}
+
+	err := binding.DeleteBinding(c, cmd.Args[0], cmd.Args[1], cmd.Args[2])
+	if errorsStdLib.Is(err, sql.ErrNoRows) {
+		return reply("%s is not bound to %s on %s.", cmd.Args[0], cmd.Args[2], cmd.Args[1]), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("delete binding: %w", err)
+	}
+
+	return reply("Unbound %s from %s on %s.", cmd.Args[2], cmd.Args[0], cmd.Args[1]), nil
+}
+
+func getBindings(c context.Context, tickerSymbol string) ([]database.Binding, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	bindings, err := database.Client.GetBindingsByTicker(ctx, tickerSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("get bindings: %w", err)
+	}
+
+	return bindings, nil
+}
+
+func strategiesCommand(_ context.Context, _ *telegram.CommandRequest) (*telegram.Reply, error) {
+	var builder strings.Builder
+	for _, name := range getStrategies() {
+		builder.WriteString(fmt.Sprintf("<code>%s</code>\n", html.EscapeString(name)))
+	}
+
+	return &telegram.Reply{Text: builder.String()}, nil
+}
+
+// evaluateCommand sends the results to the notification channels, the reply only says how it went.
+func evaluateCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	if len(cmd.Args) == 0 {
+		return choose("Timeframe?", "/evaluate", timeframe.AllowedTimeframes), nil
+	}
+
+	tf := cmd.Args[0]
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		return nil, fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)
+	}
+
+	res, failures, err := strategy.EvaluateAndNotify(c, tf)
+	if res == nil && err != nil {
+		return nil, fmt.Errorf("evaluate strategies: %w", err)
+	}
+
+	evaluated, fulfilled := 0, 0
+	for _, strategyResps := range res {
+		for _, strategyResp := range strategyResps {
+			evaluated++
+			if strategyResp.IsFulfilled {
+				fulfilled++
+			}
+		}
+	}
+
+	failed := ""
+	if len(failures) > 0 {
+		symbols := make([]string, 0, len(failures))
+		for _, failure := range failures {
+			symbols = append(symbols, fmt.Sprintf("%s (%s)", failure.TickerSymbol, failure.Category))
+		}
+		failed = fmt.Sprintf(" %d tickers failed: %s.", len(failures), strings.Join(symbols, ", "))
+	}
+
+	if err != nil {
+		return reply("Evaluated %d strategies on %d tickers, %d fulfilled.%s Notifying failed: %v",
+			evaluated, len(res), fulfilled, failed, err), nil
+	}
+
+	return reply("Evaluated %d strategies on %d tickers, %d fulfilled.%s Results were sent to their channels.",
+		evaluated, len(res), fulfilled, failed), nil
+}
+
+func priceCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	switch len(cmd.Args) {
+	case 0:
+		return usage("/price SYMBOL TF"), nil
+	case 1:
+		return choose("Timeframe?", "/price "+cmd.Args[0], timeframe.AllowedTimeframes), nil
+	}
+
+	tickerSymbol, tf := cmd.Args[0], cmd.Args[1]
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		return nil, fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)
+	}
+
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	data, err := database.Client.QueryPriceData(ctx, tickerSymbol, tf, &database.PriceFilter{Limit: 1, Descending: true})
+	if err != nil {
+		return nil, fmt.Errorf("get price: %w", err)
+	}
+
+	if len(data) == 0 {
+		return reply("No %s prices stored for %s.", tf, tickerSymbol), nil
+	}
+
+	return reply("%s %s: %g at %s", tickerSymbol, tf, data[0].Price, data[0].Time.Format(database.PriceTimeLayout)), nil
+}
+
+func getStrategies() []string {
+	strategies := strategy.StrategyManager.GetStrategies()
+	slices.Sort(strategies)
+
+	return strategies
+}
+
+// choose asks for the next argument of a command, each option runs it with that argument appended.
+func choose(question, commandPrefix string, options []string) *telegram.Reply {
+	choices := make([]telegram.Choice, 0, len(options))
+	for _, option := range options {
+		choices = append(choices, telegram.Choice{
+			Label:   option,
+			Command: commandPrefix + " " + option,
+		})
+	}
+
+	return &telegram.Reply{
+		Text:    html.EscapeString(question),
+		Choices: choices,
+	}
+}
+
+func usage(format string) *telegram.Reply {
+	return reply("Usage: %s", format)
+}
+
+// reply escapes the formatted text, symbols and error messages are user input.
+func reply(format string, args ...any) *telegram.Reply {
+	return &telegram.Reply{Text: html.EscapeString(fmt.Sprintf(format, args...))}
+}
@@ -0,0 +1,273 @@
+package botcommand
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+
+	errorsStdLib "errors"
+
+	"github.com/signalb/internal/binding"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/telegram"
+	"github.com/signalb/internal/timeframe"
+)
+
+var errNotRegistered = errorsStdLib.New("this chat is not registered, subscribe with /register first")
+
+func registerCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	created, err := database.Client.UpsertSubscriber(ctx, &database.Subscriber{
+		ChatID: cmd.ChatID,
+		Name:   cmd.ChatName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("register chat: %w", err)
+	}
+
+	if !created {
+		return reply("This chat is already registered, see /watchlist."), nil
+	}
+
+	return reply("Registered! Add signals to your watchlist with /watch SYMBOL TF strategy."), nil
+}
+
+func unregisterCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	err := database.Client.DeleteSubscriber(ctx, cmd.ChatID)
+	if errorsStdLib.Is(err, sql.ErrNoRows) {
+		return nil, errNotRegistered
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unregister chat: %w", err)
+	}
+
+	return reply("Unregistered, this chat won't get signals anymore."), nil
+}
+
+// watchCommand subscribes to a bound strategy, subscribers share the bindings and their evaluation. Only admins
+// may watch a strategy no one bound yet, the binding then lasts as long as a watchlist has it.
+func watchCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	if err := checkRegistered(c, cmd.ChatID); err != nil {
+		return nil, err
+	}
+
+	switch len(cmd.Args) {
+	case 0:
+		tickerSymbols, err := getTickerSymbols(c)
+		if err != nil {
+			return nil, err
+		}
+		return choose("Ticker?", "/watch", tickerSymbols), nil
+	case 1:
+		return choose("Timeframe?", "/watch "+cmd.Args[0], timeframe.AllowedTimeframes), nil
+	case 2:
+		strategies, err := getWatchableStrategies(c, cmd)
+		if err != nil {
+			return nil, err
+		}
+		if len(strategies) == 0 {
+			return reply("Nothing is bound to %s on %s yet.", cmd.Args[0], cmd.Args[1]), nil
+		}
+		return choose("Strategy?", fmt.Sprintf("/watch %s %s", cmd.Args[0], cmd.Args[1]), strategies), nil
+	}
+
+	req := &binding.RegisterBindingReq{TickerSymbol: cmd.Args[0], Timeframe: cmd.Args[1], Strategy: cmd.Args[2]}
+	if err := binding.ValidateRegisterBindingReq(req); err != nil {
+		return nil, err
+	}
+
+	if err := ensureBinding(c, cmd.ChatID, req); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	err := database.Client.InsertSubscription(ctx, &database.Subscription{
+		ChatID:       cmd.ChatID,
+		TickerSymbol: req.TickerSymbol,
+		Timeframe:    req.Timeframe,
+		Strategy:     req.Strategy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert subscription: %w", err)
+	}
+
+	return reply("Watching %s on %s %s.", req.Strategy, req.TickerSymbol, req.Timeframe), nil
+}
+
+// getWatchableStrategies offers admins every strategy and other chats the ones bound to the ticker and timeframe.
+func getWatchableStrategies(c context.Context, cmd *telegram.CommandRequest) ([]string, error) {
+	if telegram.Bot.IsAdmin(cmd.ChatID) {
+		return getStrategies(), nil
+	}
+
+	bindings, err := getBindings(c, cmd.Args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var strategies []string
+	for _, b := range bindings {
+		if b.Timeframe == cmd.Args[1] {
+			strategies = append(strategies, b.Strategy)
+		}
+	}
+	slices.Sort(strategies)
+
+	return strategies, nil
+}
+
+func ensureBinding(c context.Context, chatID int64, req *binding.RegisterBindingReq) error {
+	bindings, err := getBindings(c, req.TickerSymbol)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range bindings {
+		if b.Timeframe == req.Timeframe && b.Strategy == req.Strategy {
+			return nil
+		}
+	}
+
+	if !telegram.Bot.IsAdmin(chatID) {
+		return fmt.Errorf("%s is not bound to %s on %s, only admins can bind strategies",
+			req.Strategy, req.TickerSymbol, req.Timeframe)
+	}
+
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	if !database.Client.IsTickerRegistered(ctx, req.TickerSymbol) {
+		return fmt.Errorf("%s is not registered", req.TickerSymbol)
+	}
+
+	err = database.Client.InsertWatchlistBinding(ctx, req.TickerSymbol, req.Timeframe, req.Strategy, req.PriceSeries)
+	if err != nil {
+		return fmt.Errorf("insert binding: %w", err)
+	}
+
+	return nil
+}
+
+// unwatchCommand only offers what is on the chat's watchlist.
+func unwatchCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	subscriptions, err := getWatchlist(c, cmd.ChatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cmd.Args) < 3 {
+		var choices []telegram.Choice
+		for _, s := range subscriptions {
+			if len(cmd.Args) > 0 && s.TickerSymbol != cmd.Args[0] ||
+				len(cmd.Args) > 1 && s.Timeframe != cmd.Args[1] {
+				continue
+			}
+
+			choices = append(choices, telegram.Choice{
+				Label:   fmt.Sprintf("%s %s %s", s.TickerSymbol, s.Timeframe, s.Strategy),
+				Command: fmt.Sprintf("/unwatch %s %s %s", s.TickerSymbol, s.Timeframe, s.Strategy),
+			})
+		}
+
+		if len(choices) == 0 {
+			return reply("Nothing to remove from the watchlist."), nil
+		}
+
+		return &telegram.Reply{Text: "Remove which?", Choices: choices}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	err = database.Client.DeleteSubscription(ctx, &database.Subscription{
+		ChatID:       cmd.ChatID,
+		TickerSymbol: cmd.Args[0],
+		Timeframe:    cmd.Args[1],
+		Strategy:     cmd.Args[2],
+	})
+	if errorsStdLib.Is(err, sql.ErrNoRows) {
+		return reply("%s is not on the watchlist.", strings.Join(cmd.Args, " ")), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("delete subscription: %w", err)
+	}
+
+	return reply("Stopped watching %s on %s %s.", cmd.Args[2], cmd.Args[0], cmd.Args[1]), nil
+}
+
+func watchlistCommand(c context.Context, cmd *telegram.CommandRequest) (*telegram.Reply, error) {
+	subscriptions, err := getWatchlist(c, cmd.ChatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subscriptions) == 0 {
+		return reply("The watchlist is empty, add to it with /watch."), nil
+	}
+
+	var builder strings.Builder
+	for _, s := range subscriptions {
+		builder.WriteString(fmt.Sprintf("<b>%s</b> %s <code>%s</code>\n",
+			html.EscapeString(s.TickerSymbol), s.Timeframe, html.EscapeString(s.Strategy)))
+	}
+
+	return &telegram.Reply{Text: builder.String()}, nil
+}
+
+func getWatchlist(c context.Context, chatID int64) ([]database.Subscription, error) {
+	if err := checkRegistered(c, chatID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	subscriptions, err := database.Client.GetSubscriptionsByChat(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("get watchlist: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func checkRegistered(c context.Context, chatID int64) error {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	if !database.Client.IsSubscriber(ctx, chatID) {
+		return errNotRegistered
+	}
+
+	return nil
+}
+
+func getTickerSymbols(c context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	tickers, err := database.Client.GetTickers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tickers: %w", err)
+	}
+
+	symbols := make([]string, 0, len(tickers))
+	for _, t := range tickers {
+		symbols = append(symbols, t.Symbol)
+	}
+	slices.Sort(symbols)
+
+	return symbols, nil
+}
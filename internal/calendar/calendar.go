@@ -0,0 +1,266 @@
+package calendar
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/signalb/internal/ticker"
+	"github.com/signalb/internal/timeframe"
+)
+
+const (
+	hour4CandleLength = 4 * time.Hour
+
+	// maxSessionGap bounds how far we look for the next/previous session, longer than any market closure.
+	maxSessionGap = 14
+)
+
+var CalendarManager *Manager
+
+type Session struct {
+	Open       time.Time `json:"open"`
+	Close      time.Time `json:"close"`
+	EarlyClose bool      `json:"earlyClose"`
+}
+
+// Calendar describes when an exchange trades. Candle times for calendars follow the
+// convention of the stored price data: D1 candles start at midnight of the session date,
+// W1 candles at midnight of the week's Monday and H4 candles every 4 hours from session open.
+type Calendar interface {
+	Name() string
+	Location() *time.Location
+	// Session returns the trading session on the calendar date of day, if the market trades that day.
+	Session(day time.Time) (*Session, bool)
+}
+
+type Manager struct {
+	ClassToCalendar map[string]Calendar
+}
+
+func NewCalendarManager(classToCalendar map[string]Calendar) *Manager {
+	return &Manager{
+		ClassToCalendar: classToCalendar,
+	}
+}
+
+// GetCalendarByClass returns false for classes that trade around the clock, e.g. crypto.
+func (m *Manager) GetCalendarByClass(class string) (Calendar, bool) {
+	cal, ok := m.ClassToCalendar[class]
+	return cal, ok
+}
+
+func InitCalendars() {
+	usEquity, err := NewUSEquityCalendar()
+	if err != nil {
+		log.Fatalf("failed to load US equity calendar: %v", err)
+	}
+
+	forex, err := NewForexCalendar()
+	if err != nil {
+		log.Fatalf("failed to load forex calendar: %v", err)
+	}
+
+	commodity, err := NewCommodityCalendar()
+	if err != nil {
+		log.Fatalf("failed to load commodity calendar: %v", err)
+	}
+
+	// Indices are calculated from their constituents, so they follow the exchange session
+	CalendarManager = NewCalendarManager(map[string]Calendar{
+		ticker.StockClass:     usEquity,
+		ticker.IndexClass:     usEquity,
+		ticker.ForexClass:     forex,
+		ticker.CommodityClass: commodity,
+	})
+}
+
+func IsOpen(cal Calendar, t time.Time) bool {
+	session, ok := cal.Session(t)
+	return ok && !t.Before(session.Open) && t.Before(session.Close)
+}
+
+// SessionsBetween returns the sessions whose dates fall between from and to inclusive.
+func SessionsBetween(cal Calendar, from, to time.Time) []*Session {
+	loc := cal.Location()
+	day := midnight(from.In(loc))
+	last := midnight(to.In(loc))
+
+	var sessions []*Session
+	for !day.After(last) {
+		if session, ok := cal.Session(day); ok {
+			sessions = append(sessions, session)
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return sessions
+}
+
+// SessionsBack returns the date of the nth trading session counting back from end, end's own session included.
+func SessionsBack(cal Calendar, end time.Time, n int) time.Time {
+	day := midnight(end.In(cal.Location()))
+	found := 0
+	skipped := 0
+
+	for found < n && skipped < maxSessionGap {
+		if _, ok := cal.Session(day); ok {
+			found++
+			skipped = 0
+			if found == n {
+				break
+			}
+		} else {
+			skipped++
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return day
+}
+
+// CandleStart maps a time within a candle to the time the candle is stored under.
+func CandleStart(cal Calendar, tf string, t time.Time) time.Time {
+	t = t.In(cal.Location())
+
+	switch tf {
+	case timeframe.Week1:
+		return weekStart(t)
+	case timeframe.Hour4:
+		session, ok := cal.Session(t)
+		if !ok {
+			return t
+		}
+
+		blocks := sessionH4Blocks(session)
+		idx := 0
+		for idx < len(blocks)-1 && !t.Before(blocks[idx+1]) {
+			idx++
+		}
+		return blocks[idx]
+	default:
+		return midnight(t)
+	}
+}
+
+// CandleClose returns when the candle containing t stops changing.
+func CandleClose(cal Calendar, tf string, t time.Time) time.Time {
+	start := CandleStart(cal, tf, t)
+
+	switch tf {
+	case timeframe.Week1:
+		sessions := SessionsBetween(cal, start, start.AddDate(0, 0, 6))
+		if len(sessions) == 0 {
+			return start.AddDate(0, 0, 7)
+		}
+		return sessions[len(sessions)-1].Close
+	case timeframe.Hour4:
+		session, ok := cal.Session(start)
+		if !ok {
+			return start.Add(hour4CandleLength)
+		}
+		end := start.Add(hour4CandleLength)
+		if end.After(session.Close) {
+			end = session.Close
+		}
+		return end
+	default:
+		session, ok := cal.Session(start)
+		if !ok {
+			return start.AddDate(0, 0, 1)
+		}
+		return session.Close
+	}
+}
+
+func IsCandleFinal(cal Calendar, tf string, t, now time.Time) bool {
+	return !now.Before(CandleClose(cal, tf, t))
+}
+
+// ExpectedCandles returns the start of every candle that should exist between from and to inclusive.
+func ExpectedCandles(cal Calendar, tf string, from, to time.Time) []time.Time {
+	from = CandleStart(cal, tf, from)
+
+	var (
+		candles []time.Time
+		seen    = make(map[time.Time]bool)
+	)
+
+	for _, session := range SessionsBetween(cal, from, to) {
+		var starts []time.Time
+
+		switch tf {
+		case timeframe.Week1:
+			starts = []time.Time{weekStart(session.Open)}
+		case timeframe.Hour4:
+			starts = sessionH4Blocks(session)
+		default:
+			starts = []time.Time{midnight(session.Open)}
+		}
+
+		for _, start := range starts {
+			if seen[start] || start.Before(from) || start.After(to) {
+				continue
+			}
+			seen[start] = true
+			candles = append(candles, start)
+		}
+	}
+
+	return candles
+}
+
+// FindGaps returns the candles that should exist between the earliest and latest of times but are missing.
+func FindGaps(cal Calendar, tf string, times []time.Time) []time.Time {
+	if len(times) < 2 {
+		return nil
+	}
+
+	present := make(map[time.Time]bool, len(times))
+	for _, t := range times {
+		present[CandleStart(cal, tf, t)] = true
+	}
+
+	sorted := make([]time.Time, len(times))
+	copy(sorted, times)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	var gaps []time.Time
+	for _, expected := range ExpectedCandles(cal, tf, sorted[0], sorted[len(sorted)-1]) {
+		if !present[expected] {
+			gaps = append(gaps, expected)
+		}
+	}
+
+	return gaps
+}
+
+// HasNewFinalCandle reports whether a candle after the one containing latest has closed by now.
+func HasNewFinalCandle(cal Calendar, tf string, latest, now time.Time) bool {
+	latestStart := CandleStart(cal, tf, latest)
+
+	for _, candle := range ExpectedCandles(cal, tf, latestStart, now) {
+		if candle.After(latestStart) && IsCandleFinal(cal, tf, candle, now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sessionH4Blocks(session *Session) []time.Time {
+	var blocks []time.Time
+	for start := session.Open; start.Before(session.Close); start = start.Add(hour4CandleLength) {
+		blocks = append(blocks, start)
+	}
+	return blocks
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func weekStart(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return midnight(t).AddDate(0, 0, -daysSinceMonday)
+}
@@ -0,0 +1,228 @@
+package calendar
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/timeframe"
+)
+
+func newUSEquity(t *testing.T) *USEquityCalendar {
+	t.Helper()
+
+	cal, err := NewUSEquityCalendar()
+	if err != nil {
+		t.Fatalf("load calendar: %v", err)
+	}
+
+	return cal
+}
+
+// at parses "2006-01-02 15:04" in the location.
+func at(t *testing.T, loc *time.Location, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatalf("parse %q: %v", value, err)
+	}
+
+	return parsed
+}
+
+func TestUSEquitySessions(t *testing.T) {
+	cal := newUSEquity(t)
+
+	tests := []struct {
+		day       string
+		open      bool
+		closeHour int
+		holiday   string
+	}{
+		{"2024-01-01", false, 0, "New Year's Day"},
+		{"2024-01-15", false, 0, "Martin Luther King Jr. Day"},
+		{"2024-03-29", false, 0, "Good Friday"},
+		{"2024-05-27", false, 0, "Memorial Day"},
+		{"2024-06-19", false, 0, "Juneteenth"},
+		{"2024-11-28", false, 0, "Thanksgiving Day"},
+		{"2024-12-25", false, 0, "Christmas Day"},
+		// Before 2022 Juneteenth was a trading day
+		{"2021-06-18", true, 16, ""},
+		// New Year's Day 2022 was a Saturday, the Friday before still traded
+		{"2021-12-31", true, 16, ""},
+		// Independence Day 2026 is a Saturday, observed on the Friday without an early close the day before
+		{"2026-07-03", false, 0, "Independence Day"},
+		{"2026-07-02", true, 16, ""},
+		{"2024-07-03", true, 13, ""},
+		{"2024-11-29", true, 13, ""},
+		{"2024-12-24", true, 13, ""},
+		{"2024-05-10", true, 16, ""},
+		{"2024-05-11", false, 0, ""},
+	}
+
+	for _, tt := range tests {
+		day := at(t, cal.Location(), tt.day+" 12:00")
+
+		session, ok := cal.Session(day)
+		if ok != tt.open {
+			t.Errorf("%s: open %t, want %t", tt.day, ok, tt.open)
+			continue
+		}
+
+		if name, _ := cal.Holiday(day); name != tt.holiday {
+			t.Errorf("%s: holiday %q, want %q", tt.day, name, tt.holiday)
+		}
+
+		if !ok {
+			continue
+		}
+
+		if session.Open.Hour() != 9 || session.Open.Minute() != 30 || session.Close.Hour() != tt.closeHour {
+			t.Errorf("%s: session %s to %s, want 09:30 to %d:00", tt.day, session.Open, session.Close, tt.closeHour)
+		}
+		if session.EarlyClose != (tt.closeHour == 13) {
+			t.Errorf("%s: early close %t", tt.day, session.EarlyClose)
+		}
+	}
+}
+
+func TestUSEquityH4Blocks(t *testing.T) {
+	cal := newUSEquity(t)
+	loc := cal.Location()
+
+	tests := []struct {
+		name   string
+		t      string
+		start  string
+		close  string
+		blocks []string
+	}{
+		{"morning", "2024-05-10 10:00", "2024-05-10 09:30", "2024-05-10 13:30",
+			[]string{"2024-05-10 09:30", "2024-05-10 13:30"}},
+		// The afternoon block is cut short by the close
+		{"afternoon", "2024-05-10 15:59", "2024-05-10 13:30", "2024-05-10 16:00",
+			[]string{"2024-05-10 09:30", "2024-05-10 13:30"}},
+		// An early close leaves a single block ending at 13:00
+		{"early close", "2024-11-29 12:00", "2024-11-29 09:30", "2024-11-29 13:00",
+			[]string{"2024-11-29 09:30"}},
+	}
+
+	for _, tt := range tests {
+		tm := at(t, loc, tt.t)
+
+		if got := CandleStart(cal, timeframe.Hour4, tm); !got.Equal(at(t, loc, tt.start)) {
+			t.Errorf("%s: candle start %s, want %s", tt.name, got, tt.start)
+		}
+		if got := CandleClose(cal, timeframe.Hour4, tm); !got.Equal(at(t, loc, tt.close)) {
+			t.Errorf("%s: candle close %s, want %s", tt.name, got, tt.close)
+		}
+
+		day := at(t, loc, tt.t[:10]+" 00:00")
+		var want []time.Time
+		for _, block := range tt.blocks {
+			want = append(want, at(t, loc, block))
+		}
+		got := ExpectedCandles(cal, timeframe.Hour4, day, day.AddDate(0, 0, 1))
+		if !slices.EqualFunc(got, want, time.Time.Equal) {
+			t.Errorf("%s: blocks %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestUSEquityCandles(t *testing.T) {
+	cal := newUSEquity(t)
+	loc := cal.Location()
+
+	// The week of Thanksgiving 2024 closes at the early close of the Friday after
+	thanksgivingWeek := at(t, loc, "2024-11-27 11:00")
+	if got := CandleStart(cal, timeframe.Week1, thanksgivingWeek); !got.Equal(at(t, loc, "2024-11-25 00:00")) {
+		t.Errorf("week start %s, want Monday 2024-11-25", got)
+	}
+	if got := CandleClose(cal, timeframe.Week1, thanksgivingWeek); !got.Equal(at(t, loc, "2024-11-29 13:00")) {
+		t.Errorf("week close %s, want 2024-11-29 13:00", got)
+	}
+
+	// Independence Day isn't a gap, the day before it is when missing
+	days := func(values ...string) []time.Time {
+		var times []time.Time
+		for _, value := range values {
+			times = append(times, at(t, loc, value+" 00:00"))
+		}
+		return times
+	}
+
+	if gaps := FindGaps(cal, timeframe.Day1, days("2024-07-03", "2024-07-05")); len(gaps) != 0 {
+		t.Errorf("gaps %v over Independence Day, want none", gaps)
+	}
+	gaps := FindGaps(cal, timeframe.Day1, days("2024-07-02", "2024-07-05"))
+	if !slices.EqualFunc(gaps, days("2024-07-03"), time.Time.Equal) {
+		t.Errorf("gaps %v, want 2024-07-03", gaps)
+	}
+
+	// Friday's candle is the latest to close over the weekend
+	latest := at(t, loc, "2024-05-10 00:00")
+	if HasNewFinalCandle(cal, timeframe.Day1, latest, at(t, loc, "2024-05-13 15:59")) {
+		t.Error("a new candle closed before Monday's close")
+	}
+	if !HasNewFinalCandle(cal, timeframe.Day1, latest, at(t, loc, "2024-05-13 16:00")) {
+		t.Error("Monday's candle didn't close at 16:00")
+	}
+}
+
+func TestTwentyFourFiveFridayClose(t *testing.T) {
+	forex, err := NewForexCalendar()
+	if err != nil {
+		t.Fatalf("load forex calendar: %v", err)
+	}
+
+	commodity, err := NewCommodityCalendar()
+	if err != nil {
+		t.Fatalf("load commodity calendar: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		cal   Calendar
+		day   string
+		open  bool
+		close string
+	}{
+		// 17:00 in New York is 21:00 UTC in summer and 22:00 UTC in winter
+		{"forex summer Friday", forex, "2024-05-10", true, "2024-05-10 21:00"},
+		{"forex winter Friday", forex, "2024-01-12", true, "2024-01-12 22:00"},
+		{"forex Thursday", forex, "2024-05-09", true, "2024-05-10 00:00"},
+		{"forex Saturday", forex, "2024-05-11", false, ""},
+		{"forex Christmas", forex, "2024-12-25", false, ""},
+		{"forex Good Friday", forex, "2024-03-29", true, "2024-03-29 21:00"},
+		// 16:00 in Chicago is 21:00 UTC in summer
+		{"commodity summer Friday", commodity, "2024-05-10", true, "2024-05-10 21:00"},
+		{"commodity Good Friday", commodity, "2024-03-29", false, ""},
+		{"commodity New Year", commodity, "2024-01-01", false, ""},
+	}
+
+	for _, tt := range tests {
+		day := at(t, time.UTC, tt.day+" 12:00")
+
+		session, ok := tt.cal.Session(day)
+		if ok != tt.open {
+			t.Errorf("%s: open %t, want %t", tt.name, ok, tt.open)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if !session.Open.Equal(at(t, time.UTC, tt.day+" 00:00")) || !session.Close.Equal(at(t, time.UTC, tt.close)) {
+			t.Errorf("%s: session %s to %s, want midnight to %s UTC", tt.name, session.Open, session.Close, tt.close)
+		}
+
+		// The D1 candle is final from the close on
+		closeTime := at(t, time.UTC, tt.close)
+		if IsCandleFinal(tt.cal, timeframe.Day1, day, closeTime.Add(-time.Minute)) ||
+			!IsCandleFinal(tt.cal, timeframe.Day1, day, closeTime) {
+			t.Errorf("%s: candle not final exactly at %s", tt.name, tt.close)
+		}
+	}
+}
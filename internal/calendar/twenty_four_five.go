@@ -0,0 +1,84 @@
+package calendar
+
+import "time"
+
+// TwentyFourFiveCalendar is for markets trading around the clock on weekdays, e.g. FX and futures on Globex.
+// Sessions are UTC calendar days, so D1 candles match the UTC dated bars providers return for these markets.
+// Trading between the Sunday open and midnight UTC has no session, bars from it are left out of candles.
+type TwentyFourFiveCalendar struct {
+	name string
+	// weeklyCloseHour is the Friday hour the market closes at, in closeLocation
+	weeklyCloseHour int
+	closeLocation   *time.Location
+	closedDays      func(day time.Time) bool
+}
+
+func (c *TwentyFourFiveCalendar) Name() string {
+	return c.name
+}
+
+func (c *TwentyFourFiveCalendar) Location() *time.Location {
+	return time.UTC
+}
+
+func (c *TwentyFourFiveCalendar) Session(day time.Time) (*Session, bool) {
+	day = day.UTC()
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return nil, false
+	}
+
+	if c.closedDays != nil && c.closedDays(day) {
+		return nil, false
+	}
+
+	open := midnight(day)
+	session := &Session{
+		Open:  open,
+		Close: open.AddDate(0, 0, 1),
+	}
+
+	if day.Weekday() == time.Friday {
+		session.Close = time.Date(day.Year(), day.Month(), day.Day(), c.weeklyCloseHour, 0, 0, 0, c.closeLocation).UTC()
+	}
+
+	return session, true
+}
+
+// NewForexCalendar trades from the Sunday 17:00 New York open to the Friday 17:00 New York close, dark on
+// Christmas and New Year's Day when liquidity providers stop quoting.
+func NewForexCalendar() (*TwentyFourFiveCalendar, error) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwentyFourFiveCalendar{
+		name:            "forex",
+		weeklyCloseHour: 17,
+		closeLocation:   newYork,
+		closedDays:      isChristmasOrNewYear,
+	}, nil
+}
+
+// NewCommodityCalendar follows CME Globex metals and energy, trading until 16:00 Chicago on Fridays and
+// closed on Good Friday, Christmas and New Year's Day. The daily maintenance hour is ignored.
+func NewCommodityCalendar() (*TwentyFourFiveCalendar, error) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwentyFourFiveCalendar{
+		name:            "cme_globex",
+		weeklyCloseHour: 16,
+		closeLocation:   chicago,
+		closedDays: func(day time.Time) bool {
+			goodFriday := easterSunday(day.Year(), time.UTC).AddDate(0, 0, -2)
+			return isChristmasOrNewYear(day) || newDateKey(day) == newDateKey(goodFriday)
+		},
+	}, nil
+}
+
+func isChristmasOrNewYear(day time.Time) bool {
+	return (day.Month() == time.December && day.Day() == 25) || (day.Month() == time.January && day.Day() == 1)
+}
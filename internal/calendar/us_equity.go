@@ -0,0 +1,194 @@
+package calendar
+
+import (
+	"sync"
+	"time"
+)
+
+// USEquityCalendar follows the NYSE/Nasdaq regular session, holiday and early close schedule.
+type USEquityCalendar struct {
+	location *time.Location
+
+	mu    sync.Mutex
+	years map[int]*yearSchedule
+}
+
+type yearSchedule struct {
+	holidays    map[dateKey]string
+	earlyCloses map[dateKey]string
+}
+
+type dateKey struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func newDateKey(t time.Time) dateKey {
+	return dateKey{year: t.Year(), month: t.Month(), day: t.Day()}
+}
+
+func NewUSEquityCalendar() (*USEquityCalendar, error) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, err
+	}
+
+	return &USEquityCalendar{
+		location: location,
+		years:    make(map[int]*yearSchedule),
+	}, nil
+}
+
+func (c *USEquityCalendar) Name() string {
+	return "us_equity"
+}
+
+func (c *USEquityCalendar) Location() *time.Location {
+	return c.location
+}
+
+func (c *USEquityCalendar) Session(day time.Time) (*Session, bool) {
+	day = day.In(c.location)
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return nil, false
+	}
+
+	schedule := c.scheduleForYear(day.Year())
+	key := newDateKey(day)
+
+	if _, ok := schedule.holidays[key]; ok {
+		return nil, false
+	}
+
+	closeHour := 16
+	_, isEarlyClose := schedule.earlyCloses[key]
+	if isEarlyClose {
+		closeHour = 13
+	}
+
+	return &Session{
+		Open:       time.Date(day.Year(), day.Month(), day.Day(), 9, 30, 0, 0, c.location),
+		Close:      time.Date(day.Year(), day.Month(), day.Day(), closeHour, 0, 0, 0, c.location),
+		EarlyClose: isEarlyClose,
+	}, true
+}
+
+// Holiday returns the name of the market holiday on day, if any.
+func (c *USEquityCalendar) Holiday(day time.Time) (string, bool) {
+	day = day.In(c.location)
+	name, ok := c.scheduleForYear(day.Year()).holidays[newDateKey(day)]
+	return name, ok
+}
+
+func (c *USEquityCalendar) scheduleForYear(year int) *yearSchedule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if schedule, ok := c.years[year]; ok {
+		return schedule
+	}
+
+	schedule := c.buildSchedule(year)
+	c.years[year] = schedule
+
+	return schedule
+}
+
+func (c *USEquityCalendar) buildSchedule(year int) *yearSchedule {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, c.location)
+	}
+
+	holidays := map[dateKey]string{}
+	addHoliday := func(t time.Time, name string) {
+		holidays[newDateKey(t)] = name
+	}
+
+	// NYSE doesn't close on the Friday before when New Year's Day falls on a Saturday
+	if newYear := date(time.January, 1); newYear.Weekday() != time.Saturday {
+		addHoliday(observed(newYear), "New Year's Day")
+	}
+
+	addHoliday(nthWeekday(year, time.January, time.Monday, 3, c.location), "Martin Luther King Jr. Day")
+	addHoliday(nthWeekday(year, time.February, time.Monday, 3, c.location), "Washington's Birthday")
+	addHoliday(easterSunday(year, c.location).AddDate(0, 0, -2), "Good Friday")
+	addHoliday(lastWeekday(year, time.May, time.Monday, c.location), "Memorial Day")
+
+	if year >= 2022 {
+		addHoliday(observed(date(time.June, 19)), "Juneteenth")
+	}
+
+	addHoliday(observed(date(time.July, 4)), "Independence Day")
+	addHoliday(nthWeekday(year, time.September, time.Monday, 1, c.location), "Labor Day")
+
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4, c.location)
+	addHoliday(thanksgiving, "Thanksgiving Day")
+	addHoliday(observed(date(time.December, 25)), "Christmas Day")
+
+	earlyCloses := map[dateKey]string{}
+	addEarlyClose := func(t time.Time, name string) {
+		key := newDateKey(t)
+		if _, isHoliday := holidays[key]; isHoliday {
+			return
+		}
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			return
+		}
+		earlyCloses[key] = name
+	}
+
+	addEarlyClose(date(time.July, 3), "Day before Independence Day")
+	addEarlyClose(thanksgiving.AddDate(0, 0, 1), "Day after Thanksgiving")
+	addEarlyClose(date(time.December, 24), "Christmas Eve")
+
+	return &yearSchedule{
+		holidays:    holidays,
+		earlyCloses: earlyCloses,
+	}
+}
+
+// observed moves a fixed-date holiday falling on a weekend to the nearest weekday.
+func observed(t time.Time) time.Time {
+	if t.Weekday() == time.Saturday {
+		return t.AddDate(0, 0, -1)
+	}
+
+	if t.Weekday() == time.Sunday {
+		return t.AddDate(0, 0, 1)
+	}
+
+	return t
+}
+
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+(n-1)*7)
+}
+
+func lastWeekday(year int, month time.Month, weekday time.Weekday, loc *time.Location) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// Reference https://en.wikipedia.org/wiki/Date_of_Easter#Anonymous_Gregorian_algorithm
+func easterSunday(year int, loc *time.Location) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := ((h + l - 7*m + 114) % 31) + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
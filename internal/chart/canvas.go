@@ -0,0 +1,80 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+)
+
+// canvas draws the few primitives a chart needs straight onto an RGBA image.
+type canvas struct {
+	img *image.RGBA
+}
+
+func newCanvas(width, height int, background color.RGBA) *canvas {
+	cv := &canvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	cv.fillRect(0, 0, width, height, background)
+
+	return cv
+}
+
+func (cv *canvas) set(x, y int, col color.RGBA) {
+	if (image.Point{X: x, Y: y}).In(cv.img.Rect) {
+		cv.img.SetRGBA(x, y, col)
+	}
+}
+
+func (cv *canvas) fillRect(x, y, width, height int, col color.RGBA) {
+	for dy := 0; dy < height; dy++ {
+		for dx := 0; dx < width; dx++ {
+			cv.set(x+dx, y+dy, col)
+		}
+	}
+}
+
+// drawLine is Bresenham's algorithm, thickness grows the line downwards and to the right.
+func (cv *canvas) drawLine(x0, y0, x1, y1, thickness int, col color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+	for {
+		cv.fillRect(x0, y0, thickness, thickness, col)
+
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawDashedHLine draws dash pixels on, then dash pixels off.
+func (cv *canvas) drawDashedHLine(x0, x1, y, dash int, col color.RGBA) {
+	for x := x0; x <= x1; x++ {
+		if (x-x0)/dash%2 == 0 {
+			cv.set(x, y, col)
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
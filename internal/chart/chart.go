@@ -0,0 +1,281 @@
+// Package chart renders stored price series with their strategy overlays to PNG, using only the
+// standard library.
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	width       = 1200
+	priceHeight = 600
+	panelHeight = 200
+
+	marginLeft   = 16
+	marginRight  = 136
+	marginTop    = 64
+	marginBottom = 40
+	panelGap     = 24
+
+	textScale   = 2
+	yAxisTicks  = 6
+	xAxisLabels = 5
+)
+
+var (
+	backgroundColor = color.RGBA{R: 19, G: 23, B: 34, A: 255}
+	gridColor       = color.RGBA{R: 42, G: 46, B: 57, A: 255}
+	textColor       = color.RGBA{R: 209, G: 212, B: 220, A: 255}
+	priceColor      = color.RGBA{R: 41, G: 98, B: 255, A: 255}
+
+	// LineColors are used in turn for overlay lines
+	LineColors = []color.RGBA{
+		{R: 255, G: 152, B: 0, A: 255},
+		{R: 0, G: 188, B: 212, A: 255},
+		{R: 233, G: 30, B: 99, A: 255},
+		{R: 139, G: 195, B: 74, A: 255},
+	}
+	LevelColor     = color.RGBA{R: 120, G: 123, B: 134, A: 255}
+	BuyLevelColor  = color.RGBA{R: 38, G: 166, B: 154, A: 255}
+	SellLevelColor = color.RGBA{R: 239, G: 83, B: 80, A: 255}
+	PanelColor     = color.RGBA{R: 126, G: 87, B: 194, A: 255}
+)
+
+// Line is an overlay drawn over the prices, Values line up with them and NaN leaves a gap.
+type Line struct {
+	Label  string
+	Values []float64
+	Color  color.RGBA
+}
+
+// Level is a dashed horizontal line, like a Fibonacci retracement or an RSI zone.
+type Level struct {
+	Label string
+	Value float64
+	Color color.RGBA
+}
+
+// Panel is an indicator drawn below the prices on its own scale, like RSI.
+type Panel struct {
+	Label    string
+	Values   []float64
+	Min, Max float64
+	Levels   []Level
+}
+
+// Spec is everything drawn on a chart. Times and Prices are oldest first.
+type Spec struct {
+	Title  string
+	Times  []time.Time
+	Prices []float64
+	Lines  []Line
+	Levels []Level
+	Panel  *Panel
+}
+
+// plotArea maps values and candle indexes to pixels inside a rectangle.
+type plotArea struct {
+	left, top, right, bottom int
+	min, max                 float64
+	candles                  int
+}
+
+func (pa *plotArea) x(i int) int {
+	if pa.candles < 2 {
+		return pa.left
+	}
+
+	return pa.left + i*(pa.right-pa.left)/(pa.candles-1)
+}
+
+func (pa *plotArea) y(value float64) int {
+	return pa.bottom - int(math.Round((value-pa.min)/(pa.max-pa.min)*float64(pa.bottom-pa.top)))
+}
+
+// Render draws the spec as a PNG.
+func Render(spec *Spec) ([]byte, error) {
+	if len(spec.Prices) < 2 {
+		return nil, errors.New("at least 2 prices are needed to draw a chart")
+	}
+
+	if len(spec.Times) != len(spec.Prices) {
+		return nil, fmt.Errorf("%d times for %d prices", len(spec.Times), len(spec.Prices))
+	}
+
+	height := priceHeight
+	if spec.Panel != nil {
+		height += panelGap + panelHeight
+	}
+
+	cv := newCanvas(width, height, backgroundColor)
+
+	low, high := priceRange(spec)
+	priceArea := &plotArea{
+		left:    marginLeft,
+		top:     marginTop,
+		right:   width - marginRight,
+		bottom:  priceHeight - marginBottom,
+		min:     low,
+		max:     high,
+		candles: len(spec.Prices),
+	}
+
+	drawGrid(cv, priceArea, yAxisTicks)
+	drawXAxis(cv, priceArea, spec.Times, height-marginBottom+textScale*4)
+
+	for _, level := range spec.Levels {
+		drawLevel(cv, priceArea, level)
+	}
+
+	for _, line := range spec.Lines {
+		drawSeries(cv, priceArea, line.Values, 2, line.Color)
+	}
+	drawSeries(cv, priceArea, spec.Prices, 2, priceColor)
+
+	if spec.Panel != nil {
+		panelArea := &plotArea{
+			left:    marginLeft,
+			top:     priceHeight + panelGap,
+			right:   width - marginRight,
+			bottom:  height - marginBottom,
+			min:     spec.Panel.Min,
+			max:     spec.Panel.Max,
+			candles: len(spec.Prices),
+		}
+
+		drawGrid(cv, panelArea, 2)
+		for _, level := range spec.Panel.Levels {
+			drawLevel(cv, panelArea, level)
+		}
+		drawSeries(cv, panelArea, spec.Panel.Values, 2, PanelColor)
+		cv.drawText(panelArea.left+8, panelArea.top+8, spec.Panel.Label, textScale, PanelColor)
+	}
+
+	drawLegend(cv, spec)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, cv.img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// priceRange covers the prices and overlays with some room above and below.
+func priceRange(spec *Spec) (float64, float64) {
+	low, high := math.Inf(1), math.Inf(-1)
+	include := func(value float64) {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return
+		}
+		low, high = math.Min(low, value), math.Max(high, value)
+	}
+
+	for _, price := range spec.Prices {
+		include(price)
+	}
+	for _, line := range spec.Lines {
+		for _, value := range line.Values {
+			include(value)
+		}
+	}
+	for _, level := range spec.Levels {
+		include(level.Value)
+	}
+
+	padding := (high - low) * 0.05
+	if padding == 0 {
+		padding = math.Max(math.Abs(high)*0.01, 1e-9)
+	}
+
+	return low - padding, high + padding
+}
+
+// drawGrid draws ticks+1 horizontal lines with their values on the right.
+func drawGrid(cv *canvas, area *plotArea, ticks int) {
+	for i := 0; i <= ticks; i++ {
+		value := area.min + (area.max-area.min)*float64(i)/float64(ticks)
+		y := area.y(value)
+
+		cv.drawLine(area.left, y, area.right, y, 1, gridColor)
+		cv.drawText(area.right+8, y-textHeight(textScale)/2, FormatValue(value), textScale, textColor)
+	}
+}
+
+func drawXAxis(cv *canvas, area *plotArea, times []time.Time, labelY int) {
+	layout := time.DateOnly
+	if len(times) > 1 && times[1].Sub(times[0]) < 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+
+	for i := 0; i < xAxisLabels; i++ {
+		idx := i * (len(times) - 1) / (xAxisLabels - 1)
+		x := area.x(idx)
+		label := times[idx].Format(layout)
+
+		cv.drawLine(x, area.top, x, area.bottom, 1, gridColor)
+
+		labelX := x - textWidth(label, textScale)/2
+		labelX = max(labelX, area.left)
+		labelX = min(labelX, area.right-textWidth(label, textScale))
+		cv.drawText(labelX, labelY, label, textScale, textColor)
+	}
+}
+
+func drawLevel(cv *canvas, area *plotArea, level Level) {
+	if level.Value < area.min || level.Value > area.max {
+		return
+	}
+
+	y := area.y(level.Value)
+	cv.drawDashedHLine(area.left, area.right, y, 6, level.Color)
+	cv.drawText(area.left+8, y-textHeight(textScale)-3, level.Label, textScale, level.Color)
+}
+
+// drawSeries connects the values that aren't NaN.
+func drawSeries(cv *canvas, area *plotArea, values []float64, thickness int, col color.RGBA) {
+	prev := -1
+	for i, value := range values {
+		if math.IsNaN(value) {
+			prev = -1
+			continue
+		}
+
+		if prev >= 0 {
+			cv.drawLine(area.x(prev), area.y(values[prev]), area.x(i), area.y(value), thickness, col)
+		}
+		prev = i
+	}
+}
+
+// drawLegend writes the title and the overlays, each in its color, with the latest price.
+func drawLegend(cv *canvas, spec *Spec) {
+	x, y := marginLeft, 12
+	cv.drawText(x, y, spec.Title, textScale, textColor)
+
+	y += textHeight(textScale) + 10
+	latest := fmt.Sprintf("PRICE %s", FormatValue(spec.Prices[len(spec.Prices)-1]))
+	cv.drawText(x, y, latest, textScale, priceColor)
+	x += textWidth(latest, textScale) + 24
+
+	for _, line := range spec.Lines {
+		cv.drawText(x, y, line.Label, textScale, line.Color)
+		x += textWidth(line.Label, textScale) + 24
+	}
+}
+
+// FormatValue keeps 4 significant digits under 1, like the strategies' messages.
+func FormatValue(value float64) string {
+	if math.Abs(value) >= 1 {
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	}
+
+	return strconv.FormatFloat(value, 'g', 4, 64)
+}
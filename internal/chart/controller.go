@@ -0,0 +1,63 @@
+package chart
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/errors"
+	"github.com/signalb/internal/timeframe"
+)
+
+// GetChartController serves the PNG chart of a ticker. ?strategies=rsi30,sma200 picks the overlays,
+// the ticker's bindings are used without it, ?series=adjusted|raw overrides the prices the bindings are
+// evaluated on and ?candles sets how many candles are drawn.
+func GetChartController(c *gin.Context) {
+	tf := c.Param("timeframe")
+	ticker := c.Param("ticker")
+
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return
+	}
+
+	candles, err := strconv.Atoi(c.DefaultQuery("candles", strconv.Itoa(DefaultCandles)))
+	if err != nil || candles < 2 || candles > MaxCandles {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("candles must be between 2 and %d", MaxCandles)))
+		return
+	}
+
+	series := c.Query("series")
+	if series != "" && !slices.Contains(database.AllowedPriceSeries, series) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid price series: %v", database.AllowedPriceSeries)))
+		return
+	}
+
+	var strategies []string
+	if val := c.Query("strategies"); val != "" {
+		strategies = strings.Split(val, ",")
+	}
+
+	spec, err := LoadSignalChart(c.Request.Context(), ticker, tf, strategies, series, candles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	img, err := Render(spec)
+	if err != nil {
+		c.JSON(http.StatusNotFound,
+			errors.NewErrorResp(fmt.Errorf("render chart of %s %s: %w", ticker, tf, err)))
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", img)
+}
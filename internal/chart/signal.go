@@ -0,0 +1,235 @@
+package chart
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/indicators"
+)
+
+const (
+	DefaultCandles = 120
+	MaxCandles     = 1000
+
+	rsiPeriod = 14
+)
+
+var fibonacciRatios = []float64{0, 0.236, 0.382, 0.5, 0.618, 0.786, 1}
+
+// Overlays are the indicators a strategy looks at, drawn on the charts of its signals.
+type Overlays struct {
+	SMALengths []int
+	EMALengths []int
+	RSILevels  []float64
+	Bands      []BollingerBands
+}
+
+type BollingerBands struct {
+	Length     int
+	Multiplier float64
+}
+
+// strategyOverlays are the overlays of the strategies by name, registered by the strategies since the chart
+// can't depend on them. It's only written while the strategies are initialized.
+var strategyOverlays = map[string]Overlays{}
+
+// RegisterOverlays sets the overlays drawn for the strategy.
+func RegisterOverlays(strategy string, o Overlays) {
+	strategyOverlays[strategy] = o
+}
+
+// getOverlays are the overlays of all of the strategies, each drawn once. Unknown strategies draw nothing.
+func getOverlays(strategies []string) *Overlays {
+	o := &Overlays{}
+
+	for _, strategy := range strategies {
+		overlays := strategyOverlays[strategy]
+
+		o.SMALengths = appendNew(o.SMALengths, overlays.SMALengths...)
+		o.EMALengths = appendNew(o.EMALengths, overlays.EMALengths...)
+		o.RSILevels = appendNew(o.RSILevels, overlays.RSILevels...)
+		o.Bands = appendNew(o.Bands, overlays.Bands...)
+	}
+
+	slices.Sort(o.SMALengths)
+	slices.Sort(o.EMALengths)
+	slices.Sort(o.RSILevels)
+
+	return o
+}
+
+func appendNew[T comparable](values []T, news ...T) []T {
+	for _, v := range news {
+		if !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// LoadSignalChart builds the chart of the ticker's latest candles with the overlays of the strategies:
+// a line per SMA and EMA, the Bollinger Bands, an RSI panel with the RSI levels, and the Fibonacci retracements
+// of the window. The overlays are computed over all of the stored prices, like the strategies are evaluated,
+// since EMAs and Wilder's smoothing depend on every price before the window.
+// Without strategies the ticker's bindings in the timeframe are used. Without a series the prices are the
+// ones the drawn strategies are evaluated on: raw when all their bindings evaluate raw prices, adjusted otherwise.
+func LoadSignalChart(c context.Context, tickerSymbol, timeframe string, strategies []string, series string, candles int) (*Spec, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	bindings, err := database.Client.GetBindingsByTicker(ctx, tickerSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("get bindings: %w", err)
+	}
+
+	if len(strategies) == 0 {
+		for _, binding := range bindings {
+			if binding.Timeframe == timeframe {
+				strategies = append(strategies, binding.Strategy)
+			}
+		}
+	}
+
+	if series == "" {
+		series = getBindingsSeries(bindings, timeframe, strategies)
+	}
+
+	o := getOverlays(strategies)
+
+	data, err := database.Client.GetPriceByTicker(ctx, tickerSymbol, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("get prices: %w", err)
+	}
+
+	times := make([]time.Time, 0, len(data))
+	prices := make([]float64, 0, len(data))
+	for _, d := range data {
+		times = append(times, d.Time)
+		if series == database.RawSeries {
+			prices = append(prices, d.Price)
+		} else {
+			prices = append(prices, d.AdjPrice)
+		}
+	}
+
+	return newSignalSpec(fmt.Sprintf("%s %s", tickerSymbol, timeframe), times, prices, o, candles), nil
+}
+
+// getBindingsSeries is raw when every binding of the strategies in the timeframe evaluates raw prices.
+func getBindingsSeries(bindings []database.Binding, timeframe string, strategies []string) string {
+	raw := false
+	for _, binding := range bindings {
+		if binding.Timeframe != timeframe || !slices.Contains(strategies, binding.Strategy) {
+			continue
+		}
+
+		if binding.PriceSeries != database.RawSeries {
+			return database.AdjustedSeries
+		}
+		raw = true
+	}
+
+	if raw {
+		return database.RawSeries
+	}
+
+	return database.AdjustedSeries
+}
+
+// newSignalSpec computes the overlays over every price and keeps the last candles of them.
+func newSignalSpec(title string, times []time.Time, prices []float64, o *Overlays, candles int) *Spec {
+	start := max(len(prices)-candles, 0)
+
+	spec := &Spec{
+		Title:  title,
+		Times:  times[start:],
+		Prices: prices[start:],
+	}
+
+	for i, length := range o.SMALengths {
+		spec.Lines = append(spec.Lines, Line{
+			Label:  fmt.Sprintf("SMA %d", length),
+			Values: indicators.SMA(prices, length)[start:],
+			Color:  LineColors[i%len(LineColors)],
+		})
+	}
+
+	for i, length := range o.EMALengths {
+		spec.Lines = append(spec.Lines, Line{
+			Label:  fmt.Sprintf("EMA %d", length),
+			Values: indicators.EMA(prices, length)[start:],
+			Color:  LineColors[(len(o.SMALengths)+i)%len(LineColors)],
+		})
+	}
+
+	for i, bands := range o.Bands {
+		series := indicators.Bollinger(prices, bands.Length, bands.Multiplier)
+		col := LineColors[(len(o.SMALengths)+len(o.EMALengths)+i)%len(LineColors)]
+		label := fmt.Sprintf("BB %d %g", bands.Length, bands.Multiplier)
+
+		spec.Lines = append(spec.Lines,
+			Line{Label: label + " UPPER", Values: series.Upper[start:], Color: col},
+			Line{Label: label + " LOWER", Values: series.Lower[start:], Color: col},
+		)
+	}
+
+	spec.Levels = fibonacciLevels(spec.Prices)
+
+	if len(o.RSILevels) > 0 {
+		panel := &Panel{
+			Label:  fmt.Sprintf("RSI %d", rsiPeriod),
+			Values: indicators.RSI(prices, rsiPeriod)[start:],
+			Min:    0,
+			Max:    100,
+		}
+
+		for _, level := range o.RSILevels {
+			col := BuyLevelColor
+			if level > 50 {
+				col = SellLevelColor
+			}
+
+			panel.Levels = append(panel.Levels, Level{
+				Label: FormatValue(level),
+				Value: level,
+				Color: col,
+			})
+		}
+
+		spec.Panel = panel
+	}
+
+	return spec
+}
+
+// fibonacciLevels are the retracements from the window's high down to its low.
+func fibonacciLevels(prices []float64) []Level {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	high, low := slices.Max(prices), slices.Min(prices)
+	if high == low {
+		return nil
+	}
+
+	levels := make([]Level, 0, len(fibonacciRatios))
+	for _, ratio := range fibonacciRatios {
+		value := high - (high-low)*ratio
+		percent := strconv.FormatFloat(math.Round(ratio*1000)/10, 'f', -1, 64)
+
+		levels = append(levels, Level{
+			Label: fmt.Sprintf("FIB %s%% %s", percent, FormatValue(value)),
+			Value: value,
+			Color: LevelColor,
+		})
+	}
+
+	return levels
+}
@@ -0,0 +1,127 @@
+package chart
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+	"github.com/signalb/internal/indicators"
+)
+
+func TestGetBindingsSeries(t *testing.T) {
+	bindings := []database.Binding{
+		{TickerSymbol: "AAPL", Timeframe: "D1", Strategy: "rsi30buy", PriceSeries: database.RawSeries},
+		{TickerSymbol: "AAPL", Timeframe: "D1", Strategy: "sma200", PriceSeries: database.AdjustedSeries},
+		{TickerSymbol: "AAPL", Timeframe: "W1", Strategy: "sma200", PriceSeries: database.RawSeries},
+	}
+
+	tests := []struct {
+		timeframe  string
+		strategies []string
+		want       string
+	}{
+		{"D1", []string{"rsi30buy"}, database.RawSeries},
+		{"D1", []string{"rsi30buy", "sma200"}, database.AdjustedSeries},
+		{"W1", []string{"sma200"}, database.RawSeries},
+		// Strategies drawn without a binding keep the adjusted prices
+		{"H4", []string{"rsi30buy"}, database.AdjustedSeries},
+		{"D1", []string{"ema9x21golden"}, database.AdjustedSeries},
+	}
+
+	for _, tt := range tests {
+		if got := getBindingsSeries(bindings, tt.timeframe, tt.strategies); got != tt.want {
+			t.Errorf("series of %s %v = %s, want %s", tt.timeframe, tt.strategies, got, tt.want)
+		}
+	}
+}
+
+// useOverlays registers the strategies' overlays for the test.
+func useOverlays(t *testing.T, overlays map[string]Overlays) {
+	t.Helper()
+
+	for strategy, o := range overlays {
+		RegisterOverlays(strategy, o)
+	}
+
+	t.Cleanup(func() {
+		for strategy := range overlays {
+			delete(strategyOverlays, strategy)
+		}
+	})
+}
+
+func TestGetOverlays(t *testing.T) {
+	useOverlays(t, map[string]Overlays{
+		"sma200":         {SMALengths: []int{200}},
+		"sma50x200death": {SMALengths: []int{50, 200}},
+		"rsi70":          {RSILevels: []float64{70}},
+		"rsidiv40buy":    {RSILevels: []float64{40}},
+		"rsi30":          {RSILevels: []float64{30}},
+		"bb20buy":        {Bands: []BollingerBands{{Length: 20, Multiplier: 2}}},
+		"bb20squeeze":    {Bands: []BollingerBands{{Length: 20, Multiplier: 2}}},
+	})
+
+	o := getOverlays([]string{"sma200", "sma50x200death", "rsi70", "rsidiv40buy", "rsi30", "bb20buy", "bb20squeeze",
+		"fng"})
+
+	if !slices.Equal(o.SMALengths, []int{50, 200}) {
+		t.Errorf("SMA lengths = %v, want [50 200]", o.SMALengths)
+	}
+
+	if len(o.EMALengths) != 0 {
+		t.Errorf("EMA lengths = %v, want none", o.EMALengths)
+	}
+
+	if !slices.Equal(o.RSILevels, []float64{30, 40, 70}) {
+		t.Errorf("RSI levels = %v, want [30 40 70]", o.RSILevels)
+	}
+
+	if !slices.Equal(o.Bands, []BollingerBands{{Length: 20, Multiplier: 2}}) {
+		t.Errorf("bands = %v, want one BB 20 2", o.Bands)
+	}
+}
+
+func TestLoadSignalChartComputesOverlaysOverAllStoredPrices(t *testing.T) {
+	prices := make([]float64, 0, 400)
+	data := make([]database.PriceData, 0, 400)
+	for i := 0; i < 400; i++ {
+		price := 100 + 10*math.Sin(float64(i)/7) + float64(i)/10
+		prices = append(prices, price)
+		data = append(data, database.PriceData{TickerSymbol: "BTC", Time: time.Date(2023, 1, 1+i, 0, 0, 0, 0, time.UTC),
+			Price: price, AdjPrice: price})
+	}
+
+	dbtest.Use(t).SetPrices("BTC", "D1", data)
+	useOverlays(t, map[string]Overlays{
+		"ema9x21golden": {EMALengths: []int{9, 21}},
+		"rsi30buy":      {RSILevels: []float64{30}},
+	})
+
+	spec, err := LoadSignalChart(context.Background(), "BTC", "D1", []string{"ema9x21golden", "rsi30buy"}, "", 50)
+	if err != nil {
+		t.Fatalf("load chart: %v", err)
+	}
+
+	if len(spec.Prices) != 50 {
+		t.Fatalf("%d candles drawn, want 50", len(spec.Prices))
+	}
+
+	// The drawn values are the last ones of the indicators over the 400 stored prices
+	want := map[string][]float64{
+		"EMA 9":  indicators.EMA(prices, 9)[350:],
+		"EMA 21": indicators.EMA(prices, 21)[350:],
+	}
+	for _, line := range spec.Lines {
+		if !slices.Equal(line.Values, want[line.Label]) {
+			t.Errorf("%s = %v, want %v", line.Label, line.Values, want[line.Label])
+		}
+	}
+
+	if spec.Panel == nil || !slices.Equal(spec.Panel.Values, indicators.RSI(prices, rsiPeriod)[350:]) {
+		t.Error("RSI panel isn't the RSI over the stored prices")
+	}
+}
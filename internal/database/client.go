@@ -5,22 +5,63 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// PriceTimeLayout is how candle times are stored, as wall clock times without a zone.
+const PriceTimeLayout = "2006-01-02 15:04:05"
+
+// insertPriceBatchSize keeps a batch's 5 bound parameters per row under SQLite's default limit of 999.
+const insertPriceBatchSize = 190
+
 type Database interface {
 	InsertTicker(ctx context.Context, symbol, class string) error
 	GetTickers(ctx context.Context) ([]Ticker, error)
 	GetTickerClassBySymbol(ctx context.Context, tickerSymbol string) (string, error)
 	GetTickersByTimeframe(ctx context.Context, timeframe string) ([]*Ticker, error)
 	IsTickerRegistered(ctx context.Context, tickerSymbol string) bool
+	UpsertTickerProviderSymbols(ctx context.Context, tickerSymbol string, providerSymbols map[string]string) error
+	GetTickerProviderSymbols(ctx context.Context, tickerSymbol string) (map[string]string, error)
 
-	InsertBinding(ctx context.Context, tickerSymbol, timeframe, strategy string) error
+	InsertBinding(ctx context.Context, tickerSymbol, timeframe, strategy, priceSeries string) error
+	InsertWatchlistBinding(ctx context.Context, tickerSymbol, timeframe, strategy, priceSeries string) error
+	DeleteBinding(ctx context.Context, tickerSymbol, timeframe, strategy string) error
 	GetBindingsByTicker(ctx context.Context, tickerSymbol string) ([]Binding, error)
 	GetBindingsByTimeframe(ctx context.Context, timeframe string) ([]Binding, error)
 
-	DeletePriceData(ctx context.Context, tickerSymbol, timeframe string, limit int) error
+	TrimPriceData(ctx context.Context, tickerSymbol, timeframe string, keepCandles int, cutoff *time.Time, archive bool) (int64, error)
+	ScaleAdjustedPrices(ctx context.Context, tickerSymbol, timeframe string, before time.Time, factor float64) (int64, error)
+	InsertCorporateAction(ctx context.Context, action *CorporateAction) (bool, error)
+	GetCorporateActions(ctx context.Context, tickerSymbol string) ([]CorporateAction, error)
+	UpsertRetentionPolicy(ctx context.Context, policy *RetentionPolicy) error
+	InsertQuarantinedCandles(ctx context.Context, candles []QuarantinedCandle) error
+	GetQuarantinedCandles(ctx context.Context, tickerSymbol, timeframe string) ([]QuarantinedCandle, error)
+
+	InsertPriceAlert(ctx context.Context, alert *PriceAlert) (int64, error)
+	GetPriceAlerts(ctx context.Context, activeOnly bool) ([]PriceAlert, error)
+	MarkPriceAlertTriggered(ctx context.Context, id int64, triggeredAt time.Time) error
+	DeletePriceAlert(ctx context.Context, id int64) error
+
+	UpsertNotificationChannel(ctx context.Context, channel *NotificationChannel) error
+	GetNotificationChannels(ctx context.Context) ([]NotificationChannel, error)
+	InsertNotificationRoute(ctx context.Context, route *NotificationRoute) error
+	GetNotificationRoutes(ctx context.Context) ([]NotificationRoute, error)
+	DeleteNotificationRoute(ctx context.Context, route *NotificationRoute) error
+	UpsertSubscriber(ctx context.Context, subscriber *Subscriber) (bool, error)
+	DeleteSubscriber(ctx context.Context, chatID int64) error
+	IsSubscriber(ctx context.Context, chatID int64) bool
+	GetSubscribers(ctx context.Context) ([]Subscriber, error)
+	InsertSubscription(ctx context.Context, subscription *Subscription) error
+	DeleteSubscription(ctx context.Context, subscription *Subscription) error
+	GetSubscriptions(ctx context.Context) ([]Subscription, error)
+	GetSubscriptionsByChat(ctx context.Context, chatID int64) ([]Subscription, error)
+	GetRetentionPolicies(ctx context.Context) ([]RetentionPolicy, error)
+	GetRetentionPolicy(ctx context.Context, tickerSymbol, timeframe string) (*RetentionPolicy, error)
 	InsertPriceData(ctx context.Context, timeframe string, data []PriceData) error
-	GetPriceByTicker(ctx context.Context, tickerSymbol, timeframe string) ([]float64, error)
+	ReplacePriceData(ctx context.Context, tickerSymbol, timeframe string, data []PriceData, stale []time.Time) error
+	GetPriceByTicker(ctx context.Context, tickerSymbol, timeframe string) ([]PriceData, error)
+	QueryPriceData(ctx context.Context, tickerSymbol, timeframe string, filter *PriceFilter) ([]PriceData, error)
+	GetLatestPriceTime(ctx context.Context, tickerSymbol, timeframe string) (time.Time, error)
 
 	Close()
 	Ping(ctx context.Context) error
@@ -73,18 +114,27 @@ func (d *DBClient) GetTickers(ctx context.Context) ([]Ticker, error) {
 		tickers = append(tickers, ticker)
 	}
 
-	return tickers, err
+	providerSymbols, err := d.getAllTickerProviderSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tickers {
+		tickers[i].ProviderSymbols = providerSymbols[tickers[i].Symbol]
+	}
+
+	return tickers, nil
 }
 
 func (d *DBClient) GetTickerClassBySymbol(ctx context.Context, tickerSymbol string) (string, error) {
-	query := fmt.Sprintf(
-		`select class 
+	query :=
+		`select class
 		from ticker
-		where symbol = '%s'`, tickerSymbol)
+		where symbol = ?`
 
 	var class string
 
-	err := d.DB.QueryRowContext(ctx, query).Scan(&class)
+	err := d.DB.QueryRowContext(ctx, query, tickerSymbol).Scan(&class)
 	if err != nil {
 		return "", err
 	}
@@ -104,26 +154,118 @@ func (d *DBClient) IsTickerRegistered(ctx context.Context, tickerSymbol string)
 	return err == nil && count > 0
 }
 
+func (d *DBClient) UpsertTickerProviderSymbols(
+	ctx context.Context,
+	tickerSymbol string,
+	providerSymbols map[string]string,
+) error {
+	if len(providerSymbols) == 0 {
+		return nil
+	}
+
+	upsertQuery :=
+		`insert into ticker_provider_symbol (ticker_symbol, provider, symbol) values (?,?,?)
+		on conflict (ticker_symbol, provider) do update set symbol = excluded.symbol`
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for provider, symbol := range providerSymbols {
+		if _, err := tx.ExecContext(ctx, upsertQuery, tickerSymbol, provider, symbol); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (d *DBClient) GetTickerProviderSymbols(ctx context.Context, tickerSymbol string) (map[string]string, error) {
+	query :=
+		`select provider, symbol
+		from ticker_provider_symbol
+		where ticker_symbol = ?`
+
+	rows, err := d.DB.QueryContext(ctx, query, tickerSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	providerSymbols := make(map[string]string)
+	for rows.Next() {
+		var provider, symbol string
+
+		if err := rows.Scan(&provider, &symbol); err != nil {
+			return nil, err
+		}
+
+		providerSymbols[provider] = symbol
+	}
+
+	return providerSymbols, nil
+}
+
+func (d *DBClient) getAllTickerProviderSymbols(ctx context.Context) (map[string]map[string]string, error) {
+	query := `select ticker_symbol, provider, symbol from ticker_provider_symbol`
+
+	rows, err := d.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	results := make(map[string]map[string]string)
+	for rows.Next() {
+		var tickerSymbol, provider, symbol string
+
+		if err := rows.Scan(&tickerSymbol, &provider, &symbol); err != nil {
+			return nil, err
+		}
+
+		if results[tickerSymbol] == nil {
+			results[tickerSymbol] = make(map[string]string)
+		}
+		results[tickerSymbol][provider] = symbol
+	}
+
+	return results, nil
+}
+
 func (d *DBClient) GetBindingsByTicker(ctx context.Context, tickerSymbol string) ([]Binding, error) {
-	query := fmt.Sprintf(
-		`select ticker_symbol, timeframe, strategy 
-		from binding 
-		where ticker_symbol = '%s'`, tickerSymbol)
+	query :=
+		`select ticker_symbol, timeframe, strategy, price_series
+		from binding
+		where ticker_symbol = ?`
 
-	return d.getBindingsWithQuery(ctx, query)
+	return d.getBindingsWithQuery(ctx, query, tickerSymbol)
 }
 
 func (d *DBClient) GetBindingsByTimeframe(ctx context.Context, timeframe string) ([]Binding, error) {
-	query := fmt.Sprintf(
-		`select ticker_symbol, timeframe, strategy
-		from binding 
-		where timeframe = '%s'`, timeframe)
+	query :=
+		`select ticker_symbol, timeframe, strategy, price_series
+		from binding
+		where timeframe = ?`
 
-	return d.getBindingsWithQuery(ctx, query)
+	return d.getBindingsWithQuery(ctx, query, timeframe)
 }
 
-func (d *DBClient) getBindingsWithQuery(ctx context.Context, query string) ([]Binding, error) {
-	rows, err := d.DB.QueryContext(ctx, query)
+func (d *DBClient) getBindingsWithQuery(ctx context.Context, query string, args ...any) ([]Binding, error) {
+	rows, err := d.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -138,7 +280,7 @@ func (d *DBClient) getBindingsWithQuery(ctx context.Context, query string) ([]Bi
 	for rows.Next() {
 		var binding Binding
 
-		if err := rows.Scan(&binding.TickerSymbol, &binding.Timeframe, &binding.Strategy); err != nil {
+		if err := rows.Scan(&binding.TickerSymbol, &binding.Timeframe, &binding.Strategy, &binding.PriceSeries); err != nil {
 			return nil, err
 		}
 		results = append(results, binding)
@@ -147,67 +289,243 @@ func (d *DBClient) getBindingsWithQuery(ctx context.Context, query string) ([]Bi
 	return results, nil
 }
 
-func (d *DBClient) InsertBinding(ctx context.Context, tickerSymbol, timeframe, strategy string) error {
-	registerQuery := `insert into binding (ticker_symbol, timeframe, strategy) values (?,?,?)`
-	_, err := d.DB.ExecContext(ctx, registerQuery, tickerSymbol, timeframe, strategy)
+// InsertBinding takes over a binding only a watchlist created, it then stays when the watchlists drop it.
+func (d *DBClient) InsertBinding(ctx context.Context, tickerSymbol, timeframe, strategy, priceSeries string) error {
+	registerQuery :=
+		`insert into binding (ticker_symbol, timeframe, strategy, price_series) values (?,?,?,?)
+		on conflict (ticker_symbol, timeframe, strategy) do update
+		set price_series = excluded.price_series, from_watchlist = 0
+		where from_watchlist = 1`
+
+	res, err := d.DB.ExecContext(ctx, registerQuery, tickerSymbol, timeframe, strategy, priceSeries)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if inserted == 0 {
+		return fmt.Errorf("%s is already bound to %s on %s", strategy, tickerSymbol, timeframe)
+	}
+
+	return nil
+}
+
+// InsertWatchlistBinding binds the strategy for a subscription, it's deleted with the last subscription to it.
+// An existing binding is left as it is.
+func (d *DBClient) InsertWatchlistBinding(ctx context.Context, tickerSymbol, timeframe, strategy, priceSeries string) error {
+	registerQuery :=
+		`insert or ignore into binding (ticker_symbol, timeframe, strategy, price_series, from_watchlist)
+		values (?,?,?,?,1)`
+
+	_, err := d.DB.ExecContext(ctx, registerQuery, tickerSymbol, timeframe, strategy, priceSeries)
 	return err
 }
 
-func (d *DBClient) DeletePriceData(ctx context.Context, tickerSymbol, timeframe string, limit int) error {
+func (d *DBClient) DeleteBinding(ctx context.Context, tickerSymbol, timeframe, strategy string) error {
+	query := `delete from binding where ticker_symbol = ? and timeframe = ? and strategy = ?`
+
+	res, err := d.DB.ExecContext(ctx, query, tickerSymbol, timeframe, strategy)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// TrimPriceData removes the ticker's candles older than the cutoff, or beyond the newest keepCandles
+// when cutoff is nil, moving them to the timeframe's archive table first if archive is set.
+func (d *DBClient) TrimPriceData(
+	ctx context.Context,
+	tickerSymbol, timeframe string,
+	keepCandles int,
+	cutoff *time.Time,
+	archive bool,
+) (int64, error) {
 	table := "price_" + strings.ToLower(timeframe)
 
-	delQuery := fmt.Sprintf(
-		`delete
-		from %s
-		where (ticker_symbol,time) in (
-			select ticker_symbol, time
+	var (
+		condition string
+		args      []any
+	)
+
+	if cutoff != nil {
+		condition = "ticker_symbol = ? and time < ?"
+		args = []any{tickerSymbol, cutoff.Format(PriceTimeLayout)}
+	} else {
+		// Older than the keepCandles-th newest candle, nothing matches while there are fewer candles
+		condition = fmt.Sprintf(
+			`ticker_symbol = ? and time < (
+				select time
+				from %s
+				where ticker_symbol = ?
+				order by time desc
+				limit 1 offset ?)`, table)
+		args = []any{tickerSymbol, tickerSymbol, keepCandles - 1}
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if archive {
+		archiveQuery := fmt.Sprintf(
+			`insert or replace into %s_archive (ticker_symbol, time, price, adj_price, rounded, suspect)
+			select ticker_symbol, time, price, adj_price, rounded, suspect
 			from %s
-			where ticker_symbol = ?
-			order by time 
-			limit ?)`,
-		table, table)
+			where %s`, table, table, condition)
 
-	_, err := d.DB.ExecContext(ctx, delQuery, tickerSymbol, limit)
-	return err
+		if _, err := tx.ExecContext(ctx, archiveQuery, args...); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return 0, rbErr
+			}
+			return 0, err
+		}
+	}
+
+	delQuery := fmt.Sprintf(`delete from %s where %s`, table, condition)
+
+	res, err := tx.ExecContext(ctx, delQuery, args...)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return 0, rbErr
+		}
+		return 0, err
+	}
+
+	// Not every driver reports affected rows, the count is informational only
+	trimmed, _ := res.RowsAffected()
+
+	return trimmed, tx.Commit()
 }
 
-func (d *DBClient) InsertPriceData(ctx context.Context, timeframe string, data []PriceData) error {
-	table := "price_" + strings.ToLower(timeframe)
-	insQuery := `insert into %s (ticker_symbol,time,price) values ('%s','%s',%.2f);`
+// ScaleAdjustedPrices multiplies the adjusted prices of the ticker's candles before the given time,
+// back-adjusting stored history after a split or dividend.
+func (d *DBClient) ScaleAdjustedPrices(
+	ctx context.Context,
+	tickerSymbol, timeframe string,
+	before time.Time,
+	factor float64,
+) (int64, error) {
+	query := fmt.Sprintf(
+		`update price_%s
+		set adj_price = coalesce(adj_price, price) * ?
+		where ticker_symbol = ? and time < ?`, strings.ToLower(timeframe))
+
+	res, err := d.DB.ExecContext(ctx, query, factor, tickerSymbol, before.Format(PriceTimeLayout))
+	if err != nil {
+		return 0, err
+	}
 
-	var builder strings.Builder
+	// Not every driver reports affected rows, the count is informational only
+	scaled, _ := res.RowsAffected()
 
-	for i := len(data) - 1; i > -1; i-- {
-		currData := data[i]
-		timeString := currData.Time.Format("2006-01-02 15:04:05")
-		nxtQuery := fmt.Sprintf(insQuery, table, currData.TickerSymbol, timeString, currData.Price)
-		builder.WriteString(nxtQuery)
+	return scaled, nil
+}
+
+// InsertCorporateAction records the action unless it already is, reporting whether it was new.
+func (d *DBClient) InsertCorporateAction(ctx context.Context, action *CorporateAction) (bool, error) {
+	query :=
+		`insert or ignore into corporate_action (ticker_symbol, time, kind, ratio) values (?,?,?,?)`
+
+	res, err := d.DB.ExecContext(ctx, query, action.TickerSymbol, action.Time.Format(PriceTimeLayout), action.Kind, action.Ratio)
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted > 0, nil
+}
+
+func (d *DBClient) GetCorporateActions(ctx context.Context, tickerSymbol string) ([]CorporateAction, error) {
+	query :=
+		`select ticker_symbol, time, kind, ratio
+		from corporate_action
+		where ticker_symbol = ?
+		order by time`
+
+	rows, err := d.DB.QueryContext(ctx, query, tickerSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	var actions []CorporateAction
+	for rows.Next() {
+		var (
+			action     CorporateAction
+			timeString string
+		)
+
+		if err := rows.Scan(&action.TickerSymbol, &timeString, &action.Kind, &action.Ratio); err != nil {
+			return nil, err
+		}
+
+		action.Time, err = time.Parse(PriceTimeLayout, timeString)
+		if err != nil {
+			return nil, err
+		}
+
+		actions = append(actions, action)
 	}
 
-	finalQuery := builder.String()
+	return actions, nil
+}
+
+func (d *DBClient) InsertQuarantinedCandles(ctx context.Context, candles []QuarantinedCandle) error {
+	query :=
+		`insert or replace into price_quarantine (ticker_symbol, timeframe, time, price, rule, reason)
+		values (?,?,?,?,?,?)`
+
 	tx, err := d.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(finalQuery)
-	if err != nil {
-		if err = tx.Rollback(); err != nil {
+	for _, candle := range candles {
+		_, err := tx.ExecContext(ctx, query,
+			candle.TickerSymbol, candle.Timeframe, candle.Time.Format(PriceTimeLayout), candle.Price, candle.Rule, candle.Reason)
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return rbErr
+			}
 			return err
 		}
-		return err
 	}
 
 	return tx.Commit()
 }
 
-func (d *DBClient) GetTickersByTimeframe(ctx context.Context, timeframe string) ([]*Ticker, error) {
+func (d *DBClient) GetQuarantinedCandles(ctx context.Context, tickerSymbol, timeframe string) ([]QuarantinedCandle, error) {
 	query :=
-		`select distinct t.symbol, t.class
-		from ticker t join binding b on t.symbol = b.ticker_symbol
-		where b.timeframe = ?`
+		`select ticker_symbol, timeframe, time, price, rule, reason
+		from price_quarantine
+		where ticker_symbol = ? and timeframe = ?
+		order by time`
 
-	rows, err := d.DB.QueryContext(ctx, query, timeframe)
+	rows, err := d.DB.QueryContext(ctx, query, tickerSymbol, timeframe)
 	if err != nil {
 		return nil, err
 	}
@@ -218,29 +536,46 @@ func (d *DBClient) GetTickersByTimeframe(ctx context.Context, timeframe string)
 
 	defer rows.Close()
 
-	var tickers []*Ticker
+	var candles []QuarantinedCandle
 	for rows.Next() {
-		var ticker Ticker
+		var (
+			candle     QuarantinedCandle
+			timeString string
+		)
 
-		err = rows.Scan(&ticker.Symbol, &ticker.Class)
+		err := rows.Scan(&candle.TickerSymbol, &candle.Timeframe, &timeString, &candle.Price, &candle.Rule, &candle.Reason)
 		if err != nil {
 			return nil, err
 		}
 
-		tickers = append(tickers, &ticker)
+		candle.Time, err = time.Parse(PriceTimeLayout, timeString)
+		if err != nil {
+			return nil, err
+		}
+
+		candles = append(candles, candle)
 	}
 
-	return tickers, nil
+	return candles, nil
 }
 
-func (d *DBClient) GetPriceByTicker(ctx context.Context, tickerSymbol, timeframe string) ([]float64, error) {
-	query := fmt.Sprintf(
-		`select price
-		from price_%s
-		where ticker_symbol = ?
-		order by time`, strings.ToLower(timeframe))
+func (d *DBClient) UpsertRetentionPolicy(ctx context.Context, policy *RetentionPolicy) error {
+	query :=
+		`insert into retention_policy (ticker_symbol, timeframe, mode, value, archive) values (?,?,?,?,?)
+		on conflict (ticker_symbol, timeframe) do update
+		set mode = excluded.mode, value = excluded.value, archive = excluded.archive`
 
-	rows, err := d.DB.QueryContext(ctx, query, tickerSymbol)
+	_, err := d.DB.ExecContext(ctx, query, policy.TickerSymbol, policy.Timeframe, policy.Mode, policy.Value, policy.Archive)
+	return err
+}
+
+func (d *DBClient) GetRetentionPolicies(ctx context.Context) ([]RetentionPolicy, error) {
+	query :=
+		`select ticker_symbol, timeframe, mode, value, archive
+		from retention_policy
+		order by timeframe, ticker_symbol`
+
+	rows, err := d.DB.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -251,35 +586,683 @@ func (d *DBClient) GetPriceByTicker(ctx context.Context, tickerSymbol, timeframe
 
 	defer rows.Close()
 
-	var prices []float64
-
+	var policies []RetentionPolicy
 	for rows.Next() {
-		var price float64
+		var policy RetentionPolicy
 
-		err := rows.Scan(&price)
-		if err != nil {
+		if err := rows.Scan(&policy.TickerSymbol, &policy.Timeframe, &policy.Mode, &policy.Value, &policy.Archive); err != nil {
 			return nil, err
 		}
 
-		prices = append(prices, price)
+		policies = append(policies, policy)
 	}
 
-	return prices, nil
+	return policies, nil
 }
 
-func (d *DBClient) Close() {
-	d.DB.Close()
+// GetRetentionPolicy prefers the ticker's own policy over the timeframe-wide one, returning
+// sql.ErrNoRows when neither exists.
+func (d *DBClient) GetRetentionPolicy(ctx context.Context, tickerSymbol, timeframe string) (*RetentionPolicy, error) {
+	query :=
+		`select ticker_symbol, timeframe, mode, value, archive
+		from retention_policy
+		where timeframe = ? and ticker_symbol in (?, '')
+		order by ticker_symbol desc
+		limit 1`
+
+	var policy RetentionPolicy
+
+	err := d.DB.QueryRowContext(ctx, query, timeframe, tickerSymbol).
+		Scan(&policy.TickerSymbol, &policy.Timeframe, &policy.Mode, &policy.Value, &policy.Archive)
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
 }
 
-func (d *DBClient) Ping(ctx context.Context) error {
-	query := `select symbol from ticker limit 1`
+// InsertPriceData binds prices as float64 parameters so sub-cent prices are stored without rounding.
+func (d *DBClient) InsertPriceData(ctx context.Context, timeframe string, data []PriceData) error {
+	table := "price_" + strings.ToLower(timeframe)
 
-	//nolint:rowserrcheck
-	res, err := d.DB.QueryContext(ctx, query)
+	tx, err := d.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	defer res.Close()
+	if err := insertPriceData(ctx, tx, table, data); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplacePriceData deletes the ticker's stored candles at the stale times and upserts data, in one transaction.
+// Stale times are stored candles data replaces under another time, like ones keyed by an older candle convention.
+func (d *DBClient) ReplacePriceData(
+	ctx context.Context,
+	tickerSymbol, timeframe string,
+	data []PriceData,
+	stale []time.Time,
+) error {
+	table := "price_" + strings.ToLower(timeframe)
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(stale); start += insertPriceBatchSize {
+		batch := stale[start:min(start+insertPriceBatchSize, len(stale))]
+
+		placeholders := make([]string, 0, len(batch))
+		args := make([]any, 0, len(batch)+1)
+		args = append(args, tickerSymbol)
+
+		for _, t := range batch {
+			placeholders = append(placeholders, "?")
+			args = append(args, t.Format(PriceTimeLayout))
+		}
+
+		delQuery := fmt.Sprintf(`delete from %s where ticker_symbol = ? and time in (%s)`,
+			table, strings.Join(placeholders, ","))
+
+		if _, err := tx.ExecContext(ctx, delQuery, args...); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+	}
+
+	if err := insertPriceData(ctx, tx, table, data); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertPriceData upserts data into table in batches, leaving the transaction to the caller.
+func insertPriceData(ctx context.Context, tx *sql.Tx, table string, data []PriceData) error {
+	for start := 0; start < len(data); start += insertPriceBatchSize {
+		batch := data[start:min(start+insertPriceBatchSize, len(data))]
+
+		placeholders := make([]string, 0, len(batch))
+		args := make([]any, 0, 5*len(batch))
+
+		for _, currData := range batch {
+			adjPrice := currData.AdjPrice
+			if adjPrice == 0 {
+				adjPrice = currData.Price
+			}
+
+			placeholders = append(placeholders, "(?,?,?,?,?)")
+			args = append(args,
+				currData.TickerSymbol, currData.Time.Format(PriceTimeLayout), currData.Price, adjPrice, currData.Suspect)
+		}
+
+		insQuery := fmt.Sprintf(`insert or replace into %s (ticker_symbol,time,price,adj_price,suspect) values %s`,
+			table, strings.Join(placeholders, ","))
+
+		if _, err := tx.ExecContext(ctx, insQuery, args...); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+func (d *DBClient) GetTickersByTimeframe(ctx context.Context, timeframe string) ([]*Ticker, error) {
+	query :=
+		`select distinct t.symbol, t.class
+		from ticker t join binding b on t.symbol = b.ticker_symbol
+		where b.timeframe = ?`
+
+	rows, err := d.DB.QueryContext(ctx, query, timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	var tickers []*Ticker
+	for rows.Next() {
+		var ticker Ticker
+
+		err = rows.Scan(&ticker.Symbol, &ticker.Class)
+		if err != nil {
+			return nil, err
+		}
+
+		tickers = append(tickers, &ticker)
+	}
+
+	return tickers, nil
+}
+
+// GetPriceByTicker returns every stored candle of the ticker, oldest first.
+func (d *DBClient) GetPriceByTicker(ctx context.Context, tickerSymbol, timeframe string) ([]PriceData, error) {
+	return d.QueryPriceData(ctx, tickerSymbol, timeframe, &PriceFilter{})
+}
+
+func (d *DBClient) QueryPriceData(
+	ctx context.Context,
+	tickerSymbol, timeframe string,
+	filter *PriceFilter,
+) ([]PriceData, error) {
+	conditions := []string{"ticker_symbol = ?"}
+	args := []any{tickerSymbol}
+
+	addCondition := func(condition string, t *time.Time) {
+		if t != nil {
+			conditions = append(conditions, condition)
+			args = append(args, t.Format(PriceTimeLayout))
+		}
+	}
+
+	addCondition("time >= ?", filter.From)
+	addCondition("time <= ?", filter.To)
+	addCondition("time > ?", filter.After)
+	addCondition("time < ?", filter.Before)
+
+	order := "asc"
+	if filter.Descending {
+		order = "desc"
+	}
+
+	query := fmt.Sprintf(
+		`select ticker_symbol, time, price, coalesce(adj_price, price), rounded, suspect
+		from price_%s
+		where %s
+		order by time %s`, strings.ToLower(timeframe), strings.Join(conditions, " and "), order)
+
+	if filter.Limit > 0 {
+		query += " limit ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	var results []PriceData
+	for rows.Next() {
+		var (
+			data       PriceData
+			timeString string
+		)
+
+		if err := rows.Scan(&data.TickerSymbol, &timeString, &data.Price, &data.AdjPrice, &data.Rounded, &data.Suspect); err != nil {
+			return nil, err
+		}
+
+		data.Time, err = time.Parse(PriceTimeLayout, timeString)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, data)
+	}
+
+	return results, nil
+}
+
+// GetLatestPriceTime returns the zero time when no price is stored. Times are stored as wall clock
+// times without a zone, so the result is in UTC and callers re-interpret it in the market's location.
+func (d *DBClient) GetLatestPriceTime(ctx context.Context, tickerSymbol, timeframe string) (time.Time, error) {
+	query := fmt.Sprintf(
+		`select max(time)
+		from price_%s
+		where ticker_symbol = ?`, strings.ToLower(timeframe))
+
+	var latest sql.NullString
+
+	if err := d.DB.QueryRowContext(ctx, query, tickerSymbol).Scan(&latest); err != nil {
+		return time.Time{}, err
+	}
+
+	if !latest.Valid {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(PriceTimeLayout, latest.String)
+}
+
+func (d *DBClient) Close() {
+	d.DB.Close()
+}
+
+func (d *DBClient) Ping(ctx context.Context) error {
+	query := `select symbol from ticker limit 1`
+
+	//nolint:rowserrcheck
+	res, err := d.DB.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	defer res.Close()
+	return nil
+}
+
+func (d *DBClient) InsertPriceAlert(ctx context.Context, alert *PriceAlert) (int64, error) {
+	query := `insert into price_alert (ticker_symbol, condition, price) values (?,?,?)`
+
+	res, err := d.DB.ExecContext(ctx, query, alert.TickerSymbol, alert.Condition, alert.Price)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// GetPriceAlerts returns the alerts oldest first, leaving out triggered ones when activeOnly is set.
+func (d *DBClient) GetPriceAlerts(ctx context.Context, activeOnly bool) ([]PriceAlert, error) {
+	query :=
+		`select id, ticker_symbol, condition, price, created_at, triggered_at
+		from price_alert`
+
+	if activeOnly {
+		query += " where triggered_at is null"
+	}
+
+	query += " order by id"
+
+	rows, err := d.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	var alerts []PriceAlert
+	for rows.Next() {
+		var (
+			alert       PriceAlert
+			createdAt   string
+			triggeredAt sql.NullString
+		)
+
+		err := rows.Scan(&alert.ID, &alert.TickerSymbol, &alert.Condition, &alert.Price, &createdAt, &triggeredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		alert.CreatedAt, err = time.Parse(PriceTimeLayout, createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if triggeredAt.Valid {
+			t, err := time.Parse(PriceTimeLayout, triggeredAt.String)
+			if err != nil {
+				return nil, err
+			}
+			alert.TriggeredAt = &t
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+func (d *DBClient) MarkPriceAlertTriggered(ctx context.Context, id int64, triggeredAt time.Time) error {
+	query := `update price_alert set triggered_at = ? where id = ?`
+
+	_, err := d.DB.ExecContext(ctx, query, triggeredAt.UTC().Format(PriceTimeLayout), id)
+	return err
+}
+
+func (d *DBClient) DeletePriceAlert(ctx context.Context, id int64) error {
+	query := `delete from price_alert where id = ?`
+
+	res, err := d.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (d *DBClient) UpsertNotificationChannel(ctx context.Context, channel *NotificationChannel) error {
+	query :=
+		`insert into notification_channel (name, kind, target, secret, options) values (?,?,?,?,?)
+		on conflict (name) do update
+		set kind = excluded.kind, target = excluded.target, secret = excluded.secret, options = excluded.options`
+
+	options := channel.Options
+	if options == "" {
+		options = "{}"
+	}
+
+	_, err := d.DB.ExecContext(ctx, query, channel.Name, channel.Kind, channel.Target, channel.Secret, options)
+	return err
+}
+
+func (d *DBClient) GetNotificationChannels(ctx context.Context) ([]NotificationChannel, error) {
+	query :=
+		`select name, kind, target, secret, options
+		from notification_channel
+		order by name`
+
+	rows, err := d.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	var channels []NotificationChannel
+	for rows.Next() {
+		var channel NotificationChannel
+
+		if err := rows.Scan(&channel.Name, &channel.Kind, &channel.Target, &channel.Secret, &channel.Options); err != nil {
+			return nil, err
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
+
+func (d *DBClient) InsertNotificationRoute(ctx context.Context, route *NotificationRoute) error {
+	query :=
+		`insert or ignore into notification_route (ticker_symbol, timeframe, strategy, channel) values (?,?,?,?)`
+
+	_, err := d.DB.ExecContext(ctx, query, route.TickerSymbol, route.Timeframe, route.Strategy, route.Channel)
+	return err
+}
+
+func (d *DBClient) GetNotificationRoutes(ctx context.Context) ([]NotificationRoute, error) {
+	query :=
+		`select ticker_symbol, timeframe, strategy, channel
+		from notification_route
+		order by ticker_symbol, timeframe, strategy, channel`
+
+	rows, err := d.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	var routes []NotificationRoute
+	for rows.Next() {
+		var route NotificationRoute
+
+		if err := rows.Scan(&route.TickerSymbol, &route.Timeframe, &route.Strategy, &route.Channel); err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+func (d *DBClient) DeleteNotificationRoute(ctx context.Context, route *NotificationRoute) error {
+	query :=
+		`delete from notification_route
+		where ticker_symbol = ? and timeframe = ? and strategy = ? and channel = ?`
+
+	res, err := d.DB.ExecContext(ctx, query, route.TickerSymbol, route.Timeframe, route.Strategy, route.Channel)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpsertSubscriber registers the chat or renames it, reporting whether it was new.
+func (d *DBClient) UpsertSubscriber(ctx context.Context, subscriber *Subscriber) (bool, error) {
+	created := !d.IsSubscriber(ctx, subscriber.ChatID)
+
+	query :=
+		`insert into subscriber (chat_id, name) values (?,?)
+		on conflict (chat_id) do update set name = excluded.name`
+
+	_, err := d.DB.ExecContext(ctx, query, subscriber.ChatID, subscriber.Name)
+	return created, err
+}
+
+// DeleteSubscriber removes the chat and its watchlist, along with the bindings only the watchlist kept, returning
+// sql.ErrNoRows when it wasn't registered.
+func (d *DBClient) DeleteSubscriber(ctx context.Context, chatID int64) error {
+	if !d.IsSubscriber(ctx, chatID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		`delete from subscription where chat_id = ?`,
+		`delete from subscriber where chat_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, chatID); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteUnwatchedBindingsQuery); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *DBClient) IsSubscriber(ctx context.Context, chatID int64) bool {
+	query := `select chat_id from subscriber where chat_id = ?`
+
+	var found int64
+	if err := d.DB.QueryRowContext(ctx, query, chatID).Scan(&found); err != nil {
+		return false
+	}
+
+	return true
+}
+
+func (d *DBClient) GetSubscribers(ctx context.Context) ([]Subscriber, error) {
+	query :=
+		`select chat_id, name, registered_at
+		from subscriber
+		order by chat_id`
+
+	rows, err := d.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	var subscribers []Subscriber
+	for rows.Next() {
+		var (
+			subscriber   Subscriber
+			registeredAt string
+		)
+
+		if err := rows.Scan(&subscriber.ChatID, &subscriber.Name, &registeredAt); err != nil {
+			return nil, err
+		}
+
+		subscriber.RegisteredAt, err = time.Parse(PriceTimeLayout, registeredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		subscribers = append(subscribers, subscriber)
+	}
+
+	return subscribers, nil
+}
+
+func (d *DBClient) InsertSubscription(ctx context.Context, subscription *Subscription) error {
+	query :=
+		`insert or ignore into subscription (chat_id, ticker_symbol, timeframe, strategy) values (?,?,?,?)`
+
+	_, err := d.DB.ExecContext(ctx, query,
+		subscription.ChatID, subscription.TickerSymbol, subscription.Timeframe, subscription.Strategy)
+	return err
+}
+
+// deleteUnwatchedBindingsQuery deletes the bindings a watchlist created once no subscription is left on them.
+const deleteUnwatchedBindingsQuery = `delete from binding
+	where from_watchlist = 1 and not exists (
+		select 1
+		from subscription s
+		where s.ticker_symbol = binding.ticker_symbol
+			and s.timeframe = binding.timeframe
+			and s.strategy = binding.strategy)`
+
+// DeleteSubscription also deletes the binding the subscription created if no other subscription is on it.
+func (d *DBClient) DeleteSubscription(ctx context.Context, subscription *Subscription) error {
+	query :=
+		`delete from subscription
+		where chat_id = ? and ticker_symbol = ? and timeframe = ? and strategy = ?`
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, query,
+		subscription.ChatID, subscription.TickerSymbol, subscription.Timeframe, subscription.Strategy)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	if deleted == 0 {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteUnwatchedBindingsQuery); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *DBClient) GetSubscriptions(ctx context.Context) ([]Subscription, error) {
+	query :=
+		`select chat_id, ticker_symbol, timeframe, strategy
+		from subscription
+		order by chat_id, ticker_symbol, timeframe, strategy`
+
+	return d.getSubscriptionsWithQuery(ctx, query)
+}
+
+func (d *DBClient) GetSubscriptionsByChat(ctx context.Context, chatID int64) ([]Subscription, error) {
+	query :=
+		`select chat_id, ticker_symbol, timeframe, strategy
+		from subscription
+		where chat_id = ?
+		order by ticker_symbol, timeframe, strategy`
+
+	return d.getSubscriptionsWithQuery(ctx, query, chatID)
+}
+
+func (d *DBClient) getSubscriptionsWithQuery(ctx context.Context, query string, args ...any) ([]Subscription, error) {
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	defer rows.Close()
+
+	var subscriptions []Subscription
+	for rows.Next() {
+		var subscription Subscription
+
+		err := rows.Scan(&subscription.ChatID, &subscription.TickerSymbol, &subscription.Timeframe, &subscription.Strategy)
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
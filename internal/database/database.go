@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -25,7 +27,16 @@ func InitDB() {
 		log.Fatalf("failed to ping: %v", err)
 	}
 
-	Client = newDBClient(db)
+	client := newDBClient(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := client.migrate(ctx); err != nil {
+		log.Fatalf("failed to migrate: %v", err)
+	}
+
+	Client = client
 
 	log.Println("Successfully connected to database!")
 }
@@ -0,0 +1,459 @@
+// Package dbtest is an in-memory database.Database for tests. It keeps tickers, bindings, prices, alerts and
+// policies the way their tables do. Methods it doesn't implement go to the nil embedded interface and panic,
+// so a test touching an unexpected table fails loudly.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/database"
+)
+
+type DB struct {
+	database.Database
+
+	mu                sync.Mutex
+	tickers           []database.Ticker
+	bindings          []database.Binding
+	prices            map[priceKey][]database.PriceData
+	archived          map[priceKey][]database.PriceData
+	quarantined       []database.QuarantinedCandle
+	corporateActions  []database.CorporateAction
+	retentionPolicies []database.RetentionPolicy
+	routes            []database.NotificationRoute
+	triggered         map[int64]time.Time
+}
+
+type priceKey struct {
+	tickerSymbol string
+	timeframe    string
+}
+
+func New() *DB {
+	return &DB{
+		prices:    make(map[priceKey][]database.PriceData),
+		archived:  make(map[priceKey][]database.PriceData),
+		triggered: make(map[int64]time.Time),
+	}
+}
+
+// Use makes a new DB the database.Client until the test ends.
+func Use(t testing.TB) *DB {
+	t.Helper()
+
+	db := New()
+
+	prev := database.Client
+	database.Client = db
+	t.Cleanup(func() { database.Client = prev })
+
+	return db
+}
+
+func (db *DB) SetTickers(tickers ...database.Ticker) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.tickers = tickers
+}
+
+func (db *DB) SetBindings(bindings ...database.Binding) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.bindings = bindings
+}
+
+func (db *DB) SetRetentionPolicies(policies ...database.RetentionPolicy) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.retentionPolicies = policies
+}
+
+func (db *DB) SetRoutes(routes ...database.NotificationRoute) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.routes = routes
+}
+
+// SetPrices stores data as the ticker's candles in the timeframe, sorted by time.
+func (db *DB) SetPrices(tickerSymbol, timeframe string, data []database.PriceData) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.prices[priceKey{tickerSymbol, timeframe}] = sortedPrices(data)
+}
+
+// Prices returns a copy of the ticker's stored candles in the timeframe, oldest first.
+func (db *DB) Prices(tickerSymbol, timeframe string) []database.PriceData {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return slices.Clone(db.prices[priceKey{tickerSymbol, timeframe}])
+}
+
+// Archived returns a copy of the ticker's archived candles in the timeframe, oldest first.
+func (db *DB) Archived(tickerSymbol, timeframe string) []database.PriceData {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return slices.Clone(db.archived[priceKey{tickerSymbol, timeframe}])
+}
+
+func (db *DB) Quarantined() []database.QuarantinedCandle {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return slices.Clone(db.quarantined)
+}
+
+// TriggeredAt returns when the alert was marked triggered.
+func (db *DB) TriggeredAt(id int64) (time.Time, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, ok := db.triggered[id]
+	return t, ok
+}
+
+func (db *DB) GetTickers(context.Context) ([]database.Ticker, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return slices.Clone(db.tickers), nil
+}
+
+func (db *DB) GetTickerClassBySymbol(_ context.Context, tickerSymbol string) (string, error) {
+	t, ok := db.ticker(tickerSymbol)
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+
+	return t.Class, nil
+}
+
+func (db *DB) IsTickerRegistered(_ context.Context, tickerSymbol string) bool {
+	_, ok := db.ticker(tickerSymbol)
+	return ok
+}
+
+func (db *DB) GetTickerProviderSymbols(_ context.Context, tickerSymbol string) (map[string]string, error) {
+	t, _ := db.ticker(tickerSymbol)
+	return t.ProviderSymbols, nil
+}
+
+// GetTickersByTimeframe returns the tickers with a binding in the timeframe.
+func (db *DB) GetTickersByTimeframe(_ context.Context, timeframe string) ([]*database.Ticker, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var tickers []*database.Ticker
+	for i := range db.tickers {
+		bound := slices.ContainsFunc(db.bindings, func(b database.Binding) bool {
+			return b.TickerSymbol == db.tickers[i].Symbol && b.Timeframe == timeframe
+		})
+		if bound {
+			t := db.tickers[i]
+			tickers = append(tickers, &t)
+		}
+	}
+
+	return tickers, nil
+}
+
+func (db *DB) ticker(tickerSymbol string) (database.Ticker, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	idx := slices.IndexFunc(db.tickers, func(t database.Ticker) bool { return t.Symbol == tickerSymbol })
+	if idx < 0 {
+		return database.Ticker{}, false
+	}
+
+	return db.tickers[idx], true
+}
+
+func (db *DB) GetBindingsByTicker(_ context.Context, tickerSymbol string) ([]database.Binding, error) {
+	return db.filterBindings(func(b database.Binding) bool { return b.TickerSymbol == tickerSymbol }), nil
+}
+
+func (db *DB) GetBindingsByTimeframe(_ context.Context, timeframe string) ([]database.Binding, error) {
+	return db.filterBindings(func(b database.Binding) bool { return b.Timeframe == timeframe }), nil
+}
+
+func (db *DB) filterBindings(keep func(database.Binding) bool) []database.Binding {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var bindings []database.Binding
+	for _, b := range db.bindings {
+		if keep(b) {
+			bindings = append(bindings, b)
+		}
+	}
+
+	return bindings
+}
+
+func (db *DB) MarkPriceAlertTriggered(_ context.Context, id int64, triggeredAt time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.triggered[id] = triggeredAt
+	return nil
+}
+
+// InsertNotificationRoute ignores a route that's already there, like the unique index of the table does.
+func (db *DB) InsertNotificationRoute(_ context.Context, route *database.NotificationRoute) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if !slices.Contains(db.routes, *route) {
+		db.routes = append(db.routes, *route)
+	}
+
+	return nil
+}
+
+func (db *DB) GetNotificationRoutes(context.Context) ([]database.NotificationRoute, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return slices.Clone(db.routes), nil
+}
+
+func (db *DB) DeleteNotificationRoute(_ context.Context, route *database.NotificationRoute) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	idx := slices.Index(db.routes, *route)
+	if idx < 0 {
+		return sql.ErrNoRows
+	}
+
+	db.routes = slices.Delete(db.routes, idx, idx+1)
+	return nil
+}
+
+func (db *DB) GetPriceByTicker(ctx context.Context, tickerSymbol, timeframe string) ([]database.PriceData, error) {
+	return db.QueryPriceData(ctx, tickerSymbol, timeframe, &database.PriceFilter{})
+}
+
+func (db *DB) QueryPriceData(
+	_ context.Context,
+	tickerSymbol, timeframe string,
+	filter *database.PriceFilter,
+) ([]database.PriceData, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var data []database.PriceData
+	for _, d := range db.prices[priceKey{tickerSymbol, timeframe}] {
+		switch {
+		case filter.From != nil && d.Time.Before(*filter.From),
+			filter.To != nil && d.Time.After(*filter.To),
+			filter.After != nil && !d.Time.After(*filter.After),
+			filter.Before != nil && !d.Time.Before(*filter.Before):
+			continue
+		}
+		data = append(data, d)
+	}
+
+	if filter.Descending {
+		slices.Reverse(data)
+	}
+
+	if filter.Limit > 0 && len(data) > filter.Limit {
+		data = data[:filter.Limit]
+	}
+
+	return data, nil
+}
+
+func (db *DB) GetLatestPriceTime(_ context.Context, tickerSymbol, timeframe string) (time.Time, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	data := db.prices[priceKey{tickerSymbol, timeframe}]
+	if len(data) == 0 {
+		return time.Time{}, nil
+	}
+
+	return data[len(data)-1].Time, nil
+}
+
+// InsertPriceData replaces the candles at the times of data, like the primary key of the price tables does.
+func (db *DB) InsertPriceData(_ context.Context, timeframe string, data []database.PriceData) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, d := range data {
+		db.upsertPrice(timeframe, d)
+	}
+
+	return nil
+}
+
+func (db *DB) ReplacePriceData(
+	_ context.Context,
+	tickerSymbol, timeframe string,
+	data []database.PriceData,
+	stale []time.Time,
+) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := priceKey{tickerSymbol, timeframe}
+	db.prices[key] = slices.DeleteFunc(db.prices[key], func(d database.PriceData) bool {
+		return slices.ContainsFunc(stale, d.Time.Equal)
+	})
+
+	for _, d := range data {
+		db.upsertPrice(timeframe, d)
+	}
+
+	return nil
+}
+
+func (db *DB) upsertPrice(timeframe string, d database.PriceData) {
+	if d.AdjPrice == 0 {
+		d.AdjPrice = d.Price
+	}
+
+	key := priceKey{d.TickerSymbol, timeframe}
+	stored := db.prices[key]
+
+	if idx := slices.IndexFunc(stored, func(s database.PriceData) bool { return s.Time.Equal(d.Time) }); idx >= 0 {
+		stored[idx] = d
+		return
+	}
+
+	db.prices[key] = sortedPrices(append(stored, d))
+}
+
+// TrimPriceData keeps the keepCandles newest candles, or the ones from cutoff on when it's set.
+func (db *DB) TrimPriceData(
+	_ context.Context,
+	tickerSymbol, timeframe string,
+	keepCandles int,
+	cutoff *time.Time,
+	archive bool,
+) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := priceKey{tickerSymbol, timeframe}
+	stored := db.prices[key]
+
+	trimmed := 0
+	if cutoff != nil {
+		for trimmed < len(stored) && stored[trimmed].Time.Before(*cutoff) {
+			trimmed++
+		}
+	} else {
+		trimmed = max(len(stored)-keepCandles, 0)
+	}
+
+	if archive {
+		db.archived[key] = sortedPrices(append(db.archived[key], stored[:trimmed]...))
+	}
+
+	db.prices[key] = slices.Clone(stored[trimmed:])
+
+	return int64(trimmed), nil
+}
+
+func (db *DB) ScaleAdjustedPrices(
+	_ context.Context,
+	tickerSymbol, timeframe string,
+	before time.Time,
+	factor float64,
+) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var scaled int64
+	for i, d := range db.prices[priceKey{tickerSymbol, timeframe}] {
+		if d.Time.Before(before) {
+			db.prices[priceKey{tickerSymbol, timeframe}][i].AdjPrice = d.AdjPrice * factor
+			scaled++
+		}
+	}
+
+	return scaled, nil
+}
+
+// GetRetentionPolicy prefers the ticker's own policy over the timeframe-wide one.
+func (db *DB) GetRetentionPolicy(_ context.Context, tickerSymbol, timeframe string) (*database.RetentionPolicy, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var found *database.RetentionPolicy
+	for i, policy := range db.retentionPolicies {
+		if policy.Timeframe != timeframe {
+			continue
+		}
+
+		switch policy.TickerSymbol {
+		case tickerSymbol:
+			return &db.retentionPolicies[i], nil
+		case "":
+			found = &db.retentionPolicies[i]
+		}
+	}
+
+	if found == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return found, nil
+}
+
+func (db *DB) InsertQuarantinedCandles(_ context.Context, candles []database.QuarantinedCandle) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.quarantined = append(db.quarantined, candles...)
+	return nil
+}
+
+func (db *DB) GetCorporateActions(_ context.Context, tickerSymbol string) ([]database.CorporateAction, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var actions []database.CorporateAction
+	for _, action := range db.corporateActions {
+		if action.TickerSymbol == tickerSymbol {
+			actions = append(actions, action)
+		}
+	}
+
+	return actions, nil
+}
+
+func (db *DB) InsertCorporateAction(_ context.Context, action *database.CorporateAction) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if slices.ContainsFunc(db.corporateActions, func(stored database.CorporateAction) bool {
+		return stored.TickerSymbol == action.TickerSymbol && stored.Time.Equal(action.Time) && stored.Kind == action.Kind
+	}) {
+		return false, nil
+	}
+
+	db.corporateActions = append(db.corporateActions, *action)
+	return true, nil
+}
+
+func sortedPrices(data []database.PriceData) []database.PriceData {
+	data = slices.Clone(data)
+	slices.SortFunc(data, func(a, b database.PriceData) int { return a.Time.Compare(b.Time) })
+	return data
+}
@@ -0,0 +1,322 @@
+package database
+
+import (
+	"context"
+	"log"
+)
+
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// migrations are applied in order on startup. Never edit an applied migration, append a new one instead.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "baseline schema",
+		statements: []string{
+			`create table if not exists ticker (
+				symbol text primary key,
+				class text not null)`,
+			`create table if not exists binding (
+				ticker_symbol text not null,
+				timeframe text not null,
+				strategy text not null,
+				primary key (ticker_symbol, timeframe, strategy))`,
+			`create table if not exists price_h4 (
+				ticker_symbol text not null,
+				time text not null,
+				price real not null,
+				primary key (ticker_symbol, time))`,
+			`create table if not exists price_d1 (
+				ticker_symbol text not null,
+				time text not null,
+				price real not null,
+				primary key (ticker_symbol, time))`,
+			`create table if not exists price_w1 (
+				ticker_symbol text not null,
+				time text not null,
+				price real not null,
+				primary key (ticker_symbol, time))`,
+		},
+	},
+	{
+		version:     2,
+		description: "per-provider ticker symbols",
+		statements: []string{
+			`create table if not exists ticker_provider_symbol (
+				ticker_symbol text not null,
+				provider text not null,
+				symbol text not null,
+				primary key (ticker_symbol, provider))`,
+			// Carry over the mappings that used to be hard-coded in the crypto fetcher
+			`insert or ignore into ticker_provider_symbol (ticker_symbol, provider, symbol)
+				select symbol, 'coinapi', 'BTC' from ticker where symbol = 'BITCOIN'`,
+			`insert or ignore into ticker_provider_symbol (ticker_symbol, provider, symbol)
+				select symbol, 'coinapi', 'ETH' from ticker where symbol = 'ETHEREUM'`,
+		},
+	},
+	{
+		version:     3,
+		description: "flag prices rounded to 2 decimals",
+		// Every row inserted until now went through %.2f formatting, which only lost digits that matter for
+		// crypto prices under 1, stock prices are quoted in cents. Refreshed or imported rows replace them with
+		// full precision prices and the default of 0.
+		statements: []string{
+			`alter table price_h4 add column rounded integer not null default 0`,
+			`alter table price_d1 add column rounded integer not null default 0`,
+			`alter table price_w1 add column rounded integer not null default 0`,
+			`update price_h4 set rounded = 1
+			where price < 1 and ticker_symbol in (select symbol from ticker where class = 'crypto')`,
+			`update price_d1 set rounded = 1
+			where price < 1 and ticker_symbol in (select symbol from ticker where class = 'crypto')`,
+			`update price_w1 set rounded = 1
+			where price < 1 and ticker_symbol in (select symbol from ticker where class = 'crypto')`,
+		},
+	},
+	{
+		version:     4,
+		description: "price retention policies and archive",
+		statements: []string{
+			`create table if not exists retention_policy (
+				ticker_symbol text not null default '',
+				timeframe text not null,
+				mode text not null,
+				value integer not null default 0,
+				archive integer not null default 0,
+				primary key (ticker_symbol, timeframe))`,
+			`create table if not exists price_h4_archive (
+				ticker_symbol text not null,
+				time text not null,
+				price real not null,
+				rounded integer not null default 0,
+				archived_at text not null default current_timestamp,
+				primary key (ticker_symbol, time))`,
+			`create table if not exists price_d1_archive (
+				ticker_symbol text not null,
+				time text not null,
+				price real not null,
+				rounded integer not null default 0,
+				archived_at text not null default current_timestamp,
+				primary key (ticker_symbol, time))`,
+			`create table if not exists price_w1_archive (
+				ticker_symbol text not null,
+				time text not null,
+				price real not null,
+				rounded integer not null default 0,
+				archived_at text not null default current_timestamp,
+				primary key (ticker_symbol, time))`,
+		},
+	},
+	{
+		version:     5,
+		description: "adjusted prices, corporate actions and binding price series",
+		statements: []string{
+			`alter table price_h4 add column adj_price real`,
+			`alter table price_d1 add column adj_price real`,
+			`alter table price_w1 add column adj_price real`,
+			`alter table price_h4_archive add column adj_price real`,
+			`alter table price_d1_archive add column adj_price real`,
+			`alter table price_w1_archive add column adj_price real`,
+			`create table if not exists corporate_action (
+				ticker_symbol text not null,
+				time text not null,
+				kind text not null,
+				ratio real not null,
+				detected_at text not null default current_timestamp,
+				primary key (ticker_symbol, time, kind))`,
+			`alter table binding add column price_series text not null default 'adjusted'`,
+		},
+	},
+	{
+		version:     6,
+		description: "suspect candle flags and quarantine",
+		statements: []string{
+			`alter table price_h4 add column suspect integer not null default 0`,
+			`alter table price_d1 add column suspect integer not null default 0`,
+			`alter table price_w1 add column suspect integer not null default 0`,
+			`alter table price_h4_archive add column suspect integer not null default 0`,
+			`alter table price_d1_archive add column suspect integer not null default 0`,
+			`alter table price_w1_archive add column suspect integer not null default 0`,
+			`create table if not exists price_quarantine (
+				ticker_symbol text not null,
+				timeframe text not null,
+				time text not null,
+				price real not null,
+				rule text not null,
+				reason text not null,
+				quarantined_at text not null default current_timestamp,
+				primary key (ticker_symbol, timeframe, time, rule))`,
+		},
+	},
+	{
+		version:     7,
+		description: "streamed price alerts",
+		statements: []string{
+			`create table if not exists price_alert (
+				id integer primary key autoincrement,
+				ticker_symbol text not null,
+				condition text not null,
+				price real not null,
+				created_at text not null default current_timestamp,
+				triggered_at text)`,
+		},
+	},
+	{
+		version:     8,
+		description: "notification channels and routes",
+		statements: []string{
+			`create table if not exists notification_channel (
+				name text primary key,
+				kind text not null,
+				target text not null,
+				secret text not null default '')`,
+			`create table if not exists notification_route (
+				ticker_symbol text not null,
+				timeframe text not null default '',
+				strategy text not null default '',
+				channel text not null,
+				primary key (ticker_symbol, timeframe, strategy, channel))`,
+		},
+	},
+	{
+		version:     9,
+		description: "notification channel options",
+		statements: []string{
+			`alter table notification_channel add column options text not null default '{}'`,
+		},
+	},
+	{
+		version:     10,
+		description: "telegram subscribers and their subscriptions",
+		statements: []string{
+			`create table if not exists subscriber (
+				chat_id integer primary key,
+				name text not null default '',
+				registered_at text not null default current_timestamp)`,
+			`create table if not exists subscription (
+				chat_id integer not null,
+				ticker_symbol text not null,
+				timeframe text not null,
+				strategy text not null,
+				primary key (chat_id, ticker_symbol, timeframe, strategy))`,
+		},
+	},
+	{
+		version:     11,
+		description: "rekey stock W1 and H4 candles to the US market calendar",
+		statements: []string{
+			// Weeks were keyed by the provider's bar date, they start on Monday now. A week already stored
+			// under both keys keeps the Monday one, both close on the week's last session
+			`update or ignore price_w1
+			set time = date(time, '-6 days', 'weekday 1') || ' 00:00:00'
+			where ticker_symbol in (select symbol from ticker where class = 'stock')
+				and time != date(time, '-6 days', 'weekday 1') || ' 00:00:00'`,
+			`delete from price_w1
+			where ticker_symbol in (select symbol from ticker where class = 'stock')
+				and time != date(time, '-6 days', 'weekday 1') || ' 00:00:00'`,
+			`update or ignore price_w1_archive
+			set time = date(time, '-6 days', 'weekday 1') || ' 00:00:00'
+			where ticker_symbol in (select symbol from ticker where class = 'stock')
+				and time != date(time, '-6 days', 'weekday 1') || ' 00:00:00'`,
+			`delete from price_w1_archive
+			where ticker_symbol in (select symbol from ticker where class = 'stock')
+				and time != date(time, '-6 days', 'weekday 1') || ' 00:00:00'`,
+			// H4 candles were every 4th hourly bar, which don't map onto the 9:30 and 13:30 session blocks.
+			// They're dropped and the next refresh fetches the blocks again
+			`delete from price_h4
+			where ticker_symbol in (select symbol from ticker where class = 'stock')
+				and strftime('%H:%M:%S', time) not in ('09:30:00', '13:30:00')`,
+			`delete from price_h4_archive
+			where ticker_symbol in (select symbol from ticker where class = 'stock')
+				and strftime('%H:%M:%S', time) not in ('09:30:00', '13:30:00')`,
+		},
+	},
+	{
+		version:     12,
+		description: "bindings created for a watchlist",
+		statements: []string{
+			`alter table binding add column from_watchlist integer not null default 0`,
+		},
+	},
+	{
+		version:     13,
+		description: "unflag rounded prices that kept their precision",
+		// Version 3 used to flag every row, clear the ones it flags no more where it already ran
+		statements: []string{
+			`update price_h4 set rounded = 0
+			where rounded = 1 and (price >= 1 or ticker_symbol not in (select symbol from ticker where class = 'crypto'))`,
+			`update price_d1 set rounded = 0
+			where rounded = 1 and (price >= 1 or ticker_symbol not in (select symbol from ticker where class = 'crypto'))`,
+			`update price_w1 set rounded = 0
+			where rounded = 1 and (price >= 1 or ticker_symbol not in (select symbol from ticker where class = 'crypto'))`,
+			`update price_h4_archive set rounded = 0
+			where rounded = 1 and (price >= 1 or ticker_symbol not in (select symbol from ticker where class = 'crypto'))`,
+			`update price_d1_archive set rounded = 0
+			where rounded = 1 and (price >= 1 or ticker_symbol not in (select symbol from ticker where class = 'crypto'))`,
+			`update price_w1_archive set rounded = 0
+			where rounded = 1 and (price >= 1 or ticker_symbol not in (select symbol from ticker where class = 'crypto'))`,
+		},
+	},
+}
+
+func (d *DBClient) migrate(ctx context.Context) error {
+	createQuery :=
+		`create table if not exists schema_migration (
+			version integer primary key,
+			description text not null,
+			applied_at text not null default current_timestamp)`
+
+	if _, err := d.DB.ExecContext(ctx, createQuery); err != nil {
+		return err
+	}
+
+	var currentVersion int
+	versionQuery := `select coalesce(max(version), 0) from schema_migration`
+
+	if err := d.DB.QueryRowContext(ctx, versionQuery).Scan(&currentVersion); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= currentVersion {
+			continue
+		}
+
+		if err := d.applyMigration(ctx, m); err != nil {
+			return err
+		}
+
+		log.Printf("Applied migration %d: %s", m.version, m.description)
+	}
+
+	return nil
+}
+
+func (d *DBClient) applyMigration(ctx context.Context, m migration) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range m.statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+	}
+
+	insertQuery := `insert into schema_migration (version, description) values (?,?)`
+	if _, err := tx.ExecContext(ctx, insertQuery, m.version, m.description); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
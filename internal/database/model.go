@@ -3,8 +3,9 @@ package database
 import "time"
 
 type Ticker struct {
-	Symbol string `json:"symbol" db:"symbol"`
-	Class  string `json:"class" db:"class"`
+	Symbol          string            `json:"symbol" db:"symbol"`
+	Class           string            `json:"class" db:"class"`
+	ProviderSymbols map[string]string `json:"providerSymbols,omitempty"`
 }
 
 func NewTicker(symbol, class string) *Ticker {
@@ -14,17 +15,28 @@ func NewTicker(symbol, class string) *Ticker {
 	}
 }
 
+const (
+	// AdjustedSeries is the split and dividend adjusted prices, so corporate actions don't look like crashes
+	AdjustedSeries = "adjusted"
+	RawSeries      = "raw"
+)
+
+// AllowedPriceSeries are the stored prices strategies, indicators and charts can be computed on.
+var AllowedPriceSeries = []string{AdjustedSeries, RawSeries}
+
 type Binding struct {
 	TickerSymbol string `json:"ticker_symbol" db:"ticker_symbol"`
 	Timeframe    string `json:"timeframe" db:"timeframe"`
 	Strategy     string `json:"strategy" db:"strategy"`
+	PriceSeries  string `json:"price_series" db:"price_series"`
 }
 
-func NewBinding(tickerSymbol, timeframe, strategy string) *Binding {
+func NewBinding(tickerSymbol, timeframe, strategy, priceSeries string) *Binding {
 	return &Binding{
 		TickerSymbol: tickerSymbol,
 		Timeframe:    timeframe,
 		Strategy:     strategy,
+		PriceSeries:  priceSeries,
 	}
 }
 
@@ -32,4 +44,94 @@ type PriceData struct {
 	TickerSymbol string
 	Time         time.Time
 	Price        float64
+	// AdjPrice is the split and dividend adjusted price, equal to Price for assets without corporate actions
+	AdjPrice float64
+	// Rounded marks crypto prices under 1 stored before prices were kept at full precision, whose rounding to
+	// 2 decimals lost digits
+	Rounded bool
+	// Suspect marks candles a validation rule flagged but still let through
+	Suspect bool
+}
+
+// PriceFilter narrows a price query. Nil times and a zero Limit are ignored.
+type PriceFilter struct {
+	From       *time.Time
+	To         *time.Time
+	After      *time.Time
+	Before     *time.Time
+	Limit      int
+	Descending bool
+}
+
+// RetentionPolicy decides how much price history is kept. An empty TickerSymbol applies to every
+// ticker in the timeframe without a policy of its own.
+type RetentionPolicy struct {
+	TickerSymbol string `json:"tickerSymbol" db:"ticker_symbol"`
+	Timeframe    string `json:"timeframe" db:"timeframe"`
+	Mode         string `json:"mode" db:"mode"`
+	Value        int    `json:"value" db:"value"`
+	Archive      bool   `json:"archive" db:"archive"`
+}
+
+type CorporateAction struct {
+	TickerSymbol string    `json:"tickerSymbol" db:"ticker_symbol"`
+	Time         time.Time `json:"time" db:"time"`
+	Kind         string    `json:"kind" db:"kind"`
+	// Ratio is the number of new shares per old share for splits, e.g. 4 for a 4-for-1 split
+	Ratio float64 `json:"ratio" db:"ratio"`
+}
+
+// QuarantinedCandle is a fetched candle held back from the price tables by a validation rule.
+type QuarantinedCandle struct {
+	TickerSymbol string    `json:"tickerSymbol" db:"ticker_symbol"`
+	Timeframe    string    `json:"timeframe" db:"timeframe"`
+	Time         time.Time `json:"time" db:"time"`
+	Price        float64   `json:"price" db:"price"`
+	Rule         string    `json:"rule" db:"rule"`
+	Reason       string    `json:"reason" db:"reason"`
+}
+
+// PriceAlert fires once when a streamed price crosses Price in the direction of Condition.
+type PriceAlert struct {
+	ID           int64      `json:"id" db:"id"`
+	TickerSymbol string     `json:"tickerSymbol" db:"ticker_symbol"`
+	Condition    string     `json:"condition" db:"condition"`
+	Price        float64    `json:"price" db:"price"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	TriggeredAt  *time.Time `json:"triggeredAt,omitempty" db:"triggered_at"`
+}
+
+// Subscriber is a Telegram chat that registered with the bot to get the results of its own watchlist.
+type Subscriber struct {
+	ChatID       int64     `json:"chatId" db:"chat_id"`
+	Name         string    `json:"name" db:"name"`
+	RegisteredAt time.Time `json:"registeredAt" db:"registered_at"`
+}
+
+// Subscription puts a binding on a subscriber's watchlist, its results are sent to the subscriber's chat.
+type Subscription struct {
+	ChatID       int64  `json:"chatId" db:"chat_id"`
+	TickerSymbol string `json:"tickerSymbol" db:"ticker_symbol"`
+	Timeframe    string `json:"timeframe" db:"timeframe"`
+	Strategy     string `json:"strategy" db:"strategy"`
+}
+
+// NotificationChannel is somewhere strategy results can be sent. Target is the chat id for Telegram,
+// the URL for webhooks and the recipients for email, Secret signs generic webhook payloads and Options
+// is a JSON object of kind specific settings.
+type NotificationChannel struct {
+	Name    string `json:"name" db:"name"`
+	Kind    string `json:"kind" db:"kind"`
+	Target  string `json:"target" db:"target"`
+	Secret  string `json:"-" db:"secret"`
+	Options string `json:"options" db:"options"`
+}
+
+// NotificationRoute sends a ticker's results to a channel. Empty Timeframe or Strategy match any, so a route
+// can cover a whole ticker or a single binding.
+type NotificationRoute struct {
+	TickerSymbol string `json:"tickerSymbol" db:"ticker_symbol"`
+	Timeframe    string `json:"timeframe" db:"timeframe"`
+	Strategy     string `json:"strategy" db:"strategy"`
+	Channel      string `json:"channel" db:"channel"`
 }
@@ -1,11 +1,28 @@
 package errors
 
+import (
+	"context"
+	"net/http"
+
+	errorsStdLib "errors"
+)
+
 const (
 	RequestDeserializationError = "error deserializing request"
 	DatabaseInsertionError      = "error inserting data into database"
 	DatabaseQueryError          = "error querying data from database"
 )
 
+// Categories of why a ticker failed in a batch, so callers can tell a retry apart from a fix
+const (
+	ProviderCategory    = "provider"
+	RateLimitedCategory = "rate_limited"
+	TimeoutCategory     = "timeout"
+	DatabaseCategory    = "database"
+	EvaluationCategory  = "evaluation"
+	UnknownCategory     = "unknown"
+)
+
 type ErrorResp struct {
 	Message string `json:"message"`
 }
@@ -15,3 +32,68 @@ func NewErrorResp(err error) *ErrorResp {
 		Message: err.Error(),
 	}
 }
+
+// CategorizedError tags an error with one of the categories above.
+type CategorizedError struct {
+	Category string
+	Err      error
+}
+
+func (e *CategorizedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CategorizedError) Unwrap() error {
+	return e.Err
+}
+
+// WithCategory tags err with the category, nil stays nil.
+func WithCategory(category string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &CategorizedError{Category: category, Err: err}
+}
+
+// Category reads the category err was tagged with. Deadlines and cancellations are timeouts
+// wherever they happened, anything untagged is unknown.
+func Category(err error) string {
+	if errorsStdLib.Is(err, context.DeadlineExceeded) || errorsStdLib.Is(err, context.Canceled) {
+		return TimeoutCategory
+	}
+
+	var categorized *CategorizedError
+	if errorsStdLib.As(err, &categorized) {
+		return categorized.Category
+	}
+
+	return UnknownCategory
+}
+
+// TickerFailure is a ticker a batch request failed for while the others went on.
+type TickerFailure struct {
+	TickerSymbol string `json:"tickerSymbol"`
+	Category     string `json:"category"`
+	Error        string `json:"error"`
+}
+
+func NewTickerFailure(tickerSymbol string, err error) *TickerFailure {
+	return &TickerFailure{
+		TickerSymbol: tickerSymbol,
+		Category:     Category(err),
+		Error:        err.Error(),
+	}
+}
+
+// BatchStatus is 200 when every ticker went fine, 207 when only some did and 500 when none did.
+func BatchStatus(succeeded, failed int) int {
+	switch {
+	case failed == 0:
+		return http.StatusOK
+	case succeeded == 0:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusMultiStatus
+	}
+}
@@ -0,0 +1,47 @@
+package indicators
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/errors"
+	"github.com/signalb/internal/timeframe"
+)
+
+// GetIndicatorController serves an indicator of a ticker's stored prices, computed the same way the
+// strategies compute it. ?name picks the indicator, ?length, ?fast, ?slow, ?signal, ?smoothing and
+// ?multiplier override its defaults, ?series picks adjusted or raw prices and ?candles how many values
+// are returned.
+func GetIndicatorController(c *gin.Context) {
+	tf := c.Param("timeframe")
+	ticker := c.Param("ticker")
+
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return
+	}
+
+	var req IndicatorReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
+		return
+	}
+
+	if err := validateIndicatorReq(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(err))
+		return
+	}
+
+	res, err := LoadIndicator(c.Request.Context(), ticker, tf, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
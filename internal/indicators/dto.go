@@ -0,0 +1,17 @@
+package indicators
+
+// Point is an indicator value at a candle, null until the indicator has enough candles.
+type Point struct {
+	Time  string   `json:"time"`
+	Value *float64 `json:"value"`
+}
+
+// IndicatorResp has a line per output of the indicator, e.g. upper, middle and lower for Bollinger Bands.
+type IndicatorResp struct {
+	Ticker    string             `json:"ticker"`
+	Timeframe string             `json:"timeframe"`
+	Name      string             `json:"name"`
+	Series    string             `json:"series"`
+	Params    IndicatorParams    `json:"params"`
+	Lines     map[string][]Point `json:"lines"`
+}
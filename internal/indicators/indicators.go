@@ -0,0 +1,282 @@
+// Package indicators computes technical indicators over prices, oldest first. Every function returns a
+// series as long as its input, NaN until enough values are in, so it lines up with the candles it was
+// computed on. Leading NaNs in the input are skipped, which lets indicators be chained, e.g. MACD's signal.
+package indicators
+
+import (
+	"math"
+)
+
+// MACDSeries are the MACD line, its signal line and the histogram between them.
+type MACDSeries struct {
+	MACD      []float64
+	Signal    []float64
+	Histogram []float64
+}
+
+// BollingerSeries are the middle band, an SMA, and the bands a number of standard deviations around it.
+type BollingerSeries struct {
+	Middle []float64
+	Upper  []float64
+	Lower  []float64
+}
+
+// StochasticSeries are %K, smoothed, and %D, the SMA of %K.
+type StochasticSeries struct {
+	K []float64
+	D []float64
+}
+
+// SMA is the simple moving average of the last length values.
+func SMA(values []float64, length int) []float64 {
+	res := nanSeries(len(values))
+	if length <= 0 {
+		return res
+	}
+
+	start := firstValid(values)
+
+	var sum float64
+	for i := start; i < len(values); i++ {
+		sum += values[i]
+		if i-start >= length {
+			sum -= values[i-length]
+		}
+
+		if i-start >= length-1 {
+			res[i] = sum / float64(length)
+		}
+	}
+
+	return res
+}
+
+// EMA is the exponential moving average, seeded with the SMA of the first length values.
+func EMA(values []float64, length int) []float64 {
+	res := nanSeries(len(values))
+	if length <= 0 {
+		return res
+	}
+
+	start := firstValid(values)
+	seed := start + length - 1
+	if seed >= len(values) {
+		return res
+	}
+
+	var sum float64
+	for i := start; i <= seed; i++ {
+		sum += values[i]
+	}
+	res[seed] = sum / float64(length)
+
+	k := 2 / float64(length+1)
+	for i := seed + 1; i < len(values); i++ {
+		res[i] = values[i]*k + res[i-1]*(1-k)
+	}
+
+	return res
+}
+
+// RSI uses Wilder's smoothing, the first value is at the length-th change.
+// Reference https://blog.quantinsti.com/rsi-indicator/
+func RSI(values []float64, length int) []float64 {
+	res := nanSeries(len(values))
+	if length <= 0 {
+		return res
+	}
+
+	start := firstValid(values)
+	if len(values)-start <= length {
+		return res
+	}
+
+	var averageGain, averageLoss float64
+	for i := start + 1; i <= start+length; i++ {
+		gain, loss := change(values[i-1], values[i])
+		averageGain += gain
+		averageLoss += loss
+	}
+	averageGain /= float64(length)
+	averageLoss /= float64(length)
+	res[start+length] = rsiValue(averageGain, averageLoss)
+
+	for i := start + length + 1; i < len(values); i++ {
+		gain, loss := change(values[i-1], values[i])
+		averageGain = (averageGain*float64(length-1) + gain) / float64(length)
+		averageLoss = (averageLoss*float64(length-1) + loss) / float64(length)
+		res[i] = rsiValue(averageGain, averageLoss)
+	}
+
+	return res
+}
+
+// StdDev is the population standard deviation of the last length values, like Bollinger Bands use.
+func StdDev(values []float64, length int) []float64 {
+	res := nanSeries(len(values))
+	mean := SMA(values, length)
+
+	for i := range values {
+		if math.IsNaN(mean[i]) {
+			continue
+		}
+
+		var sum float64
+		for _, value := range values[i-length+1 : i+1] {
+			sum += (value - mean[i]) * (value - mean[i])
+		}
+		res[i] = math.Sqrt(sum / float64(length))
+	}
+
+	return res
+}
+
+// MACD is the fast EMA minus the slow EMA, with an EMA of that as the signal line.
+func MACD(values []float64, fast, slow, signal int) *MACDSeries {
+	fastEMA, slowEMA := EMA(values, fast), EMA(values, slow)
+
+	macd := nanSeries(len(values))
+	for i := range values {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+
+	signalLine := EMA(macd, signal)
+
+	histogram := nanSeries(len(values))
+	for i := range values {
+		histogram[i] = macd[i] - signalLine[i]
+	}
+
+	return &MACDSeries{
+		MACD:      macd,
+		Signal:    signalLine,
+		Histogram: histogram,
+	}
+}
+
+// Bollinger puts the bands multiplier standard deviations above and below the SMA.
+func Bollinger(values []float64, length int, multiplier float64) *BollingerSeries {
+	middle, deviation := SMA(values, length), StdDev(values, length)
+
+	upper, lower := nanSeries(len(values)), nanSeries(len(values))
+	for i := range values {
+		upper[i] = middle[i] + multiplier*deviation[i]
+		lower[i] = middle[i] - multiplier*deviation[i]
+	}
+
+	return &BollingerSeries{
+		Middle: middle,
+		Upper:  upper,
+		Lower:  lower,
+	}
+}
+
+// ATR is the average true range with Wilder's smoothing. Only closes are stored, so the true range is
+// the move from one close to the next.
+func ATR(values []float64, length int) []float64 {
+	res := nanSeries(len(values))
+	if length <= 0 {
+		return res
+	}
+
+	start := firstValid(values)
+	if len(values)-start <= length {
+		return res
+	}
+
+	var average float64
+	for i := start + 1; i <= start+length; i++ {
+		average += math.Abs(values[i] - values[i-1])
+	}
+	average /= float64(length)
+	res[start+length] = average
+
+	for i := start + length + 1; i < len(values); i++ {
+		average = (average*float64(length-1) + math.Abs(values[i]-values[i-1])) / float64(length)
+		res[i] = average
+	}
+
+	return res
+}
+
+// Stochastic puts each close in the range of the last length highs and lows, 0 at the lowest low and 100
+// at the highest high. %K is that smoothed over smoothing values and %D is %K's SMA over signal values.
+// Closes passed as the highs and lows give the close-only stochastic.
+func Stochastic(high, low, closes []float64, length, smoothing, signal int) *StochasticSeries {
+	raw := nanSeries(len(closes))
+	if length > 0 {
+		start := firstValid(closes)
+		for i := start + length - 1; i < len(closes); i++ {
+			highest, lowest := math.Inf(-1), math.Inf(1)
+			for j := i - length + 1; j <= i; j++ {
+				highest, lowest = math.Max(highest, high[j]), math.Min(lowest, low[j])
+			}
+
+			// A flat range has the close in the middle of it
+			raw[i] = 50
+			if highest > lowest {
+				raw[i] = 100 * (closes[i] - lowest) / (highest - lowest)
+			}
+		}
+	}
+
+	k := SMA(raw, smoothing)
+
+	return &StochasticSeries{
+		K: k,
+		D: SMA(k, signal),
+	}
+}
+
+// StochRSI is the stochastic of the RSI, the RSI being its own highs and lows.
+func StochRSI(values []float64, rsiLength, length, smoothing, signal int) *StochasticSeries {
+	rsi := RSI(values, rsiLength)
+
+	return Stochastic(rsi, rsi, rsi, length, smoothing, signal)
+}
+
+// Last is the latest value of a series, NaN when it's empty.
+func Last(series []float64) float64 {
+	if len(series) == 0 {
+		return math.NaN()
+	}
+
+	return series[len(series)-1]
+}
+
+func rsiValue(averageGain, averageLoss float64) float64 {
+	if averageLoss == 0 {
+		return 100
+	}
+
+	return 100 - 100/(1+averageGain/averageLoss)
+}
+
+// change splits the move from prev to curr into a gain and a loss, one of them 0.
+func change(prev, curr float64) (float64, float64) {
+	diff := curr - prev
+	if diff > 0 {
+		return diff, 0
+	}
+
+	return 0, -diff
+}
+
+func nanSeries(length int) []float64 {
+	res := make([]float64, length)
+	for i := range res {
+		res[i] = math.NaN()
+	}
+
+	return res
+}
+
+func firstValid(values []float64) int {
+	for i, value := range values {
+		if !math.IsNaN(value) {
+			return i
+		}
+	}
+
+	return len(values)
+}
@@ -0,0 +1,182 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+var nan = math.NaN()
+
+// assertSeries compares a series with the expected values, NaN only matching NaN.
+func assertSeries(t *testing.T, name string, got, want []float64, tolerance float64) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s has %d values, want %d", name, len(got), len(want))
+	}
+
+	for i := range want {
+		if math.IsNaN(want[i]) != math.IsNaN(got[i]) || math.Abs(got[i]-want[i]) > tolerance {
+			t.Errorf("%s[%d] = %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestSMA(t *testing.T) {
+	assertSeries(t, "SMA 3", SMA([]float64{1, 2, 3, 4, 5}, 3), []float64{nan, nan, 2, 3, 4}, 1e-9)
+
+	// Leading NaNs, like a chained indicator's, are skipped
+	assertSeries(t, "SMA 2 of chained", SMA([]float64{nan, nan, 2, 4, 8}, 2), []float64{nan, nan, nan, 3, 6}, 1e-9)
+
+	assertSeries(t, "SMA longer than data", SMA([]float64{1, 2}, 3), []float64{nan, nan}, 0)
+}
+
+func TestEMA(t *testing.T) {
+	// Seeded with the SMA of the first 3, then k = 2/(3+1)
+	assertSeries(t, "EMA 3", EMA([]float64{1, 2, 3, 4, 5, 9}, 3), []float64{nan, nan, 2, 3, 4, 6.5}, 1e-9)
+
+	// The EMA of a line lags it by (length-1)/2 exactly
+	line := make([]float64, 50)
+	for i := range line {
+		line[i] = float64(i)
+	}
+	ema := EMA(line, 10)
+	for i := 9; i < len(line); i++ {
+		if math.Abs(ema[i]-(line[i]-4.5)) > 1e-9 {
+			t.Errorf("EMA 10 of a line [%d] = %v, want %v", i, ema[i], line[i]-4.5)
+		}
+	}
+}
+
+func TestRSI(t *testing.T) {
+	// StockCharts' RSI 14 example. Their table rounds the averages to 2 decimals, hence the tolerance.
+	// Reference https://school.stockcharts.com/doku.php?id=technical_indicators:relative_strength_index_rsi
+	closes := []float64{
+		44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08, 45.89, 46.03, 45.61, 46.28,
+		46.28, 46.00, 46.03, 46.41, 46.22, 45.64, 46.21,
+	}
+
+	want := []float64{
+		nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan,
+		70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93,
+	}
+
+	assertSeries(t, "RSI 14", RSI(closes, 14), want, 0.1)
+
+	assertSeries(t, "RSI of gains only", RSI([]float64{1, 2, 3, 4}, 2), []float64{nan, nan, 100, 100}, 1e-9)
+	assertSeries(t, "RSI of losses only", RSI([]float64{4, 3, 2, 1}, 2), []float64{nan, nan, 0, 0}, 1e-9)
+}
+
+func TestMACD(t *testing.T) {
+	// On a line both EMAs lag by (length-1)/2, the MACD is the difference of the lags: (26-12)/2
+	line := make([]float64, 60)
+	for i := range line {
+		line[i] = 100 + 2*float64(i)
+	}
+
+	macd := MACD(line, 12, 26, 9)
+
+	for i, value := range macd.MACD {
+		switch {
+		case i < 25 && !math.IsNaN(value):
+			t.Errorf("MACD[%d] = %v before the slow EMA has data", i, value)
+		case i >= 25 && math.Abs(value-14) > 1e-9:
+			t.Errorf("MACD[%d] = %v, want 14", i, value)
+		}
+	}
+
+	// The signal line starts 9 MACD values in
+	for i, value := range macd.Signal {
+		switch {
+		case i < 33 && !math.IsNaN(value):
+			t.Errorf("signal[%d] = %v before 9 MACD values", i, value)
+		case i >= 33 && (math.Abs(value-14) > 1e-9 || math.Abs(macd.Histogram[i]) > 1e-9):
+			t.Errorf("signal[%d] = %v with histogram %v, want 14 and 0", i, value, macd.Histogram[i])
+		}
+	}
+
+	// Worked out by hand: EMA 2 is 2, 8/3, 44/9, 116/27 and EMA 3 is 7/3, 25/6, 49/12
+	small := MACD([]float64{1, 3, 3, 6, 4}, 2, 3, 2)
+	assertSeries(t, "MACD 2/3", small.MACD, []float64{nan, nan, 1.0 / 3, 13.0 / 18, 23.0 / 108}, 1e-9)
+	assertSeries(t, "signal 2", small.Signal, []float64{nan, nan, nan, 19.0 / 36, 103.0 / 324}, 1e-9)
+	assertSeries(t, "histogram", small.Histogram, []float64{nan, nan, nan, 7.0 / 36, -34.0 / 324}, 1e-9)
+}
+
+func TestBollinger(t *testing.T) {
+	// A mean of 5 and a population standard deviation of exactly 2
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	bands := Bollinger(values, 8, 2)
+	last := len(values) - 1
+
+	if bands.Middle[last] != 5 || bands.Upper[last] != 9 || bands.Lower[last] != 1 {
+		t.Errorf("bands = %v/%v/%v, want 5/9/1", bands.Lower[last], bands.Middle[last], bands.Upper[last])
+	}
+
+	for i := 0; i < last; i++ {
+		if !math.IsNaN(bands.Upper[i]) {
+			t.Errorf("upper band[%d] = %v before 8 values", i, bands.Upper[i])
+		}
+	}
+
+	assertSeries(t, "StdDev of a flat series", StdDev([]float64{3, 3, 3}, 2), []float64{nan, 0, 0}, 1e-9)
+}
+
+func TestATR(t *testing.T) {
+	// Moves of 1, 2, 3 and 4: the first ATR is their mean over 2, then Wilder's smoothing
+	assertSeries(t, "ATR 2", ATR([]float64{1, 2, 4, 7, 11}, 2), []float64{nan, nan, 1.5, 2.25, 3.125}, 1e-9)
+
+	// Falls count like rises
+	assertSeries(t, "ATR 2 of falls", ATR([]float64{11, 7, 4, 2, 1}, 2), []float64{nan, nan, 3.5, 2.75, 1.875}, 1e-9)
+}
+
+func TestStochastic(t *testing.T) {
+	high := []float64{10, 12, 11, 13}
+	low := []float64{8, 9, 9, 10}
+	closes := []float64{9, 11, 10, 12}
+
+	// %K over 2 candles unsmoothed: (11-8)/(12-8), (10-9)/(12-9), (12-9)/(13-9)
+	stoch := Stochastic(high, low, closes, 2, 1, 2)
+	assertSeries(t, "%K", stoch.K, []float64{nan, 75, 100.0 / 3, 75}, 1e-9)
+	assertSeries(t, "%D", stoch.D, []float64{nan, nan, 325.0 / 6, 325.0 / 6}, 1e-9)
+
+	// Smoothing %K over 2 moves %D one candle later
+	smoothed := Stochastic(high, low, closes, 2, 2, 2)
+	assertSeries(t, "smoothed %K", smoothed.K, []float64{nan, nan, 325.0 / 6, 325.0 / 6}, 1e-9)
+	assertSeries(t, "smoothed %D", smoothed.D, []float64{nan, nan, nan, 325.0 / 6}, 1e-9)
+
+	// A flat range puts the close in the middle
+	flat := Stochastic([]float64{5, 5, 5}, []float64{5, 5, 5}, []float64{5, 5, 5}, 2, 1, 1)
+	assertSeries(t, "%K of a flat range", flat.K, []float64{nan, 50, 50}, 1e-9)
+}
+
+func TestStochRSI(t *testing.T) {
+	closes := []float64{1, 2, 3, 2, 3, 4, 3}
+
+	stoch := StochRSI(closes, 2, 2, 1, 1)
+	rsi := RSI(closes, 2)
+
+	// The RSI is its own high and low, so %K is where it is in its last 2 values
+	for i := 3; i < len(closes); i++ {
+		highest, lowest := math.Max(rsi[i-1], rsi[i]), math.Min(rsi[i-1], rsi[i])
+
+		want := 50.0
+		if highest > lowest {
+			want = 100 * (rsi[i] - lowest) / (highest - lowest)
+		}
+
+		if math.Abs(stoch.K[i]-want) > 1e-9 {
+			t.Errorf("%%K[%d] = %v, want %v", i, stoch.K[i], want)
+		}
+	}
+}
+
+func TestLast(t *testing.T) {
+	if got := Last([]float64{1, 2, 3}); got != 3 {
+		t.Errorf("Last = %v, want 3", got)
+	}
+
+	if got := Last(nil); !math.IsNaN(got) {
+		t.Errorf("Last of nothing = %v, want NaN", got)
+	}
+}
@@ -0,0 +1,70 @@
+package indicators
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/signalb/internal/database"
+)
+
+// IndicatorParams are the settings of an indicator. Each indicator only uses some of them, the ones it
+// doesn't use stay 0 and are left out of responses.
+type IndicatorParams struct {
+	Length     int     `json:"length,omitempty" form:"length"`
+	Fast       int     `json:"fast,omitempty" form:"fast"`
+	Slow       int     `json:"slow,omitempty" form:"slow"`
+	Signal     int     `json:"signal,omitempty" form:"signal"`
+	Smoothing  int     `json:"smoothing,omitempty" form:"smoothing"`
+	Multiplier float64 `json:"multiplier,omitempty" form:"multiplier"`
+}
+
+// IndicatorReq picks the indicator, the price series it's computed on and how many candles are returned.
+type IndicatorReq struct {
+	Name    string `form:"name"`
+	Series  string `form:"series"`
+	Candles int    `form:"candles"`
+	IndicatorParams
+}
+
+// validateIndicatorReq fills in the defaults of the series, candles and the indicator's params, then
+// checks them.
+func validateIndicatorReq(req *IndicatorReq) error {
+	def, ok := definitions[req.Name]
+	if !ok {
+		return fmt.Errorf("valid names: %v", Names())
+	}
+
+	if req.Series == "" {
+		req.Series = database.AdjustedSeries
+	}
+
+	if !slices.Contains(database.AllowedPriceSeries, req.Series) {
+		return fmt.Errorf("valid series: %v", database.AllowedPriceSeries)
+	}
+
+	if req.Candles == 0 {
+		req.Candles = DefaultCandles
+	}
+
+	if req.Candles < 1 || req.Candles > MaxCandles {
+		return fmt.Errorf("candles must be between 1 and %d", MaxCandles)
+	}
+
+	req.IndicatorParams = def.withDefaults(req.IndicatorParams)
+	p := &req.IndicatorParams
+
+	if p.Length < 0 || p.Fast < 0 || p.Slow < 0 || p.Signal < 0 || p.Smoothing < 0 || p.Multiplier < 0 {
+		return errors.New("length, fast, slow, signal, smoothing and multiplier must be positive")
+	}
+
+	if p.Length > MaxCandles || p.Slow > MaxCandles {
+		return fmt.Errorf("length and slow must be at most %d", MaxCandles)
+	}
+
+	if p.Slow != 0 && p.Fast >= p.Slow {
+		return errors.New("fast must be shorter than slow")
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package indicators
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/signalb/internal/database"
+)
+
+const (
+	DefaultCandles = 120
+	MaxCandles     = 1000
+)
+
+// definition is how an indicator is computed, the params it uses are the ones with a default.
+type definition struct {
+	defaults IndicatorParams
+	compute  func(prices []float64, p *IndicatorParams) map[string][]float64
+}
+
+var definitions = map[string]*definition{
+	"sma": {
+		defaults: IndicatorParams{Length: 20},
+		compute: func(prices []float64, p *IndicatorParams) map[string][]float64 {
+			return map[string][]float64{"sma": SMA(prices, p.Length)}
+		},
+	},
+	"ema": {
+		defaults: IndicatorParams{Length: 20},
+		compute: func(prices []float64, p *IndicatorParams) map[string][]float64 {
+			return map[string][]float64{"ema": EMA(prices, p.Length)}
+		},
+	},
+	"rsi": {
+		defaults: IndicatorParams{Length: 14},
+		compute: func(prices []float64, p *IndicatorParams) map[string][]float64 {
+			return map[string][]float64{"rsi": RSI(prices, p.Length)}
+		},
+	},
+	"macd": {
+		defaults: IndicatorParams{Fast: 12, Slow: 26, Signal: 9},
+		compute: func(prices []float64, p *IndicatorParams) map[string][]float64 {
+			macd := MACD(prices, p.Fast, p.Slow, p.Signal)
+			return map[string][]float64{"macd": macd.MACD, "signal": macd.Signal, "histogram": macd.Histogram}
+		},
+	},
+	"bollinger": {
+		defaults: IndicatorParams{Length: 20, Multiplier: 2},
+		compute: func(prices []float64, p *IndicatorParams) map[string][]float64 {
+			bands := Bollinger(prices, p.Length, p.Multiplier)
+			return map[string][]float64{"middle": bands.Middle, "upper": bands.Upper, "lower": bands.Lower}
+		},
+	},
+	// Only closes are stored, so the stochastic is the close-only one
+	"stoch": {
+		defaults: IndicatorParams{Length: 14, Smoothing: 3, Signal: 3},
+		compute: func(prices []float64, p *IndicatorParams) map[string][]float64 {
+			stoch := Stochastic(prices, prices, prices, p.Length, p.Smoothing, p.Signal)
+			return map[string][]float64{"k": stoch.K, "d": stoch.D}
+		},
+	},
+	// The RSI and the stochastic over it share the length
+	"stochrsi": {
+		defaults: IndicatorParams{Length: 14, Smoothing: 3, Signal: 3},
+		compute: func(prices []float64, p *IndicatorParams) map[string][]float64 {
+			stoch := StochRSI(prices, p.Length, p.Length, p.Smoothing, p.Signal)
+			return map[string][]float64{"k": stoch.K, "d": stoch.D}
+		},
+	},
+	"atr": {
+		defaults: IndicatorParams{Length: 14},
+		compute: func(prices []float64, p *IndicatorParams) map[string][]float64 {
+			return map[string][]float64{"atr": ATR(prices, p.Length)}
+		},
+	},
+}
+
+// Names are the indicators the API serves, sorted.
+func Names() []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// withDefaults fills the params the indicator uses but weren't given and clears the ones it doesn't use.
+func (d *definition) withDefaults(p IndicatorParams) IndicatorParams {
+	return IndicatorParams{
+		Length:     withDefault(p.Length, d.defaults.Length),
+		Fast:       withDefault(p.Fast, d.defaults.Fast),
+		Slow:       withDefault(p.Slow, d.defaults.Slow),
+		Signal:     withDefault(p.Signal, d.defaults.Signal),
+		Smoothing:  withDefault(p.Smoothing, d.defaults.Smoothing),
+		Multiplier: withDefault(p.Multiplier, d.defaults.Multiplier),
+	}
+}
+
+func withDefault[T int | float64](value, def T) T {
+	switch {
+	case def == 0:
+		return 0
+	case value == 0:
+		return def
+	default:
+		return value
+	}
+}
+
+// LoadIndicator computes the indicator over all of the ticker's stored prices, the same series strategies
+// are evaluated on, and returns the values of the last candles. EMAs and Wilder's smoothing depend on every
+// price before, so a shorter history would give different values. The request is expected to be validated.
+func LoadIndicator(c context.Context, tickerSymbol, timeframe string, req *IndicatorReq) (*IndicatorResp, error) {
+	def, ok := definitions[req.Name]
+	if !ok {
+		return nil, fmt.Errorf("indicator %s not found", req.Name)
+	}
+
+	params := def.withDefaults(req.IndicatorParams)
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	data, err := database.Client.GetPriceByTicker(ctx, tickerSymbol, timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make([]float64, 0, len(data))
+	for _, d := range data {
+		if req.Series == database.RawSeries {
+			prices = append(prices, d.Price)
+		} else {
+			prices = append(prices, d.AdjPrice)
+		}
+	}
+
+	start := max(len(data)-req.Candles, 0)
+	lines := make(map[string][]Point)
+	for name, values := range def.compute(prices, &params) {
+		points := make([]Point, 0, len(data)-start)
+		for i := start; i < len(data); i++ {
+			point := Point{Time: data[i].Time.Format(database.PriceTimeLayout)}
+			if value := values[i]; !math.IsNaN(value) && !math.IsInf(value, 0) {
+				point.Value = &value
+			}
+			points = append(points, point)
+		}
+		lines[name] = points
+	}
+
+	return &IndicatorResp{
+		Ticker:    tickerSymbol,
+		Timeframe: timeframe,
+		Name:      req.Name,
+		Series:    req.Series,
+		Params:    params,
+		Lines:     lines,
+	}, nil
+}
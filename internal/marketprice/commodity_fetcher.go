@@ -0,0 +1,41 @@
+package marketprice
+
+import (
+	"context"
+
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/ticker"
+)
+
+// CommodityDataFetcher fetches continuous front month futures from RapidAPI. Their symbols share nothing
+// with the commodity's name, e.g. GC=F for gold, so every commodity needs its RapidAPI symbol registered.
+type CommodityDataFetcher struct {
+	source *rapidAPISource
+}
+
+func NewCommodityDataFetcher(
+	credentials *RapidAPICredentials,
+	client *providerClient,
+	cal calendar.Calendar,
+) *CommodityDataFetcher {
+	return &CommodityDataFetcher{
+		source: newRapidAPISource(credentials, client, cal),
+	}
+}
+
+func (commodityDF *CommodityDataFetcher) FetchClass() string {
+	return ticker.CommodityClass
+}
+
+func (commodityDF *CommodityDataFetcher) Fetch(
+	ctx context.Context,
+	timeframe, tickerSymbol string,
+	length int,
+) ([]*TickerData, error) {
+	symbol, err := getRapidAPISymbol(ctx, tickerSymbol, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return commodityDF.source.fetchCandles(ctx, timeframe, symbol, length)
+}
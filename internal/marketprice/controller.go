@@ -1,9 +1,14 @@
 package marketprice
 
 import (
+	"database/sql"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"slices"
+	"strconv"
+	"strings"
 
 	errorsStdLib "errors"
 
@@ -51,12 +56,51 @@ func RefreshMarketpriceByTimeframeController(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, res)
+	// Some tickers failing is a partial success, the results that did refresh are kept
+	c.JSON(errors.BatchStatus(len(res.Results), len(res.Failures)), res)
 }
 
+// GetMarketpriceDataByTickerTimeframeController serves stored candles, only calling the provider with ?source=live.
 func GetMarketpriceDataByTickerTimeframeController(c *gin.Context) {
 	tf := c.Param("timeframe")
 	ticker := c.Param("ticker")
+	source := c.DefaultQuery("source", DatabaseSource)
+
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return
+	}
+
+	switch source {
+	case LiveSource:
+		getLiveMarketpriceData(c, tf, ticker)
+	case DatabaseSource:
+		getStoredMarketpriceData(c, tf, ticker)
+	default:
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid sources: %v", []string{DatabaseSource, LiveSource})))
+	}
+}
+
+func getStoredMarketpriceData(c *gin.Context, tf, ticker string) {
+	query, err := parsePriceHistoryQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(err))
+		return
+	}
+
+	res, err := getPriceHistory(c.Request.Context(), ticker, tf, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func getLiveMarketpriceData(c *gin.Context, tf, ticker string) {
 	ctx := c.Request.Context()
 
 	class, err := getTickerClass(ctx, ticker)
@@ -82,6 +126,139 @@ func GetMarketpriceDataByTickerTimeframeController(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": res,
+		"source": LiveSource,
+		"data":   res,
+	})
+}
+
+func GetProviderQuotaController(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"providers": limiterManager.getUsages(),
+	})
+}
+
+func ImportPriceCSVController(c *gin.Context) {
+	tf := c.Param("timeframe")
+	ticker := c.Param("ticker")
+
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return
+	}
+
+	overwrite, err := strconv.ParseBool(c.DefaultQuery("overwrite", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(fmt.Errorf("invalid overwrite: %w", err)))
+		return
+	}
+
+	class, err := getTickerClass(c.Request.Context(), ticker)
+	if errorsStdLib.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(fmt.Errorf("%s is not registered", ticker)))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	body, err := getCSVBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
+		return
+	}
+	defer body.Close()
+
+	mapping := NewCSVColumnMapping(c.Query("timeColumn"), c.Query("priceColumn"), c.Query("timeLayout"))
+
+	data, err := ParsePriceCSV(body, ticker, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(fmt.Errorf("parse csv: %w", err)))
+		return
+	}
+
+	if err := ValidateCandleTimes(class, tf, data); err != nil {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(err))
+		return
+	}
+
+	res, err := ImportPriceHistory(c.Request.Context(), ticker, tf, data, overwrite)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseInsertionError, err)))
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+// getCSVBody accepts either a multipart upload in the "file" field or the raw request body.
+func getCSVBody(c *gin.Context) (io.ReadCloser, error) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.Request.Body, nil
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+
+	return fileHeader.Open()
+}
+
+func ExportPriceCSVController(c *gin.Context) {
+	tf := c.Param("timeframe")
+	ticker := c.Param("ticker")
+	format := c.DefaultQuery("format", "csv")
+
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return
+	}
+
+	if format != "csv" {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(errorsStdLib.New("valid formats: [csv]")))
+		return
+	}
+
+	data, err := ExportPriceHistory(c.Request.Context(), ticker, tf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_%s.csv", ticker, strings.ToLower(tf)))
+	c.Header("Content-Type", "text/csv")
+	c.Status(http.StatusOK)
+
+	if err := WritePriceCSV(c.Writer, data); err != nil {
+		log.Printf("Error writing csv export for %s %s: %v", ticker, tf, err)
+	}
+}
+
+func GetQuarantinedCandlesController(c *gin.Context) {
+	tf := c.Param("timeframe")
+	ticker := c.Param("ticker")
+
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return
+	}
+
+	res, err := getQuarantinedCandles(c.Request.Context(), ticker, tf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quarantined": res,
 	})
 }
@@ -0,0 +1,188 @@
+package marketprice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/signalb/internal/database"
+	timeframePkg "github.com/signalb/internal/timeframe"
+)
+
+const (
+	SplitAction = "split"
+
+	// minSplitRatio tells splits apart from dividends, which move the adjustment factor by a few percent at most
+	minSplitRatio = 1.2
+	// adjustmentTolerance ignores float noise when comparing stored and fetched adjusted prices
+	adjustmentTolerance = 1e-6
+)
+
+// adjustmentResult reports what applyCorporateActions changed.
+type adjustmentResult struct {
+	splits       []database.CorporateAction
+	backAdjusted int64
+}
+
+// applyCorporateActions records splits found in the fetched candles, fills in adjusted prices the provider
+// left out from recorded splits, and rescales older stored candles so the adjusted series stays continuous.
+func applyCorporateActions(
+	c context.Context,
+	tickerSymbol, timeframe string,
+	data []*TickerData,
+) (*adjustmentResult, error) {
+	ctx, cancel := context.WithTimeout(c, 10*time.Second)
+	defer cancel()
+
+	if len(data) == 0 {
+		return &adjustmentResult{}, nil
+	}
+
+	sorted := make([]*TickerData, len(data))
+	copy(sorted, data)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	result := &adjustmentResult{}
+
+	// Daily candles are the only ones detected from, weekly ones would record each split a second time
+	// under the week's start. Weekly candles come adjusted from the provider anyway.
+	if timeframe == timeframePkg.Day1 {
+		for _, split := range detectSplits(tickerSymbol, sorted) {
+			inserted, err := database.Client.InsertCorporateAction(ctx, &split)
+			if err != nil {
+				return nil, err
+			}
+
+			// The split stays in the fetched window for a while, only report it the first time
+			if !inserted {
+				continue
+			}
+
+			log.Printf("Detected %v:1 split for %s at %s", split.Ratio, tickerSymbol, split.Time.Format(database.PriceTimeLayout))
+			result.splits = append(result.splits, split)
+		}
+	}
+
+	if err := fillAdjustedPrices(ctx, tickerSymbol, sorted); err != nil {
+		return nil, err
+	}
+
+	backAdjusted, err := backAdjustStoredPrices(ctx, tickerSymbol, timeframe, sorted[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result.backAdjusted = backAdjusted
+
+	return result, nil
+}
+
+// detectSplits compares the adjustment factor, price over adjusted price, of consecutive candles. A split
+// divides it by the split ratio from the first candle trading on the new share count.
+func detectSplits(tickerSymbol string, sorted []*TickerData) []database.CorporateAction {
+	var splits []database.CorporateAction
+
+	for i := 1; i < len(sorted); i++ {
+		prev, curr := sorted[i-1], sorted[i]
+		if prev.AdjustedPrice == 0 || curr.AdjustedPrice == 0 || curr.Price == 0 {
+			continue
+		}
+
+		ratio := (prev.Price / prev.AdjustedPrice) / (curr.Price / curr.AdjustedPrice)
+		if ratio < minSplitRatio && ratio > 1/minSplitRatio {
+			continue
+		}
+
+		splits = append(splits, database.CorporateAction{
+			TickerSymbol: tickerSymbol,
+			Time:         curr.Time,
+			Kind:         SplitAction,
+			Ratio:        roundSplitRatio(ratio),
+		})
+	}
+
+	return splits
+}
+
+// roundSplitRatio snaps the ratio to what companies announce, e.g. 4 or 0.1 for a 1-for-10 reverse split.
+func roundSplitRatio(ratio float64) float64 {
+	if ratio >= 1 {
+		return math.Round(ratio*100) / 100
+	}
+
+	return 1 / (math.Round(100/ratio) / 100)
+}
+
+// fillAdjustedPrices derives missing adjusted prices by dividing the price by every recorded split after the candle.
+func fillAdjustedPrices(ctx context.Context, tickerSymbol string, sorted []*TickerData) error {
+	var missing bool
+	for _, d := range sorted {
+		if d.AdjustedPrice == 0 {
+			missing = true
+			break
+		}
+	}
+
+	if !missing {
+		return nil
+	}
+
+	actions, err := database.Client.GetCorporateActions(ctx, tickerSymbol)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range sorted {
+		if d.AdjustedPrice != 0 {
+			continue
+		}
+
+		// Stored times are wall clock times, compare them that way
+		candleTime := d.Time.Format(database.PriceTimeLayout)
+
+		factor := 1.0
+		for _, action := range actions {
+			if action.Kind == SplitAction && action.Time.Format(database.PriceTimeLayout) > candleTime {
+				factor *= action.Ratio
+			}
+		}
+
+		d.AdjustedPrice = d.Price / factor
+	}
+
+	return nil
+}
+
+// backAdjustStoredPrices rescales the adjusted prices of candles stored before the oldest fetched one by
+// how much its adjusted price moved since it was stored, after a split or a dividend.
+func backAdjustStoredPrices(ctx context.Context, tickerSymbol, timeframe string, oldest *TickerData) (int64, error) {
+	stored, err := database.Client.QueryPriceData(ctx, tickerSymbol, timeframe, &database.PriceFilter{
+		From:  &oldest.Time,
+		To:    &oldest.Time,
+		Limit: 1,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(stored) == 0 || stored[0].AdjPrice == 0 {
+		return 0, nil
+	}
+
+	factor := oldest.AdjustedPrice / stored[0].AdjPrice
+	if math.Abs(factor-1) < adjustmentTolerance {
+		return 0, nil
+	}
+
+	scaled, err := database.Client.ScaleAdjustedPrices(ctx, tickerSymbol, timeframe, oldest.Time, factor)
+	if err != nil {
+		return 0, fmt.Errorf("back-adjust %s %s: %w", tickerSymbol, timeframe, err)
+	}
+
+	log.Printf("Back-adjusted %d %s %s candles by %v", scaled, tickerSymbol, timeframe, factor)
+
+	return scaled, nil
+}
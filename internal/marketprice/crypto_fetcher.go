@@ -2,14 +2,12 @@ package marketprice
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
+	"github.com/signalb/internal/database"
 	"github.com/signalb/internal/ticker"
 	timeframePkg "github.com/signalb/internal/timeframe"
 )
@@ -25,20 +23,23 @@ type CoinAPICredentials struct {
 }
 
 type CryptoDataFetcher struct {
-	tiCredentials        *TokenInsightCredentials
-	coinAPICredentials   *CoinAPICredentials
-	tickerToShorthandMap map[string]string
+	tiCredentials      *TokenInsightCredentials
+	coinAPICredentials *CoinAPICredentials
+	tiClient           *providerClient
+	coinAPIClient      *providerClient
 }
 
 func NewCryptoDataFetcher(
 	tiCredentials *TokenInsightCredentials,
 	coinAPICredentials *CoinAPICredentials,
-	tickerToShorthandMap map[string]string,
+	tiClient *providerClient,
+	coinAPIClient *providerClient,
 ) *CryptoDataFetcher {
 	return &CryptoDataFetcher{
-		tiCredentials:        tiCredentials,
-		coinAPICredentials:   coinAPICredentials,
-		tickerToShorthandMap: tickerToShorthandMap,
+		tiCredentials:      tiCredentials,
+		coinAPICredentials: coinAPICredentials,
+		tiClient:           tiClient,
+		coinAPIClient:      coinAPIClient,
 	}
 }
 
@@ -85,10 +86,15 @@ func handleDay1DataFetching(
 	tickerSymbol string,
 	length int,
 ) ([]*TickerData, error) {
+	slug, err := getTokenInsightSlug(ctx, tickerSymbol)
+	if err != nil {
+		return nil, err
+	}
+
 	interval := "day"
-	url := fmt.Sprintf("%s/%s?interval=%s&length=%d", fetcher.tiCredentials.baseURL, strings.ToLower(tickerSymbol), interval, length)
+	url := fmt.Sprintf("%s/%s?interval=%s&length=%d", fetcher.tiCredentials.baseURL, slug, interval, length)
 
-	resp, err := makeTokenInsightHistoricalDataCall(ctx, url, fetcher.tiCredentials.key)
+	resp, err := makeTokenInsightHistoricalDataCall(ctx, fetcher.tiClient, url, fetcher.tiCredentials.key)
 	if err != nil {
 		return nil, err
 	}
@@ -106,15 +112,21 @@ func handleHour4DataFetching(ctx context.Context, fetcher *CryptoDataFetcher, ti
 	lengthMultiplier := 4
 	adjustedLength := lengthMultiplier * length
 	interval := "hour"
-	url := fmt.Sprintf("%s/%s?interval=%s&length=%d", fetcher.tiCredentials.baseURL, strings.ToLower(tickerSymbol), interval, adjustedLength)
 
-	resp, err := makeTokenInsightHistoricalDataCall(ctx, url, fetcher.tiCredentials.key)
+	slug, err := getTokenInsightSlug(ctx, tickerSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s?interval=%s&length=%d", fetcher.tiCredentials.baseURL, slug, interval, adjustedLength)
+
+	resp, err := makeTokenInsightHistoricalDataCall(ctx, fetcher.tiClient, url, fetcher.tiCredentials.key)
 	if err != nil {
 		return nil, err
 	}
 
 	var results []*TickerData
-	for i := len(resp.Data.MarketChart) - 1; len(results) < length; i -= lengthMultiplier {
+	for i := len(resp.Data.MarketChart) - 1; i >= 0 && len(results) < length; i -= lengthMultiplier {
 		currRes := resp.Data.MarketChart[i]
 		result := NewTickerData(time.UnixMilli(currRes.Timestamp), currRes.Price)
 		results = append(results, result)
@@ -123,17 +135,16 @@ func handleHour4DataFetching(ctx context.Context, fetcher *CryptoDataFetcher, ti
 	return results, nil
 }
 
-// CoinAPI can't do concurrent calls with our tier, so can't have more than 1 crypto in W1.
+// CoinAPI can't do concurrent calls with our tier, the CoinAPI limiter serialises W1 crypto fetches.
 func handleWeek1DataFetching(
 	ctx context.Context,
 	fetcher *CryptoDataFetcher,
 	tickerSymbol string,
 	length int,
 ) ([]*TickerData, error) {
-	tickerShorthand, ok := fetcher.tickerToShorthandMap[tickerSymbol]
-
-	if !ok {
-		return nil, errors.New("cant map crypto ticker")
+	symbolID, err := getCoinAPISymbolID(ctx, tickerSymbol)
+	if err != nil {
+		return nil, err
 	}
 
 	periodID := "7DAY"
@@ -142,22 +153,22 @@ func handleWeek1DataFetching(
 	timeStart := currTime.AddDate(0, 0, -length*7).Format("2006-01-02T15:04:05")
 
 	url := fmt.Sprintf(
-		"%s/BITSTAMP_SPOT_%s_USD/history?time_start=%s&time_end=%s&period_id=%s&limit=%d",
+		"%s/%s/history?time_start=%s&time_end=%s&period_id=%s&limit=%d",
 		fetcher.coinAPICredentials.baseURL,
-		tickerShorthand,
+		symbolID,
 		timeStart,
 		timeEnd,
 		periodID,
 		length)
 
-	resp, err := makeCoinAPIHistoricalDataCall(ctx, url, fetcher.coinAPICredentials.key)
+	resp, err := makeCoinAPIHistoricalDataCall(ctx, fetcher.coinAPIClient, url, fetcher.coinAPICredentials.key)
 	if err != nil {
 		return nil, err
 	}
 
 	var results []*TickerData
 
-	for i := len(resp) - 1; len(results) < length; i-- {
+	for i := len(resp) - 1; i >= 0 && len(results) < length; i-- {
 		currRes := resp[i]
 
 		// Layout representing the format of the input string
@@ -176,60 +187,79 @@ func handleWeek1DataFetching(
 	return results, nil
 }
 
-func makeTokenInsightHistoricalDataCall(ctx context.Context, url, key string) (*TokenInsightDataResp, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// getTokenInsightSlug falls back to the lowercased ticker symbol, which is the slug for most coins.
+func getTokenInsightSlug(c context.Context, tickerSymbol string) (string, error) {
+	providerSymbols, err := getTickerProviderSymbols(c, tickerSymbol)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	req.Header.Add("accept", "application/json")
-	req.Header.Add("TI_API_KEY", key)
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+	if slug, ok := providerSymbols[ticker.TokenInsightSlug]; ok {
+		return slug, nil
 	}
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
+	return strings.ToLower(tickerSymbol), nil
+}
+
+// getCoinAPISymbolID prefers an explicit exchange pair, otherwise quotes the CoinAPI asset against USD on Bitstamp.
+func getCoinAPISymbolID(c context.Context, tickerSymbol string) (string, error) {
+	providerSymbols, err := getTickerProviderSymbols(c, tickerSymbol)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	var resp TokenInsightDataResp
+	if pair, ok := providerSymbols[ticker.ExchangePair]; ok {
+		return pair, nil
+	}
 
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, err
+	if asset, ok := providerSymbols[ticker.CoinAPIAsset]; ok {
+		return fmt.Sprintf("BITSTAMP_SPOT_%s_USD", asset), nil
 	}
 
-	return &resp, nil
+	return "", fmt.Errorf("no %s or %s symbol registered for %s", ticker.ExchangePair, ticker.CoinAPIAsset, tickerSymbol)
 }
 
-func makeCoinAPIHistoricalDataCall(ctx context.Context, url, key string) ([]CoinAPIDataResp, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+func getTickerProviderSymbols(c context.Context, tickerSymbol string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.GetTickerProviderSymbols(ctx, tickerSymbol)
+}
+
+func makeTokenInsightHistoricalDataCall(ctx context.Context, client *providerClient, url, key string) (*TokenInsightDataResp, error) {
+	headers := map[string]string{
+		"accept":     "application/json",
+		"TI_API_KEY": key,
 	}
 
-	req.Header.Add("accept", "application/json")
-	req.Header.Add("X-CoinAPI-Key", key)
+	var resp TokenInsightDataResp
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
+	if err := client.getJSON(ctx, url, headers, &resp); err != nil {
 		return nil, err
 	}
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+	if len(resp.Data.MarketChart) == 0 {
+		return nil, fmt.Errorf("%s: %w", TokenInsightProvider, ErrNoData)
+	}
+
+	return &resp, nil
+}
+
+func makeCoinAPIHistoricalDataCall(ctx context.Context, client *providerClient, url, key string) ([]CoinAPIDataResp, error) {
+	headers := map[string]string{
+		"accept":        "application/json",
+		"X-CoinAPI-Key": key,
 	}
 
 	var resp []CoinAPIDataResp
 
-	if err := json.Unmarshal(body, &resp); err != nil {
+	if err := client.getJSON(ctx, url, headers, &resp); err != nil {
 		return nil, err
 	}
 
+	if len(resp) == 0 {
+		return nil, fmt.Errorf("%s: %w", CoinAPIProvider, ErrNoData)
+	}
+
 	return resp, nil
 }
@@ -0,0 +1,254 @@
+package marketprice
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/database"
+)
+
+const (
+	DefaultCSVTimeColumn  = "time"
+	DefaultCSVPriceColumn = "price"
+
+	importBatchSize = 500
+)
+
+// priceTimeLayouts are tried in order when parsing times without an explicit layout.
+var priceTimeLayouts = []string{
+	database.PriceTimeLayout,
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+type CSVColumnMapping struct {
+	TimeColumn  string
+	PriceColumn string
+	TimeLayout  string
+}
+
+func NewCSVColumnMapping(timeColumn, priceColumn, timeLayout string) *CSVColumnMapping {
+	if timeColumn == "" {
+		timeColumn = DefaultCSVTimeColumn
+	}
+
+	if priceColumn == "" {
+		priceColumn = DefaultCSVPriceColumn
+	}
+
+	return &CSVColumnMapping{
+		TimeColumn:  timeColumn,
+		PriceColumn: priceColumn,
+		TimeLayout:  timeLayout,
+	}
+}
+
+// ParsePriceCSV reads a CSV with a header row into price data, validating every row. All problems
+// found are reported together so a file can be fixed in one go.
+func ParsePriceCSV(r io.Reader, tickerSymbol string, mapping *CSVColumnMapping) ([]database.PriceData, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	timeIdx, priceIdx := -1, -1
+	for i, col := range header {
+		switch {
+		case strings.EqualFold(strings.TrimSpace(col), mapping.TimeColumn):
+			timeIdx = i
+		case strings.EqualFold(strings.TrimSpace(col), mapping.PriceColumn):
+			priceIdx = i
+		}
+	}
+
+	if timeIdx == -1 || priceIdx == -1 {
+		return nil, fmt.Errorf("csv header %v must contain columns %q and %q", header, mapping.TimeColumn, mapping.PriceColumn)
+	}
+
+	var (
+		results  []database.PriceData
+		problems []string
+		seen     = make(map[time.Time]int)
+		now      = time.Now()
+	)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		parsedTime, err := parsePriceTime(record[timeIdx], mapping.TimeLayout)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		if parsedTime.After(now) {
+			problems = append(problems, fmt.Sprintf("line %d: time %s is in the future", line, record[timeIdx]))
+			continue
+		}
+
+		if prevLine, ok := seen[parsedTime]; ok {
+			problems = append(problems, fmt.Sprintf("line %d: duplicate time %s, first seen on line %d", line, record[timeIdx], prevLine))
+			continue
+		}
+		seen[parsedTime] = line
+
+		price, err := strconv.ParseFloat(strings.TrimSpace(record[priceIdx]), 64)
+		if err != nil || math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 {
+			problems = append(problems, fmt.Sprintf("line %d: invalid price %q", line, record[priceIdx]))
+			continue
+		}
+
+		results = append(results, database.PriceData{
+			TickerSymbol: tickerSymbol,
+			Time:         parsedTime,
+			Price:        price,
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid csv rows: %s", strings.Join(problems, "; "))
+	}
+
+	if len(results) == 0 {
+		return nil, errors.New("csv contains no rows")
+	}
+
+	return results, nil
+}
+
+func parsePriceTime(val, layout string) (time.Time, error) {
+	val = strings.TrimSpace(val)
+
+	if layout != "" {
+		parsed, err := time.Parse(layout, val)
+		return parsed.UTC(), err
+	}
+
+	// Times with an explicit zone are stored as UTC wall clock times
+	for _, l := range priceTimeLayouts {
+		if parsed, err := time.Parse(l, val); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised time %q, expected one of %v", val, priceTimeLayouts)
+}
+
+func WritePriceCSV(w io.Writer, data []database.PriceData) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{DefaultCSVTimeColumn, DefaultCSVPriceColumn}); err != nil {
+		return err
+	}
+
+	for _, d := range data {
+		record := []string{
+			d.Time.Format(database.PriceTimeLayout),
+			strconv.FormatFloat(d.Price, 'f', -1, 64),
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ValidateCandleTimes reports the rows whose times don't start a candle of the timeframe on the calendar
+// of the ticker's class. Crypto candles are keyed by the provider's own times and aren't checked.
+func ValidateCandleTimes(class, timeframe string, data []database.PriceData) error {
+	cal, ok := calendar.CalendarManager.GetCalendarByClass(class)
+	if !ok {
+		return nil
+	}
+
+	var problems []string
+	for _, d := range data {
+		if start := storedCandleStart(cal, timeframe, d.Time); !start.Equal(d.Time) {
+			problems = append(problems, fmt.Sprintf("%s isn't the start of a %s candle on the %s calendar, expected %s",
+				d.Time.Format(database.PriceTimeLayout), timeframe, cal.Name(), start.Format(database.PriceTimeLayout)))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("misaligned candle times: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// ImportPriceHistory stores the rows, skipping the ones whose times are already stored for the ticker
+// unless overwrite replaces them.
+func ImportPriceHistory(c context.Context, tickerSymbol, timeframe string, data []database.PriceData, overwrite bool) (*ImportPriceResp, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Minute)
+	defer cancel()
+
+	stored, err := database.Client.GetPriceByTicker(ctx, tickerSymbol, timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	storedTimes := make(map[string]bool, len(stored))
+	for _, d := range stored {
+		storedTimes[d.Time.Format(database.PriceTimeLayout)] = true
+	}
+
+	resp := &ImportPriceResp{
+		Ticker:    tickerSymbol,
+		Timeframe: timeframe,
+	}
+
+	var toInsert []database.PriceData
+	for _, d := range data {
+		switch {
+		case !storedTimes[d.Time.Format(database.PriceTimeLayout)]:
+			toInsert = append(toInsert, d)
+			resp.Imported++
+		case overwrite:
+			toInsert = append(toInsert, d)
+			resp.Overwritten++
+		default:
+			resp.Skipped++
+		}
+	}
+
+	for start := 0; start < len(toInsert); start += importBatchSize {
+		end := min(start+importBatchSize, len(toInsert))
+
+		if err := database.Client.InsertPriceData(ctx, timeframe, toInsert[start:end]); err != nil {
+			return nil, fmt.Errorf("insert rows %d-%d: %w", start, end, err)
+		}
+	}
+
+	return resp, nil
+}
+
+func ExportPriceHistory(c context.Context, tickerSymbol, timeframe string) ([]database.PriceData, error) {
+	ctx, cancel := context.WithTimeout(c, 30*time.Second)
+	defer cancel()
+
+	return database.Client.GetPriceByTicker(ctx, tickerSymbol, timeframe)
+}
@@ -0,0 +1,138 @@
+package marketprice
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+	"github.com/signalb/internal/ticker"
+	"github.com/signalb/internal/timeframe"
+)
+
+func useUSEquityCalendar(t *testing.T) {
+	t.Helper()
+
+	usEquity, err := calendar.NewUSEquityCalendar()
+	if err != nil {
+		t.Fatalf("load calendar: %v", err)
+	}
+
+	prev := calendar.CalendarManager
+	calendar.CalendarManager = calendar.NewCalendarManager(map[string]calendar.Calendar{ticker.StockClass: usEquity})
+	t.Cleanup(func() { calendar.CalendarManager = prev })
+}
+
+func priceRows(times ...string) []database.PriceData {
+	data := make([]database.PriceData, 0, len(times))
+	for i, val := range times {
+		parsed, _ := time.Parse(database.PriceTimeLayout, val)
+		data = append(data, database.PriceData{TickerSymbol: "AAPL", Time: parsed, Price: float64(100 + i)})
+	}
+	return data
+}
+
+// useStoredAAPL stores AAPL daily candles from 2024-05-01 at prices 1, 2, 3 and so on.
+func useStoredAAPL(t *testing.T, days int) *dbtest.DB {
+	t.Helper()
+
+	times := make([]string, 0, days)
+	for i := 0; i < days; i++ {
+		times = append(times, time.Date(2024, 5, 1+i, 0, 0, 0, 0, time.UTC).Format(database.PriceTimeLayout))
+	}
+
+	data := priceRows(times...)
+	for i := range data {
+		data[i].Price = float64(i + 1)
+	}
+
+	db := dbtest.Use(t)
+	db.SetPrices("AAPL", timeframe.Day1, data)
+
+	return db
+}
+
+func pricesOfData(data []database.PriceData) []float64 {
+	prices := make([]float64, 0, len(data))
+	for _, d := range data {
+		prices = append(prices, d.Price)
+	}
+	return prices
+}
+
+func TestValidateCandleTimes(t *testing.T) {
+	useUSEquityCalendar(t)
+
+	aligned := []struct {
+		timeframe string
+		times     []string
+	}{
+		{timeframe.Day1, []string{"2024-05-06 00:00:00", "2024-05-07 00:00:00"}},
+		{timeframe.Hour4, []string{"2024-05-06 09:30:00", "2024-05-06 13:30:00"}},
+		{timeframe.Week1, []string{"2024-05-06 00:00:00", "2024-05-13 00:00:00"}},
+	}
+
+	for _, tt := range aligned {
+		if err := ValidateCandleTimes(ticker.StockClass, tt.timeframe, priceRows(tt.times...)); err != nil {
+			t.Errorf("%s %v: %v", tt.timeframe, tt.times, err)
+		}
+	}
+
+	misaligned := []struct {
+		timeframe string
+		time      string
+		expected  string
+	}{
+		{timeframe.Day1, "2024-05-06 16:00:00", "2024-05-06 00:00:00"},
+		{timeframe.Hour4, "2024-05-06 12:00:00", "2024-05-06 09:30:00"},
+		{timeframe.Week1, "2024-05-08 00:00:00", "2024-05-06 00:00:00"},
+	}
+
+	for _, tt := range misaligned {
+		err := ValidateCandleTimes(ticker.StockClass, tt.timeframe, priceRows(tt.time))
+		if err == nil || !strings.Contains(err.Error(), "expected "+tt.expected) {
+			t.Errorf("%s %s: error = %v, want one expecting %s", tt.timeframe, tt.time, err, tt.expected)
+		}
+	}
+
+	// Crypto trades around the clock without a calendar to check against
+	if err := ValidateCandleTimes(ticker.CryptoClass, timeframe.Day1, priceRows("2024-05-06 16:00:00")); err != nil {
+		t.Errorf("crypto: %v", err)
+	}
+}
+
+func TestImportPriceHistorySkipsOrOverwritesStoredRows(t *testing.T) {
+	rows := priceRows("2024-05-02 00:00:00", "2024-05-03 00:00:00", "2024-05-04 00:00:00")
+
+	db := useStoredAAPL(t, 3)
+	resp, err := ImportPriceHistory(context.Background(), "AAPL", timeframe.Day1, rows, false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	if resp.Imported != 1 || resp.Skipped != 2 || resp.Overwritten != 0 {
+		t.Errorf("imported %d, skipped %d, overwrote %d, want 1, 2 and 0", resp.Imported, resp.Skipped, resp.Overwritten)
+	}
+
+	if got := pricesOfData(db.Prices("AAPL", timeframe.Day1)); !slices.Equal(got, []float64{1, 2, 3, 102}) {
+		t.Errorf("stored %v, want the stored rows kept", got)
+	}
+
+	db = useStoredAAPL(t, 3)
+	resp, err = ImportPriceHistory(context.Background(), "AAPL", timeframe.Day1, rows, true)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	if resp.Imported != 1 || resp.Skipped != 0 || resp.Overwritten != 2 {
+		t.Errorf("imported %d, skipped %d, overwrote %d, want 1, 0 and 2", resp.Imported, resp.Skipped, resp.Overwritten)
+	}
+
+	if got := pricesOfData(db.Prices("AAPL", timeframe.Day1)); !slices.Equal(got, []float64{1, 100, 101, 102}) {
+		t.Errorf("stored %v, want the stored rows replaced", got)
+	}
+}
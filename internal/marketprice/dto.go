@@ -1,5 +1,12 @@
 package marketprice
 
+import (
+	"time"
+
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/errors"
+)
+
 type (
 	MetadataResp struct {
 		Symbol   string `json:"Symbol"`
@@ -47,5 +54,46 @@ type (
 		Class           string        `json:"class"`
 		Timeframe       string        `json:"timeframe"`
 		RefreshedPrices []*TickerData `json:"refreshedPrices"`
+		MissingCandles  []time.Time   `json:"missingCandles,omitempty"`
+		TrimmedCandles  int64         `json:"trimmedCandles"`
+		// SplitEvents are splits newly detected in the refreshed candles
+		SplitEvents []database.CorporateAction `json:"splitEvents,omitempty"`
+		// BackAdjustedCandles counts older stored candles whose adjusted price was rescaled
+		BackAdjustedCandles int64 `json:"backAdjustedCandles,omitempty"`
+		// Rejections are the fetched candles validation rules rejected, quarantined or flagged
+		Rejections []*CandleRejection `json:"rejections,omitempty"`
+		SkipReason string             `json:"skipReason,omitempty"`
+	}
+
+	// RefreshBatchResp has a result for every ticker that refreshed or was skipped and a failure for every
+	// ticker that didn't
+	RefreshBatchResp struct {
+		Timeframe string                  `json:"timeframe"`
+		Results   []*RefreshPriceResp     `json:"results"`
+		Failures  []*errors.TickerFailure `json:"failures"`
+	}
+
+	ImportPriceResp struct {
+		Ticker      string `json:"ticker"`
+		Timeframe   string `json:"timeframe"`
+		Imported    int    `json:"imported"`
+		Overwritten int    `json:"overwritten"`
+		Skipped     int    `json:"skipped"`
+	}
+
+	StoredCandle struct {
+		Time     string  `json:"time"`
+		Price    float64 `json:"price"`
+		AdjPrice float64 `json:"adjPrice"`
+		Rounded  bool    `json:"rounded,omitempty"`
+		Suspect  bool    `json:"suspect,omitempty"`
+	}
+
+	PriceHistoryResp struct {
+		Ticker     string          `json:"ticker"`
+		Timeframe  string          `json:"timeframe"`
+		Source     string          `json:"source"`
+		Data       []*StoredCandle `json:"data"`
+		NextCursor string          `json:"nextCursor,omitempty"`
 	}
 )
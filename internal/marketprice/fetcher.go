@@ -2,9 +2,13 @@ package marketprice
 
 import (
 	"log"
+	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/ticker"
 )
 
 var fetcherManager *FetcherManager
@@ -36,13 +40,63 @@ func InitFetchers() {
 		log.Println("Failed to load .env file", err)
 	}
 
-	stockDF := getStockDataFetcher()
-	cryptoDF := getCryptoDataFetcher()
+	limiterManager = NewLimiterManager(
+		NewProviderLimiter(RapidAPIProvider, getLimiterConfig("RAPID_API", LimiterConfig{
+			RequestsPerSecond: 5,
+			MaxInFlight:       5,
+		})),
+		NewProviderLimiter(TokenInsightProvider, getLimiterConfig("TI", LimiterConfig{
+			RequestsPerMinute: 30,
+			MaxInFlight:       5,
+		})),
+		// CoinAPI can't do concurrent calls with our tier
+		NewProviderLimiter(CoinAPIProvider, getLimiterConfig("COINAPI", LimiterConfig{
+			RequestsPerSecond: 1,
+			MaxInFlight:       1,
+			DailyQuota:        100,
+		})),
+	)
+
+	candleValidator = NewCandleValidator(getValidationConfig())
+
+	fetchers := append(getRapidAPIFetchers(), getCryptoDataFetcher())
+
+	fetcherManager = NewFetcherManager(fetchers...)
+}
+
+// getLimiterConfig overrides the given defaults with <PREFIX>_RPS, <PREFIX>_RPM,
+// <PREFIX>_MAX_IN_FLIGHT and <PREFIX>_DAILY_QUOTA when they are set.
+func getLimiterConfig(envPrefix string, defaults LimiterConfig) LimiterConfig {
+	config := defaults
+
+	overrides := map[string]*int{
+		"_RPS":           &config.RequestsPerSecond,
+		"_RPM":           &config.RequestsPerMinute,
+		"_MAX_IN_FLIGHT": &config.MaxInFlight,
+		"_DAILY_QUOTA":   &config.DailyQuota,
+	}
 
-	fetcherManager = NewFetcherManager(stockDF, cryptoDF)
+	for suffix, field := range overrides {
+		val := os.Getenv(envPrefix + suffix)
+		if val == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(val)
+		if err != nil {
+			log.Printf("error parsing %s%s, using default %d: %v", envPrefix, suffix, *field, err)
+			continue
+		}
+
+		*field = parsed
+	}
+
+	return config
 }
 
-func getStockDataFetcher() TickerDataFetcher {
+// getRapidAPIFetchers returns the fetchers of every class quoted by RapidAPI. They share one client, so
+// one limiter keeps all of them within the RapidAPI plan.
+func getRapidAPIFetchers() []TickerDataFetcher {
 	rapidAPIBaseURL := os.Getenv("RAPID_API_BASE_URL")
 	rapidAPIKey := os.Getenv("RAPID_API_KEY")
 	rapidAPIHost := os.Getenv("RAPID_API_HOST")
@@ -53,7 +107,22 @@ func getStockDataFetcher() TickerDataFetcher {
 		host:    rapidAPIHost,
 	}
 
-	return NewStockDataFetcher(credentials)
+	client := newProviderClient(RapidAPIProvider, http.DefaultClient, limiterManager.getLimiterByProvider(RapidAPIProvider))
+
+	getCalendar := func(class string) calendar.Calendar {
+		cal, ok := calendar.CalendarManager.GetCalendarByClass(class)
+		if !ok {
+			log.Fatalf("no market calendar for %s class", class)
+		}
+		return cal
+	}
+
+	return []TickerDataFetcher{
+		NewStockDataFetcher(credentials, client, getCalendar(ticker.StockClass)),
+		NewForexDataFetcher(credentials, client, getCalendar(ticker.ForexClass)),
+		NewCommodityDataFetcher(credentials, client, getCalendar(ticker.CommodityClass)),
+		NewIndexDataFetcher(credentials, client, getCalendar(ticker.IndexClass)),
+	}
 }
 
 func getCryptoDataFetcher() TickerDataFetcher {
@@ -72,10 +141,8 @@ func getCryptoDataFetcher() TickerDataFetcher {
 		key:     coinAPIKey,
 	}
 
-	tickerToShorthandMap := map[string]string{
-		"BITCOIN":  "BTC",
-		"ETHEREUM": "ETH",
-	}
+	tiClient := newProviderClient(TokenInsightProvider, http.DefaultClient, limiterManager.getLimiterByProvider(TokenInsightProvider))
+	coinAPIClient := newProviderClient(CoinAPIProvider, http.DefaultClient, limiterManager.getLimiterByProvider(CoinAPIProvider))
 
-	return NewCryptoDataFetcher(tiCredentials, coinAPICredentials, tickerToShorthandMap)
+	return NewCryptoDataFetcher(tiCredentials, coinAPICredentials, tiClient, coinAPIClient)
 }
@@ -0,0 +1,40 @@
+package marketprice
+
+import (
+	"context"
+
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/ticker"
+)
+
+// ForexDataFetcher fetches currency pairs from RapidAPI, quoted as the pair with an =X suffix, e.g. EURUSD=X.
+type ForexDataFetcher struct {
+	source *rapidAPISource
+}
+
+func NewForexDataFetcher(
+	credentials *RapidAPICredentials,
+	client *providerClient,
+	cal calendar.Calendar,
+) *ForexDataFetcher {
+	return &ForexDataFetcher{
+		source: newRapidAPISource(credentials, client, cal),
+	}
+}
+
+func (forexDF *ForexDataFetcher) FetchClass() string {
+	return ticker.ForexClass
+}
+
+func (forexDF *ForexDataFetcher) Fetch(
+	ctx context.Context,
+	timeframe, tickerSymbol string,
+	length int,
+) ([]*TickerData, error) {
+	symbol, err := getRapidAPISymbol(ctx, tickerSymbol, tickerSymbol+"=X")
+	if err != nil {
+		return nil, err
+	}
+
+	return forexDF.source.fetchCandles(ctx, timeframe, symbol, length)
+}
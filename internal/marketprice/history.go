@@ -0,0 +1,139 @@
+package marketprice
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/database"
+)
+
+const (
+	DatabaseSource = "database"
+	LiveSource     = "live"
+
+	defaultPriceHistoryLimit = RefreshAllDataLength
+	maxPriceHistoryLimit     = 1000
+)
+
+// priceHistoryQuery pages through stored candles. The cursor is the time of the last candle
+// of the previous page, continuing after it in ascending order and before it in descending order.
+// Without from or order the page holds the most recent candles in ascending order, the cursor is
+// then the time of the first candle and continues before it.
+type priceHistoryQuery struct {
+	from       *time.Time
+	to         *time.Time
+	cursor     *time.Time
+	limit      int
+	descending bool
+	latest     bool
+}
+
+func parsePriceHistoryQuery(c *gin.Context) (*priceHistoryQuery, error) {
+	query := &priceHistoryQuery{
+		limit: defaultPriceHistoryLimit,
+	}
+
+	timeParams := map[string]**time.Time{
+		"from":   &query.from,
+		"to":     &query.to,
+		"cursor": &query.cursor,
+	}
+
+	for param, field := range timeParams {
+		val := c.Query(param)
+		if val == "" {
+			continue
+		}
+
+		parsed, err := parsePriceTime(val, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", param, err)
+		}
+
+		*field = &parsed
+	}
+
+	if val := c.Query("limit"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil || limit < 1 || limit > maxPriceHistoryLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxPriceHistoryLimit)
+		}
+
+		query.limit = limit
+	}
+
+	order, ok := c.GetQuery("order")
+	switch {
+	case !ok:
+		query.latest = query.from == nil
+	case order == "asc":
+	case order == "desc":
+		query.descending = true
+	default:
+		return nil, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+
+	return query, nil
+}
+
+func getPriceHistory(c context.Context, ticker, timeframe string, query *priceHistoryQuery) (*PriceHistoryResp, error) {
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	filter := &database.PriceFilter{
+		From:       query.from,
+		To:         query.to,
+		Limit:      query.limit + 1, // One extra row tells us whether there's another page
+		Descending: query.descending || query.latest,
+	}
+
+	if query.descending || query.latest {
+		filter.Before = query.cursor
+	} else {
+		filter.After = query.cursor
+	}
+
+	data, err := database.Client.QueryPriceData(ctx, ticker, timeframe, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &PriceHistoryResp{
+		Ticker:    ticker,
+		Timeframe: timeframe,
+		Source:    DatabaseSource,
+		Data:      make([]*StoredCandle, 0, min(len(data), query.limit)),
+	}
+
+	hasMore := len(data) > query.limit
+	if hasMore {
+		data = data[:query.limit]
+	}
+
+	if query.latest {
+		slices.Reverse(data)
+	}
+
+	for _, d := range data {
+		resp.Data = append(resp.Data, &StoredCandle{
+			Time:     d.Time.Format(database.PriceTimeLayout),
+			Price:    d.Price,
+			AdjPrice: d.AdjPrice,
+			Rounded:  d.Rounded,
+			Suspect:  d.Suspect,
+		})
+	}
+
+	switch {
+	case hasMore && query.latest:
+		resp.NextCursor = resp.Data[0].Time
+	case hasMore:
+		resp.NextCursor = resp.Data[len(resp.Data)-1].Time
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package marketprice
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+)
+
+// useStoredPrices stores BTC daily candles from 2024-05-01 at prices 1, 2, 3 and so on.
+func useStoredPrices(t *testing.T, days int) {
+	t.Helper()
+
+	data := make([]database.PriceData, 0, days)
+	for i := 0; i < days; i++ {
+		data = append(data, database.PriceData{TickerSymbol: "BTC", Time: time.Date(2024, 5, 1+i, 0, 0, 0, 0, time.UTC),
+			Price: float64(i + 1)})
+	}
+
+	dbtest.Use(t).SetPrices("BTC", "D1", data)
+}
+
+func queryHistory(t *testing.T, rawQuery string) *PriceHistoryResp {
+	t.Helper()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/marketprice/D1/BTC?"+rawQuery, nil)
+
+	query, err := parsePriceHistoryQuery(c)
+	if err != nil {
+		t.Fatalf("parse %q: %v", rawQuery, err)
+	}
+
+	resp, err := getPriceHistory(context.Background(), "BTC", "D1", query)
+	if err != nil {
+		t.Fatalf("history %q: %v", rawQuery, err)
+	}
+
+	return resp
+}
+
+func pricesOf(resp *PriceHistoryResp) []float64 {
+	prices := make([]float64, 0, len(resp.Data))
+	for _, d := range resp.Data {
+		prices = append(prices, d.Price)
+	}
+	return prices
+}
+
+func TestPriceHistoryDefaultsToLatestCandles(t *testing.T) {
+	useStoredPrices(t, 5)
+
+	resp := queryHistory(t, "limit=2")
+	if got := pricesOf(resp); !slices.Equal(got, []float64{4, 5}) || resp.NextCursor != "2024-05-04 00:00:00" {
+		t.Fatalf("latest page = %v with cursor %q, want [4 5] with cursor 2024-05-04 00:00:00", got, resp.NextCursor)
+	}
+
+	// The cursor walks back to older candles
+	resp = queryHistory(t, "limit=2&cursor="+url.QueryEscape(resp.NextCursor))
+	if got := pricesOf(resp); !slices.Equal(got, []float64{2, 3}) || resp.NextCursor != "2024-05-02 00:00:00" {
+		t.Fatalf("second page = %v with cursor %q, want [2 3]", got, resp.NextCursor)
+	}
+
+	resp = queryHistory(t, "limit=2&cursor="+url.QueryEscape(resp.NextCursor))
+	if got := pricesOf(resp); !slices.Equal(got, []float64{1}) || resp.NextCursor != "" {
+		t.Errorf("last page = %v with cursor %q, want [1] and no cursor", got, resp.NextCursor)
+	}
+
+	resp = queryHistory(t, "limit=2&to=2024-05-03")
+	if got := pricesOf(resp); !slices.Equal(got, []float64{2, 3}) {
+		t.Errorf("latest up to 2024-05-03 = %v, want [2 3]", got)
+	}
+}
+
+func TestPriceHistoryFromOrOrderPagesForward(t *testing.T) {
+	useStoredPrices(t, 5)
+
+	if got := pricesOf(queryHistory(t, "limit=2&from=2024-05-02")); !slices.Equal(got, []float64{2, 3}) {
+		t.Errorf("from 2024-05-02 = %v, want [2 3]", got)
+	}
+
+	if got := pricesOf(queryHistory(t, "limit=2&order=asc")); !slices.Equal(got, []float64{1, 2}) {
+		t.Errorf("order=asc = %v, want [1 2]", got)
+	}
+
+	if got := pricesOf(queryHistory(t, "limit=2&order=desc")); !slices.Equal(got, []float64{5, 4}) {
+		t.Errorf("order=desc = %v, want [5 4]", got)
+	}
+}
@@ -0,0 +1,40 @@
+package marketprice
+
+import (
+	"context"
+
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/ticker"
+)
+
+// IndexDataFetcher fetches indices from RapidAPI, quoted with a ^ prefix, e.g. ^GSPC for the S&P 500.
+type IndexDataFetcher struct {
+	source *rapidAPISource
+}
+
+func NewIndexDataFetcher(
+	credentials *RapidAPICredentials,
+	client *providerClient,
+	cal calendar.Calendar,
+) *IndexDataFetcher {
+	return &IndexDataFetcher{
+		source: newRapidAPISource(credentials, client, cal),
+	}
+}
+
+func (indexDF *IndexDataFetcher) FetchClass() string {
+	return ticker.IndexClass
+}
+
+func (indexDF *IndexDataFetcher) Fetch(
+	ctx context.Context,
+	timeframe, tickerSymbol string,
+	length int,
+) ([]*TickerData, error) {
+	symbol, err := getRapidAPISymbol(ctx, tickerSymbol, "^"+tickerSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexDF.source.fetchCandles(ctx, timeframe, symbol, length)
+}
@@ -8,12 +8,18 @@ import (
 type TickerData struct {
 	Time  time.Time
 	Price float64
+	// AdjustedPrice is the split and dividend adjusted price, 0 while unknown
+	AdjustedPrice float64
+	// Suspect is set by validation rules that flag candles instead of rejecting them
+	Suspect bool
 }
 
+// NewTickerData creates a candle of an asset without corporate actions, its adjusted price is the price.
 func NewTickerData(time time.Time, price float64) *TickerData {
 	return &TickerData{
-		Time:  time,
-		Price: price,
+		Time:          time,
+		Price:         price,
+		AdjustedPrice: price,
 	}
 }
 
@@ -0,0 +1,231 @@
+package marketprice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	RapidAPIProvider     = "rapidapi"
+	TokenInsightProvider = "tokeninsight"
+	CoinAPIProvider      = "coinapi"
+)
+
+var limiterManager *LimiterManager
+
+type LimiterConfig struct {
+	RequestsPerSecond int `json:"requestsPerSecond"`
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	MaxInFlight       int `json:"maxInFlight"`
+	DailyQuota        int `json:"dailyQuota"`
+}
+
+type ProviderUsage struct {
+	Provider         string        `json:"provider"`
+	Config           LimiterConfig `json:"config"`
+	InFlight         int           `json:"inFlight"`
+	RequestsLastMin  int           `json:"requestsLastMinute"`
+	DailyUsed        int           `json:"dailyUsed"`
+	DailyRemaining   int           `json:"dailyRemaining"`
+	DailyQuotaResets time.Time     `json:"dailyQuotaResets"`
+}
+
+type QuotaExceededError struct {
+	Provider   string
+	DailyQuota int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s daily quota of %d requests exhausted", e.Provider, e.DailyQuota)
+}
+
+// ProviderLimiter throttles calls to a single data provider. A zero value in any
+// LimiterConfig field disables that particular limit.
+type ProviderLimiter struct {
+	provider string
+	config   LimiterConfig
+	inFlight chan struct{}
+
+	mu         sync.Mutex
+	recentReqs []time.Time // start times of requests within the last minute
+	dailyUsed  int
+	quotaDay   time.Time // zero until the first request or usage report rolls it to the current day
+
+	// clock is time.Now, swapped in tests
+	clock func() time.Time
+}
+
+func NewProviderLimiter(provider string, config LimiterConfig) *ProviderLimiter {
+	var inFlight chan struct{}
+	if config.MaxInFlight > 0 {
+		inFlight = make(chan struct{}, config.MaxInFlight)
+	}
+
+	return &ProviderLimiter{
+		provider: provider,
+		config:   config,
+		inFlight: inFlight,
+		clock:    time.Now,
+	}
+}
+
+// Acquire blocks until a request may be made to the provider, and returns a release
+// func that must be called once the request completes.
+func (l *ProviderLimiter) Acquire(ctx context.Context) (func(), error) {
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if l.inFlight != nil {
+			<-l.inFlight
+		}
+	}
+
+	for {
+		wait, err := l.reserve(l.clock())
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		if wait == 0 {
+			return release, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// reserve records a request at now if allowed, otherwise returns how long to wait before trying again.
+func (l *ProviderLimiter) reserve(now time.Time) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollQuotaDay(now)
+	l.pruneRecent(now)
+
+	if l.config.DailyQuota > 0 && l.dailyUsed >= l.config.DailyQuota {
+		return 0, &QuotaExceededError{Provider: l.provider, DailyQuota: l.config.DailyQuota}
+	}
+
+	if wait := windowWait(l.recentReqs, now, time.Second, l.config.RequestsPerSecond); wait > 0 {
+		return wait, nil
+	}
+
+	if wait := windowWait(l.recentReqs, now, time.Minute, l.config.RequestsPerMinute); wait > 0 {
+		return wait, nil
+	}
+
+	l.recentReqs = append(l.recentReqs, now)
+	l.dailyUsed++
+
+	return 0, nil
+}
+
+// windowWait returns how long until fewer than limit requests fall within the trailing window.
+func windowWait(reqs []time.Time, now time.Time, window time.Duration, limit int) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+
+	windowStart := now.Add(-window)
+	idx := sort.Search(len(reqs), func(i int) bool { return reqs[i].After(windowStart) })
+
+	inWindow := reqs[idx:]
+	if len(inWindow) < limit {
+		return 0
+	}
+
+	// The oldest request that has to leave the window before another one is allowed
+	blocking := inWindow[len(inWindow)-limit]
+	return blocking.Add(window).Sub(now)
+}
+
+func (l *ProviderLimiter) pruneRecent(now time.Time) {
+	minuteAgo := now.Add(-time.Minute)
+	idx := sort.Search(len(l.recentReqs), func(i int) bool { return l.recentReqs[i].After(minuteAgo) })
+	l.recentReqs = l.recentReqs[idx:]
+}
+
+func (l *ProviderLimiter) rollQuotaDay(now time.Time) {
+	if day := startOfUTCDay(now); day.After(l.quotaDay) {
+		l.quotaDay = day
+		l.dailyUsed = 0
+	}
+}
+
+func (l *ProviderLimiter) Usage() *ProviderUsage {
+	now := l.clock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollQuotaDay(now)
+	l.pruneRecent(now)
+
+	remaining := -1
+	if l.config.DailyQuota > 0 {
+		remaining = l.config.DailyQuota - l.dailyUsed
+	}
+
+	return &ProviderUsage{
+		Provider:         l.provider,
+		Config:           l.config,
+		InFlight:         len(l.inFlight),
+		RequestsLastMin:  len(l.recentReqs),
+		DailyUsed:        l.dailyUsed,
+		DailyRemaining:   remaining,
+		DailyQuotaResets: l.quotaDay.AddDate(0, 0, 1),
+	}
+}
+
+func startOfUTCDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+type LimiterManager struct {
+	ProviderToLimiter map[string]*ProviderLimiter
+}
+
+func NewLimiterManager(limiters ...*ProviderLimiter) *LimiterManager {
+	providerToLimiter := make(map[string]*ProviderLimiter)
+
+	for _, limiter := range limiters {
+		providerToLimiter[limiter.provider] = limiter
+	}
+
+	return &LimiterManager{
+		ProviderToLimiter: providerToLimiter,
+	}
+}
+
+func (lm *LimiterManager) getLimiterByProvider(provider string) *ProviderLimiter {
+	return lm.ProviderToLimiter[provider]
+}
+
+func (lm *LimiterManager) getUsages() []*ProviderUsage {
+	usages := make([]*ProviderUsage, 0, len(lm.ProviderToLimiter))
+
+	for _, limiter := range lm.ProviderToLimiter {
+		usages = append(usages, limiter.Usage())
+	}
+
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Provider < usages[j].Provider })
+
+	return usages
+}
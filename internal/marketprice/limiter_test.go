@@ -0,0 +1,138 @@
+package marketprice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newClockedLimiter makes a limiter whose clock reads *now.
+func newClockedLimiter(config LimiterConfig, now *time.Time) *ProviderLimiter {
+	limiter := NewProviderLimiter(RapidAPIProvider, config)
+	limiter.clock = func() time.Time { return *now }
+
+	return limiter
+}
+
+func TestReserveSlidingWindows(t *testing.T) {
+	limiter := NewProviderLimiter(RapidAPIProvider, LimiterConfig{RequestsPerSecond: 2, RequestsPerMinute: 3})
+	base := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		wait  time.Duration
+	}{
+		{"first", 0, 0},
+		{"second in the second", 100 * time.Millisecond, 0},
+		// Waits for the first request to leave the second
+		{"third in the second", 200 * time.Millisecond, 800 * time.Millisecond},
+		{"next second", time.Second, 0},
+		// Three requests in the minute, waits for the first one to leave it
+		{"fourth in the minute", 2 * time.Second, 58 * time.Second},
+		{"next minute", time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		wait, err := limiter.reserve(base.Add(tt.after))
+		if err != nil || wait != tt.wait {
+			t.Errorf("%s: wait %s with error %v, want %s", tt.name, wait, err, tt.wait)
+		}
+	}
+}
+
+func TestReserveDailyQuotaResetsAtUTCMidnight(t *testing.T) {
+	now := time.Date(2024, 5, 10, 23, 59, 58, 0, time.UTC)
+	limiter := newClockedLimiter(LimiterConfig{DailyQuota: 2}, &now)
+
+	for i := 0; i < 2; i++ {
+		if wait, err := limiter.reserve(now); wait != 0 || err != nil {
+			t.Fatalf("request %d: wait %s with error %v", i+1, wait, err)
+		}
+	}
+
+	var quotaErr *QuotaExceededError
+	if _, err := limiter.reserve(now.Add(time.Second)); !errors.As(err, &quotaErr) {
+		t.Fatalf("error %v, want the quota exceeded", err)
+	}
+
+	if usage := limiter.Usage(); usage.DailyUsed != 2 || usage.DailyRemaining != 0 ||
+		!usage.DailyQuotaResets.Equal(time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("usage %+v, want the quota used up until midnight", usage)
+	}
+
+	now = time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC)
+	if wait, err := limiter.reserve(now); wait != 0 || err != nil {
+		t.Fatalf("after midnight: wait %s with error %v, want the quota reset", wait, err)
+	}
+
+	if usage := limiter.Usage(); usage.DailyUsed != 1 || usage.DailyRemaining != 1 || usage.RequestsLastMin != 3 {
+		t.Errorf("usage %+v, want 1 request today and 3 in the last minute", usage)
+	}
+}
+
+func TestAcquireLimitsRequestsInFlight(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	limiter := newClockedLimiter(LimiterConfig{MaxInFlight: 1}, &now)
+
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := limiter.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v while the other request is in flight, want the deadline exceeded", err)
+	}
+
+	if usage := limiter.Usage(); usage.InFlight != 1 {
+		t.Errorf("%d in flight, want 1", usage.InFlight)
+	}
+
+	release()
+
+	release, err = limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+}
+
+func TestAcquireGivesBackInFlightSlotOnFailure(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	limiter := newClockedLimiter(LimiterConfig{RequestsPerSecond: 1, MaxInFlight: 2, DailyQuota: 2}, &now)
+
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	release()
+
+	// The clock stands still, the second request waits out the second until the context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := limiter.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want the deadline exceeded while waiting", err)
+	}
+
+	now = now.Add(time.Second)
+	release, err = limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire the next second: %v", err)
+	}
+	release()
+
+	now = now.Add(time.Second)
+	var quotaErr *QuotaExceededError
+	if _, err := limiter.Acquire(context.Background()); !errors.As(err, &quotaErr) {
+		t.Fatalf("error %v, want the quota exceeded", err)
+	}
+
+	if usage := limiter.Usage(); usage.InFlight != 0 {
+		t.Errorf("%d in flight after failed acquires, want 0", usage.InFlight)
+	}
+}
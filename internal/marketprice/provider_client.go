@@ -0,0 +1,189 @@
+package marketprice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxRetries  = 3
+	defaultBaseBackoff = 500 * time.Millisecond
+	defaultMaxBackoff  = 8 * time.Second
+	maxErrorBodyLength = 512
+)
+
+var ErrNoData = errors.New("provider returned no data")
+
+// ProviderError is returned when a provider responds with a non-2xx status code.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s responded with status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the request may succeed if attempted again.
+func (e *ProviderError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// providerClient is the HTTP layer every provider call goes through. It applies the provider's
+// limiter, checks status codes and retries rate limited or failed requests with jittered backoff.
+type providerClient struct {
+	provider    string
+	httpClient  *http.Client
+	limiter     *ProviderLimiter
+	maxRetries  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+}
+
+func newProviderClient(provider string, httpClient *http.Client, limiter *ProviderLimiter) *providerClient {
+	return &providerClient{
+		provider:    provider,
+		httpClient:  httpClient,
+		limiter:     limiter,
+		maxRetries:  defaultMaxRetries,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
+	}
+}
+
+// getJSON issues a GET request to endpoint and decodes a successful response body into out.
+func (pc *providerClient) getJSON(ctx context.Context, endpoint string, headers map[string]string, out any) error {
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		var body []byte
+
+		body, err = pc.get(ctx, endpoint, headers)
+		if err == nil {
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("decode %s response: %w", pc.provider, err)
+			}
+			return nil
+		}
+
+		if attempt >= pc.maxRetries || !isRetryable(ctx, err) {
+			return err
+		}
+
+		if waitErr := sleepCtx(ctx, pc.backoff(attempt, err)); waitErr != nil {
+			return err
+		}
+	}
+}
+
+func (pc *providerClient) get(ctx context.Context, endpoint string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, val := range headers {
+		req.Header.Add(key, val)
+	}
+
+	if pc.limiter != nil {
+		release, err := pc.limiter.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	res, err := pc.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", pc.provider, err)
+	}
+
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s response: %w", pc.provider, err)
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		if len(body) > maxErrorBodyLength {
+			body = body[:maxErrorBodyLength]
+		}
+
+		return nil, &ProviderError{
+			Provider:   pc.provider,
+			StatusCode: res.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+		}
+	}
+
+	return body, nil
+}
+
+func (pc *providerClient) backoff(attempt int, err error) time.Duration {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.RetryAfter > 0 {
+		return min(providerErr.RetryAfter, pc.maxBackoff)
+	}
+
+	ceiling := min(pc.baseBackoff<<attempt, pc.maxBackoff)
+
+	// Full jitter so concurrent refreshes don't retry in lockstep
+	//nolint:gosec // jitter doesn't need a cryptographically secure source
+	return time.Duration(rand.Int63n(int64(ceiling))) + 1
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Retryable()
+	}
+
+	// Transport level failures such as timeouts and connection resets. Limiter errors like an
+	// exhausted daily quota won't clear up by retrying.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+func parseRetryAfter(val string) time.Duration {
+	if val == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(val); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+
+	if at, err := http.ParseTime(val); err == nil {
+		return time.Until(at)
+	}
+
+	return 0
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
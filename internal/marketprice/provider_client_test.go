@@ -0,0 +1,317 @@
+package marketprice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+	"github.com/signalb/internal/ticker"
+	"github.com/signalb/internal/timeframe"
+)
+
+type pricePayload struct {
+	Price float64 `json:"price"`
+}
+
+// stubProvider answers the nth call with the nth handler, the last one answers every call after that.
+func stubProvider(t *testing.T, handlers ...http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		handlers[min(call, len(handlers))-1](w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func respond(status int, body string, headers ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		for i := 0; i+1 < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func newTestProviderClient(server *httptest.Server) *providerClient {
+	client := newProviderClient("stub", server.Client(), nil)
+	client.baseBackoff = time.Millisecond
+	client.maxBackoff = 10 * time.Millisecond
+
+	return client
+}
+
+func TestProviderClientDecodesSuccess(t *testing.T) {
+	server, calls := stubProvider(t, respond(http.StatusOK, `{"price": 42.5}`))
+
+	var out pricePayload
+	if err := newTestProviderClient(server).getJSON(context.Background(), server.URL, nil, &out); err != nil {
+		t.Fatalf("getJSON: %v", err)
+	}
+
+	if out.Price != 42.5 || calls.Load() != 1 {
+		t.Errorf("price = %v after %d calls, want 42.5 after 1", out.Price, calls.Load())
+	}
+}
+
+func TestProviderClientSendsHeaders(t *testing.T) {
+	server, _ := stubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"price": 1}`))
+	})
+
+	var out pricePayload
+	err := newTestProviderClient(server).getJSON(context.Background(), server.URL,
+		map[string]string{"X-API-Key": "secret"}, &out)
+	if err != nil {
+		t.Fatalf("getJSON: %v", err)
+	}
+}
+
+func TestProviderClientReturnsProviderErrorWithoutRetrying4xx(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		server, calls := stubProvider(t, respond(status, "symbol unknown"))
+
+		err := newTestProviderClient(server).getJSON(context.Background(), server.URL, nil, &pricePayload{})
+
+		var providerErr *ProviderError
+		if !errors.As(err, &providerErr) {
+			t.Fatalf("status %d: error %v isn't a ProviderError", status, err)
+		}
+
+		if providerErr.Provider != "stub" || providerErr.StatusCode != status || providerErr.Body != "symbol unknown" {
+			t.Errorf("status %d: got %+v", status, providerErr)
+		}
+
+		if providerErr.Retryable() || calls.Load() != 1 {
+			t.Errorf("status %d: retryable %t after %d calls, want a single call", status, providerErr.Retryable(), calls.Load())
+		}
+	}
+}
+
+func TestProviderClientRetriesRateLimitsAndServerErrors(t *testing.T) {
+	server, calls := stubProvider(t,
+		respond(http.StatusTooManyRequests, "slow down"),
+		respond(http.StatusBadGateway, "upstream down"),
+		respond(http.StatusServiceUnavailable, "maintenance"),
+		respond(http.StatusOK, `{"price": 7}`),
+	)
+
+	var out pricePayload
+	if err := newTestProviderClient(server).getJSON(context.Background(), server.URL, nil, &out); err != nil {
+		t.Fatalf("getJSON: %v", err)
+	}
+
+	if out.Price != 7 || calls.Load() != 4 {
+		t.Errorf("price = %v after %d calls, want 7 after 4", out.Price, calls.Load())
+	}
+}
+
+func TestProviderClientHonoursRetryAfter(t *testing.T) {
+	var firstCall time.Time
+	server, _ := stubProvider(t,
+		func(w http.ResponseWriter, r *http.Request) {
+			firstCall = time.Now()
+			respond(http.StatusTooManyRequests, "", "Retry-After", "1")(w, r)
+		},
+		respond(http.StatusOK, `{"price": 1}`),
+	)
+
+	client := newTestProviderClient(server)
+	client.maxBackoff = 5 * time.Second
+
+	if err := client.getJSON(context.Background(), server.URL, nil, &pricePayload{}); err != nil {
+		t.Fatalf("getJSON: %v", err)
+	}
+
+	if waited := time.Since(firstCall); waited < time.Second {
+		t.Errorf("retried after %s, want at least the 1s of Retry-After", waited)
+	}
+}
+
+func TestProviderClientBackoff(t *testing.T) {
+	client := &providerClient{baseBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+
+	retryAfter := &ProviderError{StatusCode: http.StatusTooManyRequests, RetryAfter: 300 * time.Millisecond}
+	if got := client.backoff(0, retryAfter); got != 300*time.Millisecond {
+		t.Errorf("backoff with Retry-After 300ms = %s", got)
+	}
+
+	longRetryAfter := &ProviderError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute}
+	if got := client.backoff(0, longRetryAfter); got != time.Second {
+		t.Errorf("backoff with Retry-After 1m = %s, want it capped at 1s", got)
+	}
+
+	for attempt := 0; attempt < 6; attempt++ {
+		ceiling := min(client.baseBackoff<<attempt, client.maxBackoff)
+		if got := client.backoff(attempt, errors.New("connection reset")); got <= 0 || got > ceiling {
+			t.Errorf("backoff of attempt %d = %s, want in (0, %s]", attempt, got, ceiling)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("2"); got != 2*time.Second {
+		t.Errorf("parseRetryAfter(2) = %s", got)
+	}
+
+	at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(at); got <= 58*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%s) = %s, want about a minute", at, got)
+	}
+
+	for _, val := range []string{"", "soon", "-"} {
+		if got := parseRetryAfter(val); got != 0 {
+			t.Errorf("parseRetryAfter(%q) = %s, want 0", val, got)
+		}
+	}
+}
+
+func TestProviderClientExhaustsRetryBudget(t *testing.T) {
+	server, calls := stubProvider(t, respond(http.StatusInternalServerError, "boom"))
+
+	client := newTestProviderClient(server)
+	err := client.getJSON(context.Background(), server.URL, nil, &pricePayload{})
+
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("error = %v, want the last 500", err)
+	}
+
+	if want := int32(client.maxRetries + 1); calls.Load() != want {
+		t.Errorf("calls = %d, want %d", calls.Load(), want)
+	}
+}
+
+func TestProviderClientStopsRetryingWhenCanceled(t *testing.T) {
+	server, calls := stubProvider(t, respond(http.StatusServiceUnavailable, "maintenance", "Retry-After", "60"))
+
+	client := newTestProviderClient(server)
+	client.maxBackoff = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.getJSON(ctx, server.URL, nil, &pricePayload{})
+
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || calls.Load() != 1 {
+		t.Errorf("error = %v after %d calls, want the 503 of the single call", err, calls.Load())
+	}
+}
+
+func TestProviderClientErrorBodies(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", "", ""},
+		{"short", "x", "x"},
+		{"at limit", strings.Repeat("a", maxErrorBodyLength), strings.Repeat("a", maxErrorBodyLength)},
+		{"over limit", strings.Repeat("b", 3*maxErrorBodyLength), strings.Repeat("b", maxErrorBodyLength)},
+	}
+
+	for _, tt := range tests {
+		server, _ := stubProvider(t, respond(http.StatusForbidden, tt.body))
+
+		err := newTestProviderClient(server).getJSON(context.Background(), server.URL, nil, &pricePayload{})
+
+		var providerErr *ProviderError
+		if !errors.As(err, &providerErr) {
+			t.Fatalf("%s: error %v isn't a ProviderError", tt.name, err)
+		}
+
+		if providerErr.Body != tt.want {
+			t.Errorf("%s: body of %d bytes, want %d", tt.name, len(providerErr.Body), len(tt.want))
+		}
+	}
+}
+
+func TestProviderClientMalformedSuccessBodies(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"empty", respond(http.StatusOK, "")},
+		{"cut off JSON", respond(http.StatusOK, `{"price": 4`)},
+		{"not JSON", respond(http.StatusOK, "<html>gateway</html>")},
+		{"shorter than its length", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write([]byte(`{"price":`))
+		}},
+	}
+
+	for _, tt := range tests {
+		server, calls := stubProvider(t, tt.handler)
+
+		err := newTestProviderClient(server).getJSON(context.Background(), server.URL, nil, &pricePayload{})
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+
+		var providerErr *ProviderError
+		if errors.As(err, &providerErr) || calls.Load() != 1 {
+			t.Errorf("%s: error %v after %d calls, want a read or decode error of a single call", tt.name, err, calls.Load())
+		}
+	}
+}
+
+func TestCryptoHour4FetchingShortResponses(t *testing.T) {
+	dbtest.Use(t).SetTickers(database.Ticker{Symbol: "BTC", Class: ticker.CryptoClass,
+		ProviderSymbols: map[string]string{ticker.TokenInsightSlug: "bitcoin"}})
+
+	tests := []struct {
+		name    string
+		body    string
+		want    []float64
+		wantErr error
+	}{
+		{"no points", `{"data": {"market_chart": []}}`, nil, ErrNoData},
+		{"no data", `{}`, nil, ErrNoData},
+		{"fewer points than a candle", `{"data": {"market_chart": [
+			{"timestamp": 1714996800000, "price": 10}, {"timestamp": 1715000400000, "price": 11}]}}`,
+			[]float64{11}, nil},
+		{"fewer candles than asked for", `{"data": {"market_chart": [
+			{"timestamp": 1714996800000, "price": 10}, {"timestamp": 1715000400000, "price": 11},
+			{"timestamp": 1715004000000, "price": 12}, {"timestamp": 1715007600000, "price": 13},
+			{"timestamp": 1715011200000, "price": 14}]}}`,
+			[]float64{14, 10}, nil},
+	}
+
+	for _, tt := range tests {
+		server, _ := stubProvider(t, respond(http.StatusOK, tt.body))
+
+		fetcher := NewCryptoDataFetcher(&TokenInsightCredentials{baseURL: server.URL, key: "key"}, nil,
+			newTestProviderClient(server), nil)
+
+		data, err := fetcher.Fetch(context.Background(), timeframe.Hour4, "BTC", 3)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+
+		prices := make([]float64, 0, len(data))
+		for _, d := range data {
+			prices = append(prices, d.Price)
+		}
+
+		if !slices.Equal(prices, tt.want) {
+			t.Errorf("%s: prices = %v, want %v", tt.name, prices, tt.want)
+		}
+	}
+}
@@ -2,12 +2,19 @@ package marketprice
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"sort"
 	"sync"
 	"time"
 
+	errorsStdLib "errors"
+
+	"github.com/signalb/internal/calendar"
 	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/errors"
+	"github.com/signalb/internal/retention"
 )
 
 const (
@@ -18,7 +25,7 @@ const (
 func refreshPriceByTickerTimeframe(c context.Context, ticker, timeframe string) (*RefreshPriceResp, error) {
 	class, err := getTickerClass(c, ticker)
 	if err != nil {
-		return nil, err
+		return nil, errors.WithCategory(errors.DatabaseCategory, err)
 	}
 
 	return refreshPriceByTickerClassTimeframe(c, ticker, class, timeframe)
@@ -35,27 +42,105 @@ func refreshPriceByTickerClassTimeframe(
 	fetcher, ok := fetcherManager.getFetcherByTickerClass(class)
 
 	if !ok {
-		return nil, errors.New("can't get data fetcher")
+		return nil, errorsStdLib.New("can't get data fetcher")
 	}
 
-	res, err := fetcher.Fetch(ctx, timeframe, ticker, length)
+	fetched, err := fetcher.Fetch(ctx, timeframe, ticker, length)
 	if err != nil {
-		return nil, err
+		return nil, categorizeFetchError(err)
 	}
 
-	err = refreshData(ctx, ticker, timeframe, res)
+	res, rejections, err := validateCandles(ctx, ticker, timeframe, fetched)
 	if err != nil {
-		return nil, err
+		return nil, errors.WithCategory(errors.DatabaseCategory, err)
+	}
+
+	// Before the fetched candles replace the stored ones the back-adjustment compares against
+	adjustment, err := applyCorporateActions(ctx, ticker, timeframe, res)
+	if err != nil {
+		return nil, errors.WithCategory(errors.DatabaseCategory, err)
+	}
+
+	trimmed, err := refreshData(ctx, ticker, class, timeframe, res)
+	if err != nil {
+		return nil, errors.WithCategory(errors.DatabaseCategory, err)
 	}
 
 	return &RefreshPriceResp{
-		Ticker:          ticker,
-		Class:           class,
-		Timeframe:       timeframe,
-		RefreshedPrices: res,
+		Ticker:              ticker,
+		Class:               class,
+		Timeframe:           timeframe,
+		RefreshedPrices:     res,
+		MissingCandles:      findMissingCandles(ticker, class, timeframe, res),
+		TrimmedCandles:      trimmed,
+		Rejections:          rejections,
+		SplitEvents:         adjustment.splits,
+		BackAdjustedCandles: adjustment.backAdjusted,
 	}, nil
 }
 
+// categorizeFetchError tells running out of quota or being throttled apart from other provider failures.
+func categorizeFetchError(err error) error {
+	var (
+		quotaErr    *QuotaExceededError
+		providerErr *ProviderError
+	)
+
+	if errorsStdLib.As(err, &quotaErr) ||
+		(errorsStdLib.As(err, &providerErr) && providerErr.StatusCode == http.StatusTooManyRequests) {
+		return errors.WithCategory(errors.RateLimitedCategory, err)
+	}
+
+	return errors.WithCategory(errors.ProviderCategory, err)
+}
+
+// findMissingCandles reports sessions the market traded in but the provider returned no candle for.
+func findMissingCandles(ticker, class, timeframe string, data []*TickerData) []time.Time {
+	cal, ok := calendar.CalendarManager.GetCalendarByClass(class)
+	if !ok {
+		return nil
+	}
+
+	times := make([]time.Time, 0, len(data))
+	for _, d := range data {
+		times = append(times, d.Time)
+	}
+
+	gaps := calendar.FindGaps(cal, timeframe, times)
+	if len(gaps) > 0 {
+		log.Printf("Missing %d candles for %s %s, first at %s", len(gaps), ticker, timeframe, gaps[0])
+	}
+
+	return gaps
+}
+
+// getRefreshSkipReason lets scheduled refreshes skip tickers whose market hasn't closed a new candle
+// since the latest stored one, e.g. stocks over weekends and holidays.
+func getRefreshSkipReason(c context.Context, ticker *database.Ticker, timeframe string) string {
+	cal, ok := calendar.CalendarManager.GetCalendarByClass(ticker.Class)
+	if !ok {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	latest, err := database.Client.GetLatestPriceTime(ctx, ticker.Symbol, timeframe)
+	if err != nil || latest.IsZero() {
+		return ""
+	}
+
+	// Stored times are wall clock times in the market's location
+	latest = time.Date(latest.Year(), latest.Month(), latest.Day(),
+		latest.Hour(), latest.Minute(), latest.Second(), 0, cal.Location())
+
+	if calendar.HasNewFinalCandle(cal, timeframe, latest, time.Now()) {
+		return ""
+	}
+
+	return fmt.Sprintf("no %s candle has closed on the %s calendar since %s", timeframe, cal.Name(), latest)
+}
+
 func getTickerClass(c context.Context, ticker string) (string, error) {
 	ctx, cancel := context.WithTimeout(c, 2*time.Second)
 	defer cancel()
@@ -63,29 +148,91 @@ func getTickerClass(c context.Context, ticker string) (string, error) {
 	return database.Client.GetTickerClassBySymbol(ctx, ticker)
 }
 
-func refreshData(c context.Context, tickerSymbol, timeframe string, data []*TickerData) error {
-	// Based on how much data we adding, we will remove x amount of data
+// refreshData upserts the fetched candles, dropping the stored ones in the fetched window keyed by an older
+// candle convention, then trims the stored history according to its retention policy. Stored candles whose
+// fetched copy validation held back are kept.
+func refreshData(c context.Context, tickerSymbol, class, timeframe string, data []*TickerData) (int64, error) {
 	ctx, cancel := context.WithTimeout(c, 20*time.Second)
 	defer cancel()
 
-	err := database.Client.DeletePriceData(ctx, tickerSymbol, timeframe, len(data))
-	if err != nil {
-		return err
-	}
-
 	var priceData []database.PriceData
 	for _, d := range data {
 		priceData = append(priceData, database.PriceData{
 			TickerSymbol: tickerSymbol,
 			Time:         d.Time,
 			Price:        d.Price,
+			AdjPrice:     d.AdjustedPrice,
+			Suspect:      d.Suspect,
 		})
 	}
 
-	return database.Client.InsertPriceData(ctx, timeframe, priceData)
+	stale, err := getMisalignedStoredTimes(ctx, tickerSymbol, class, timeframe, priceData)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := database.Client.ReplacePriceData(ctx, tickerSymbol, timeframe, priceData, stale); err != nil {
+		return 0, err
+	}
+
+	return retention.Apply(ctx, tickerSymbol, class, timeframe)
+}
+
+// getMisalignedStoredTimes returns the stored times between the oldest and newest of data that don't start
+// a candle on the calendar of the class. Crypto candles are keyed by the provider's own times, none are.
+func getMisalignedStoredTimes(
+	ctx context.Context,
+	tickerSymbol, class, timeframe string,
+	data []database.PriceData,
+) ([]time.Time, error) {
+	cal, ok := calendar.CalendarManager.GetCalendarByClass(class)
+	if !ok || len(data) == 0 {
+		return nil, nil
+	}
+
+	oldest, newest := data[0].Time, data[0].Time
+	for _, d := range data[1:] {
+		if d.Time.Before(oldest) {
+			oldest = d.Time
+		}
+		if d.Time.After(newest) {
+			newest = d.Time
+		}
+	}
+
+	stored, err := database.Client.QueryPriceData(ctx, tickerSymbol, timeframe, &database.PriceFilter{
+		From: &oldest,
+		To:   &newest,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var misaligned []time.Time
+	for _, d := range stored {
+		if !storedCandleStart(cal, timeframe, d.Time).Equal(d.Time) {
+			misaligned = append(misaligned, d.Time)
+		}
+	}
+
+	return misaligned, nil
 }
 
-func refreshPriceByTimeframe(c context.Context, timeframe string) ([]*RefreshPriceResp, error) {
+// storedCandleStart is the start of the candle a stored time is in. Stored times are wall clock times in
+// the market's location, kept in UTC.
+func storedCandleStart(cal calendar.Calendar, timeframe string, stored time.Time) time.Time {
+	t := time.Date(stored.Year(), stored.Month(), stored.Day(),
+		stored.Hour(), stored.Minute(), stored.Second(), 0, cal.Location())
+
+	start := calendar.CandleStart(cal, timeframe, t)
+
+	return time.Date(start.Year(), start.Month(), start.Day(),
+		start.Hour(), start.Minute(), start.Second(), 0, time.UTC)
+}
+
+// refreshPriceByTimeframe refreshes every ticker of the timeframe. A ticker failing doesn't stop the others,
+// it's reported in the failures next to their results. The error is only for not getting the tickers at all.
+func refreshPriceByTimeframe(c context.Context, timeframe string) (*RefreshBatchResp, error) {
 	// get all ticker along with class
 	tickers, err := getTickersByTimeframe(c, timeframe)
 	if err != nil {
@@ -93,9 +240,9 @@ func refreshPriceByTimeframe(c context.Context, timeframe string) ([]*RefreshPri
 	}
 
 	var (
-		results   []*RefreshPriceResp
+		batch     = &RefreshBatchResp{Timeframe: timeframe, Results: []*RefreshPriceResp{}, Failures: []*errors.TickerFailure{}}
 		chRes     = make(chan *RefreshPriceResp, len(tickers))
-		chErr     = make(chan error, len(tickers))
+		chErr     = make(chan *errors.TickerFailure, len(tickers))
 		wgRefresh sync.WaitGroup
 		wgCollect sync.WaitGroup
 	)
@@ -105,31 +252,42 @@ func refreshPriceByTimeframe(c context.Context, timeframe string) ([]*RefreshPri
 	go func() {
 		defer wgCollect.Done()
 		for res := range chRes {
-			results = append(results, res)
+			batch.Results = append(batch.Results, res)
 		}
 	}()
 
-	// collect errors
+	// collect failures
 	go func() {
 		defer wgCollect.Done()
-		for newErr := range chErr {
-			err = newErr
+		for failure := range chErr {
+			batch.Failures = append(batch.Failures, failure)
 		}
 	}()
 
 	for _, ticker := range tickers {
 		wgRefresh.Add(1)
-		go func(ticker *database.Ticker, timeframe string, chRes chan<- *RefreshPriceResp, chErr chan<- error) {
+		go func(ticker *database.Ticker, timeframe string, chRes chan<- *RefreshPriceResp, chErr chan<- *errors.TickerFailure) {
 			defer wgRefresh.Done()
 
 			ctx, cancel := context.WithTimeout(c, 10*time.Second)
 			defer cancel()
 
+			if reason := getRefreshSkipReason(ctx, ticker, timeframe); reason != "" {
+				chRes <- &RefreshPriceResp{
+					Ticker:     ticker.Symbol,
+					Class:      ticker.Class,
+					Timeframe:  timeframe,
+					SkipReason: reason,
+				}
+				log.Printf("Skipped refreshing price for %s %s: %s", ticker.Symbol, timeframe, reason)
+				return
+			}
+
 			result, err := refreshPriceByTickerClassTimeframe(ctx, ticker.Symbol, ticker.Class, timeframe)
 
 			if err != nil {
-				chErr <- err
-				log.Printf("Error refreshing price for %s %s %s", ticker.Symbol, timeframe, err)
+				chErr <- errors.NewTickerFailure(ticker.Symbol, err)
+				log.Printf("Error refreshing price for %s %s (%s): %s", ticker.Symbol, timeframe, errors.Category(err), err)
 			} else {
 				chRes <- result
 				log.Printf("Finished refreshing price for %s %s", ticker.Symbol, timeframe)
@@ -142,11 +300,10 @@ func refreshPriceByTimeframe(c context.Context, timeframe string) ([]*RefreshPri
 	close(chErr)
 	wgCollect.Wait()
 
-	if err != nil {
-		return nil, err
-	}
+	sort.Slice(batch.Results, func(i, j int) bool { return batch.Results[i].Ticker < batch.Results[j].Ticker })
+	sort.Slice(batch.Failures, func(i, j int) bool { return batch.Failures[i].TickerSymbol < batch.Failures[j].TickerSymbol })
 
-	return results, err
+	return batch, nil
 }
 
 func getTickersByTimeframe(c context.Context, timeframe string) ([]*database.Ticker, error) {
@@ -0,0 +1,95 @@
+package marketprice
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+	"github.com/signalb/internal/ticker"
+	"github.com/signalb/internal/timeframe"
+)
+
+// stubFetcher returns the same candles on every fetch.
+type stubFetcher struct {
+	class string
+	data  []*TickerData
+}
+
+func (f *stubFetcher) Fetch(context.Context, string, string, int) ([]*TickerData, error) {
+	data := make([]*TickerData, 0, len(f.data))
+	for _, d := range f.data {
+		copied := *d
+		data = append(data, &copied)
+	}
+	return data, nil
+}
+
+func (f *stubFetcher) FetchClass() string {
+	return f.class
+}
+
+func useFetcher(t *testing.T, fetcher TickerDataFetcher) {
+	t.Helper()
+
+	prevFetchers, prevValidator := fetcherManager, candleValidator
+	fetcherManager = NewFetcherManager(fetcher)
+	candleValidator = NewCandleValidator(DefaultValidationConfig())
+	t.Cleanup(func() { fetcherManager, candleValidator = prevFetchers, prevValidator })
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRefreshKeepsStoredCandlesValidationHeldBack(t *testing.T) {
+	useUSEquityCalendar(t)
+	db := dbtest.Use(t)
+
+	db.SetPrices("AAPL", timeframe.Day1, []database.PriceData{
+		{TickerSymbol: "AAPL", Time: day(6), Price: 100},
+		{TickerSymbol: "AAPL", Time: day(7), Price: 101},
+		// Keyed at the close by an older convention, the fetched 2024-05-07 candle replaces it
+		{TickerSymbol: "AAPL", Time: day(7).Add(16 * time.Hour), Price: 101},
+		{TickerSymbol: "AAPL", Time: day(8), Price: 102},
+		{TickerSymbol: "AAPL", Time: day(9), Price: 103},
+	})
+
+	useFetcher(t, &stubFetcher{class: ticker.StockClass, data: []*TickerData{
+		NewTickerData(day(6), 110),
+		NewTickerData(day(7), 111),
+		// Rejected by the positive price rule
+		NewTickerData(day(8), -1),
+		NewTickerData(day(9), 113),
+		NewTickerData(day(10), 114),
+	}})
+
+	res, err := refreshPriceByTickerClassTimeframe(context.Background(), "AAPL", ticker.StockClass, timeframe.Day1)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	if len(res.Rejections) != 1 || res.Rejections[0].Rule != PositivePriceRule {
+		t.Fatalf("rejections = %+v, want the 2024-05-08 candle's positive price", res.Rejections)
+	}
+
+	stored := db.Prices("AAPL", timeframe.Day1)
+
+	times := make([]time.Time, 0, len(stored))
+	prices := make([]float64, 0, len(stored))
+	for _, d := range stored {
+		times = append(times, d.Time)
+		prices = append(prices, d.Price)
+	}
+
+	if want := []time.Time{day(6), day(7), day(8), day(9), day(10)}; !slices.EqualFunc(times, want, time.Time.Equal) {
+		t.Errorf("stored times = %v, want %v", times, want)
+	}
+
+	// The stored 2024-05-08 candle stays as it was
+	if want := []float64{110, 111, 102, 113, 114}; !slices.Equal(prices, want) {
+		t.Errorf("stored prices = %v, want %v", prices, want)
+	}
+}
@@ -2,14 +2,15 @@ package marketprice
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	neturl "net/url"
+	"sort"
 	"time"
 
+	"github.com/signalb/internal/calendar"
 	"github.com/signalb/internal/ticker"
-	"github.com/signalb/internal/timeframe"
+	timeframePkg "github.com/signalb/internal/timeframe"
 )
 
 const (
@@ -22,31 +23,32 @@ type RapidAPICredentials struct {
 	host    string
 }
 
-type StockDataFetcher struct {
+// rapidAPISource fetches candles of any class RapidAPI quotes, resampled on the class's market calendar.
+type rapidAPISource struct {
 	credentials      *RapidAPICredentials
+	client           *providerClient
+	calendar         calendar.Calendar
 	timeframeMapping map[string]string
 }
 
-func NewStockDataFetcher(credentials *RapidAPICredentials) *StockDataFetcher {
+func newRapidAPISource(credentials *RapidAPICredentials, client *providerClient, cal calendar.Calendar) *rapidAPISource {
 	timeframeMapping := map[string]string{
-		timeframe.Day1:  "daily",
-		timeframe.Week1: "weekly",
-		timeframe.Hour4: "intraday",
+		timeframePkg.Day1:  "daily",
+		timeframePkg.Week1: "weekly",
+		timeframePkg.Hour4: "intraday",
 	}
 
-	return &StockDataFetcher{
+	return &rapidAPISource{
 		credentials:      credentials,
+		client:           client,
+		calendar:         cal,
 		timeframeMapping: timeframeMapping,
 	}
 }
 
-func (stockDF *StockDataFetcher) FetchClass() string {
-	return ticker.StockClass
-}
-
-func (stockDF *StockDataFetcher) Fetch(
+func (src *rapidAPISource) fetchCandles(
 	ctx context.Context,
-	timeframe, tickerSymbol string,
+	timeframe, symbol string,
 	length int,
 ) ([]*TickerData, error) {
 	if length > RefreshAllDataLength {
@@ -54,162 +56,227 @@ func (stockDF *StockDataFetcher) Fetch(
 	}
 
 	var (
-		results       []*TickerData
+		bars          []*TickerData
 		err           error
-		fetchStrategy func(ctx context.Context, credentials *RapidAPICredentials, timeframeVal, tickerSymbol string, length int) ([]*TickerData, error)
+		fetchStrategy func(ctx context.Context, src *rapidAPISource, timeframeVal, symbol string, length int) ([]*TickerData, error)
 	)
 
-	timeframeVal := stockDF.timeframeMapping[timeframe]
+	timeframeVal, ok := src.timeframeMapping[timeframe]
+	if !ok {
+		return nil, errors.New("error getting timeframe mapping")
+	}
+
 	if timeframeVal != "intraday" {
 		fetchStrategy = handleNonIntradayDataFetching
 	} else {
 		fetchStrategy = handleIntradayDataFetching
 	}
 
-	results, err = fetchStrategy(ctx, stockDF.credentials, timeframeVal, tickerSymbol, length)
+	bars, err = fetchStrategy(ctx, src, timeframeVal, symbol, length)
 	if err != nil {
 		return nil, err
 	}
 
-	return results, nil
+	return resampleToFinalCandles(src.calendar, timeframe, bars, time.Now(), length), nil
 }
 
-func handleIntradayDataFetching(
-	ctx context.Context,
+type StockDataFetcher struct {
+	source *rapidAPISource
+}
+
+func NewStockDataFetcher(
 	credentials *RapidAPICredentials,
-	timeframeVal, tickerSymbol string,
+	client *providerClient,
+	cal calendar.Calendar,
+) *StockDataFetcher {
+	return &StockDataFetcher{
+		source: newRapidAPISource(credentials, client, cal),
+	}
+}
+
+func (stockDF *StockDataFetcher) FetchClass() string {
+	return ticker.StockClass
+}
+
+func (stockDF *StockDataFetcher) Fetch(
+	ctx context.Context,
+	timeframe, tickerSymbol string,
 	length int,
 ) ([]*TickerData, error) {
-	location, err := time.LoadLocation("America/New_York")
+	// Tickers are RapidAPI symbols unless registered otherwise, e.g. BRK-B for BRK.B
+	symbol, err := getRapidAPISymbol(ctx, tickerSymbol, tickerSymbol)
 	if err != nil {
 		return nil, err
 	}
 
+	return stockDF.source.fetchCandles(ctx, timeframe, symbol, length)
+}
+
+func handleIntradayDataFetching(
+	ctx context.Context,
+	src *rapidAPISource,
+	timeframeVal, symbol string,
+	length int,
+) ([]*TickerData, error) {
 	if length > rapidAPIIntradayMaximumLength {
 		length = rapidAPIIntradayMaximumLength
 	}
 
+	// Hourly bars, at most 4 make up an H4 candle
 	adjustedLength := 4 * length
-	url := fmt.Sprintf("%s/%s?symbol=%s&interval=60min&maxreturn=%d", credentials.baseURL, timeframeVal, tickerSymbol, adjustedLength)
+	url := fmt.Sprintf("%s/%s?symbol=%s&interval=60min&maxreturn=%d", src.credentials.baseURL, timeframeVal, neturl.QueryEscape(symbol), adjustedLength)
 
-	resp, err := makeRapidAPIHistoricalDataCall(ctx, url, credentials.key, credentials.host)
+	resp, err := makeRapidAPIHistoricalDataCall(ctx, src.client, url, src.credentials.key, src.credentials.host)
 	if err != nil {
 		return nil, err
 	}
 
-	var results []*TickerData
-	for i := len(resp.Results) - 1; len(results) < length; i -= 4 {
-		currRes := resp.Results[i]
-		parsedTime, err := getDateStrToTime("2006-01-02 15:00", location, currRes.Date)
-		if err != nil {
-			return nil, err
-		}
-
-		results = append(results, NewTickerData(parsedTime, currRes.Close))
-	}
-
-	return results, nil
+	return parseRapidAPIResults(resp.Results, "2006-01-02 15:00", getRapidAPILocation(resp.Metadata.Timezone, src.calendar.Location()))
 }
 
 func handleNonIntradayDataFetching(
 	ctx context.Context,
-	credentials *RapidAPICredentials,
-	timeframeVal, tickerSymbol string,
+	src *rapidAPISource,
+	timeframeVal, symbol string,
 	length int,
 ) ([]*TickerData, error) {
-	location, err := time.LoadLocation("America/New_York")
-	if err != nil {
-		return nil, err
-	}
-
-	today := time.Now().In(location)
-	lengthMultiplier := 2 // This is to ensure we always get enough data points
-	var yesterday time.Time
+	today := time.Now().In(src.calendar.Location())
 
+	var start time.Time
 	if timeframeVal == "daily" {
-		yesterday = today.AddDate(0, 0, -length*lengthMultiplier)
+		// One extra session in case today's candle is still forming
+		start = calendar.SessionsBack(src.calendar, today, length+1)
 	} else {
-		yesterday = today.AddDate(0, 0, -length*lengthMultiplier*7)
+		// An extra week for the forming candle and one for a start date falling mid-week
+		start = today.AddDate(0, 0, -(length+2)*7)
 	}
 
 	dateEnd := fmt.Sprintf("%v-%v-%v", today.Year(), int(today.Month()), today.Day())
-	dateStart := fmt.Sprintf("%v-%v-%v", yesterday.Year(), int(yesterday.Month()), yesterday.Day())
+	dateStart := fmt.Sprintf("%v-%v-%v", start.Year(), int(start.Month()), start.Day())
 
-	url := fmt.Sprintf("%s/%s?symbol=%s&dateStart=%s&dateEnd=%s", credentials.baseURL, timeframeVal, tickerSymbol, dateStart, dateEnd)
+	url := fmt.Sprintf("%s/%s?symbol=%s&dateStart=%s&dateEnd=%s", src.credentials.baseURL, timeframeVal, neturl.QueryEscape(symbol), dateStart, dateEnd)
 
-	resp, err := makeRapidAPIHistoricalDataCall(ctx, url, credentials.key, credentials.host)
+	resp, err := makeRapidAPIHistoricalDataCall(ctx, src.client, url, src.credentials.key, src.credentials.host)
 	if err != nil {
 		return nil, err
 	}
 
-	var results []*TickerData
-	idx, jumpInterval := getStartIndexAndJumpInterval(timeframeVal, resp.Results)
+	return parseRapidAPIResults(resp.Results, "2006-01-02", src.calendar.Location())
+}
 
-	for i := idx; len(results) < length; i -= jumpInterval {
-		currRes := resp.Results[i]
-		parsedTime, err := getDateStrToTime("2006-01-02", location, currRes.Date)
-		if err != nil {
-			return nil, err
-		}
+// getRapidAPILocation prefers the timezone the bars are quoted in, which for FX and futures can differ from
+// the calendar's, falling back to the calendar's location when it's missing or unknown.
+func getRapidAPILocation(timezone string, fallback *time.Location) *time.Location {
+	if timezone == "" {
+		return fallback
+	}
 
-		results = append(results, NewTickerData(parsedTime, currRes.Close))
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fallback
 	}
 
-	return results, nil
+	return loc
 }
 
-func makeRapidAPIHistoricalDataCall(ctx context.Context, url, key, host string) (*RapidAPIDataResp, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// getRapidAPISymbol returns the ticker's registered RapidAPI symbol, or fallback when there is none.
+// An empty fallback makes the registered symbol required.
+func getRapidAPISymbol(c context.Context, tickerSymbol, fallback string) (string, error) {
+	providerSymbols, err := getTickerProviderSymbols(c, tickerSymbol)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	req.Header.Add("X-RapidAPI-Key", key)
-	req.Header.Add("X-RapidAPI-Host", host)
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+	if symbol, ok := providerSymbols[ticker.RapidAPISymbol]; ok {
+		return symbol, nil
 	}
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+	if fallback == "" {
+		return "", fmt.Errorf("no %s symbol registered for %s", ticker.RapidAPISymbol, tickerSymbol)
 	}
 
-	var resp RapidAPIDataResp
+	return fallback, nil
+}
 
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, err
+func parseRapidAPIResults(results []Result, layout string, loc *time.Location) ([]*TickerData, error) {
+	bars := make([]*TickerData, 0, len(results))
+
+	for _, res := range results {
+		parsedTime, err := getDateStrToTime(layout, loc, res.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		// Left at 0 when the provider doesn't adjust the bar, recorded splits fill it in on refresh
+		bars = append(bars, &TickerData{
+			Time:          parsedTime,
+			Price:         res.Close,
+			AdjustedPrice: res.AdjClose,
+		})
 	}
 
-	return &resp, nil
+	return bars, nil
 }
 
-func getStartIndexAndJumpInterval(timeframeVal string, results []Result) (int, int) {
-	if timeframeVal == "daily" {
-		return len(results) - 1, 1
+// resampleToFinalCandles groups provider bars into the timeframe's candles using the market calendar,
+// taking each candle's last bar as its close. Candles still forming at now are dropped, and the latest
+// length candles are returned newest first.
+func resampleToFinalCandles(cal calendar.Calendar, timeframe string, bars []*TickerData, now time.Time, length int) []*TickerData {
+	sorted := make([]*TickerData, len(bars))
+	copy(sorted, bars)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var candles []*TickerData
+	for _, bar := range sorted {
+		// Weekly bars may be dated on a weekend, the week they belong to is still clear
+		if _, ok := cal.Session(bar.Time); !ok && timeframe != timeframePkg.Week1 {
+			continue
+		}
+
+		candleStart := calendar.CandleStart(cal, timeframe, bar.Time)
+
+		if len(candles) > 0 && candles[len(candles)-1].Time.Equal(candleStart) {
+			candles[len(candles)-1].Price = bar.Price
+			candles[len(candles)-1].AdjustedPrice = bar.AdjustedPrice
+			continue
+		}
+
+		candles = append(candles, &TickerData{
+			Time:          candleStart,
+			Price:         bar.Price,
+			AdjustedPrice: bar.AdjustedPrice,
+		})
 	}
 
-	// For weekly data, the data is not provided in exact weeks, thus we need to do some skipping
-	weeklyJumpInterval := 2
-	if len(results) < 2 {
-		return len(results) - 1, weeklyJumpInterval
+	for len(candles) > 0 && !calendar.IsCandleFinal(cal, timeframe, candles[len(candles)-1].Time, now) {
+		candles = candles[:len(candles)-1]
 	}
 
-	lastResult := results[len(results)-1]
-	secondLastResult := results[len(results)-2]
+	results := make([]*TickerData, 0, min(length, len(candles)))
+	for i := len(candles) - 1; i >= 0 && len(results) < length; i-- {
+		results = append(results, candles[i])
+	}
 
-	// For weekly data, we need to see which data to start jumping from
-	var startIdx int
-	if secondLastResult.Close == lastResult.Close {
-		startIdx = len(results) - 2
-	} else {
-		startIdx = len(results) - 1
+	return results
+}
+
+func makeRapidAPIHistoricalDataCall(ctx context.Context, client *providerClient, url, key, host string) (*RapidAPIDataResp, error) {
+	headers := map[string]string{
+		"X-RapidAPI-Key":  key,
+		"X-RapidAPI-Host": host,
+	}
+
+	var resp RapidAPIDataResp
+
+	if err := client.getJSON(ctx, url, headers, &resp); err != nil {
+		return nil, err
 	}
 
-	return startIdx, weeklyJumpInterval
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("%s: %w", RapidAPIProvider, ErrNoData)
+	}
+
+	return &resp, nil
 }
 
 func getDateStrToTime(layout string, loc *time.Location, dateStr string) (time.Time, error) {
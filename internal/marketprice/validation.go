@@ -0,0 +1,351 @@
+package marketprice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/signalb/internal/database"
+)
+
+const (
+	PositivePriceRule = "positive_price"
+	DuplicateTimeRule = "duplicate_time"
+	MonotonicTimeRule = "monotonic_time"
+	MaxJumpRule       = "max_jump"
+
+	RejectAction     = "reject"
+	QuarantineAction = "quarantine"
+	FlagAction       = "flag"
+	OffAction        = "off"
+)
+
+// validationRules run in this order, a candle removed by one rule isn't checked by the next ones.
+var validationRules = []string{PositivePriceRule, DuplicateTimeRule, MonotonicTimeRule, MaxJumpRule}
+
+var AllowedValidationActions = []string{RejectAction, QuarantineAction, FlagAction, OffAction}
+
+var candleValidator *CandleValidator
+
+type ValidationConfig struct {
+	// RuleActions maps each rule to what happens to the candles it catches
+	RuleActions map[string]string
+	// ATRPeriod is how many previous candles the average true range is taken over
+	ATRPeriod int
+	// MaxJumpATR is the largest close to close move, in ATRs, the max jump rule lets through
+	MaxJumpATR float64
+}
+
+func DefaultValidationConfig() ValidationConfig {
+	return ValidationConfig{
+		RuleActions: map[string]string{
+			PositivePriceRule: RejectAction,
+			DuplicateTimeRule: RejectAction,
+			MonotonicTimeRule: QuarantineAction,
+			MaxJumpRule:       FlagAction,
+		},
+		ATRPeriod:  14,
+		MaxJumpATR: 10,
+	}
+}
+
+// CandleRejection reports a candle caught by a validation rule and what was done with it.
+type CandleRejection struct {
+	Time   time.Time `json:"time"`
+	Price  float64   `json:"price"` // 0 when the provider's price isn't a finite number, see Reason
+	Rule   string    `json:"rule"`
+	Action string    `json:"action"`
+	Reason string    `json:"reason"`
+}
+
+type CandleValidator struct {
+	config ValidationConfig
+}
+
+func NewCandleValidator(config ValidationConfig) *CandleValidator {
+	return &CandleValidator{
+		config: config,
+	}
+}
+
+// Validate runs the rules over fetched candles, returning the ones to store, in their original order, and
+// every candle a rule caught. Flagged candles are returned marked suspect.
+func (v *CandleValidator) Validate(data []*TickerData) ([]*TickerData, []*CandleRejection) {
+	var (
+		removed    = make(map[*TickerData]bool)
+		rejections []*CandleRejection
+	)
+
+	catch := func(d *TickerData, rule, reason string) {
+		action := v.config.RuleActions[rule]
+
+		rejections = append(rejections, &CandleRejection{
+			Time:   d.Time,
+			Price:  finiteOrZero(d.Price),
+			Rule:   rule,
+			Action: action,
+			Reason: reason,
+		})
+
+		if action == FlagAction {
+			d.Suspect = true
+			return
+		}
+
+		removed[d] = true
+	}
+
+	remaining := func() []*TickerData {
+		res := make([]*TickerData, 0, len(data))
+		for _, d := range data {
+			if !removed[d] {
+				res = append(res, d)
+			}
+		}
+		return res
+	}
+
+	for _, rule := range validationRules {
+		if action := v.config.RuleActions[rule]; action == "" || action == OffAction {
+			continue
+		}
+
+		candles := remaining()
+
+		switch rule {
+		case PositivePriceRule:
+			checkPositivePrices(candles, catch)
+		case DuplicateTimeRule:
+			checkDuplicateTimes(candles, catch)
+		case MonotonicTimeRule:
+			checkMonotonicTimes(candles, catch)
+		case MaxJumpRule:
+			checkMaxJumps(candles, v.config.ATRPeriod, v.config.MaxJumpATR, catch)
+		}
+	}
+
+	return remaining(), rejections
+}
+
+type catchFunc func(d *TickerData, rule, reason string)
+
+func checkPositivePrices(candles []*TickerData, catch catchFunc) {
+	for _, d := range candles {
+		if !isPositivePrice(d.Price) {
+			catch(d, PositivePriceRule, fmt.Sprintf("price %v is not a positive number", d.Price))
+			continue
+		}
+
+		// 0 is an adjusted price yet to be filled in from recorded splits
+		if d.AdjustedPrice != 0 && !isPositivePrice(d.AdjustedPrice) {
+			catch(d, PositivePriceRule, fmt.Sprintf("adjusted price %v is not a positive number", d.AdjustedPrice))
+		}
+	}
+}
+
+func checkDuplicateTimes(candles []*TickerData, catch catchFunc) {
+	seen := make(map[time.Time]bool, len(candles))
+
+	for _, d := range candles {
+		t := d.Time.UTC()
+		if seen[t] {
+			catch(d, DuplicateTimeRule, fmt.Sprintf("another candle at %s came first", d.Time))
+			continue
+		}
+
+		seen[t] = true
+	}
+}
+
+// checkMonotonicTimes catches candles breaking the order of the rest. Fetchers return candles either oldest
+// or newest first, the direction is taken from the first and last candle.
+func checkMonotonicTimes(candles []*TickerData, catch catchFunc) {
+	if len(candles) < 2 {
+		return
+	}
+
+	ascending := !candles[len(candles)-1].Time.Before(candles[0].Time)
+	last := candles[0].Time
+
+	for _, d := range candles[1:] {
+		inOrder := d.Time.After(last)
+		if !ascending {
+			inOrder = d.Time.Before(last)
+		}
+
+		if !inOrder {
+			catch(d, MonotonicTimeRule, fmt.Sprintf("candle at %s is out of order after %s", d.Time, last))
+			continue
+		}
+
+		last = d.Time
+	}
+}
+
+// checkMaxJumps catches close to close moves larger than maxJumpATR times the average true range of the
+// previous candles. Only closes are fetched, so the true range is the absolute change between closes.
+// Caught candles are left out of the following ranges and comparisons, so a bad tick doesn't hide the next one.
+func checkMaxJumps(candles []*TickerData, period int, maxJumpATR float64, catch catchFunc) {
+	if period < 1 || maxJumpATR <= 0 {
+		return
+	}
+
+	sorted := make([]*TickerData, len(candles))
+	copy(sorted, candles)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var (
+		prev   *TickerData
+		ranges []float64
+	)
+
+	for _, d := range sorted {
+		if prev == nil {
+			prev = d
+			continue
+		}
+
+		// The adjusted series doesn't jump on splits
+		change := math.Abs(validationPrice(d) - validationPrice(prev))
+
+		if len(ranges) >= period {
+			var sum float64
+			for _, r := range ranges[len(ranges)-period:] {
+				sum += r
+			}
+
+			if atr := sum / float64(period); atr > 0 && change > maxJumpATR*atr {
+				catch(d, MaxJumpRule, fmt.Sprintf("moved %.2f ATRs from %v to %v, at most %v allowed",
+					change/atr, validationPrice(prev), validationPrice(d), maxJumpATR))
+				continue
+			}
+		}
+
+		ranges = append(ranges, change)
+		prev = d
+	}
+}
+
+func validationPrice(d *TickerData) float64 {
+	if d.AdjustedPrice != 0 {
+		return d.AdjustedPrice
+	}
+
+	return d.Price
+}
+
+func isPositivePrice(price float64) bool {
+	return !math.IsNaN(price) && !math.IsInf(price, 0) && price > 0
+}
+
+func finiteOrZero(price float64) float64 {
+	if math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0
+	}
+
+	return price
+}
+
+// validateCandles runs the validator over fetched candles and moves quarantined ones into the quarantine table.
+func validateCandles(
+	c context.Context,
+	tickerSymbol, timeframe string,
+	data []*TickerData,
+) ([]*TickerData, []*CandleRejection, error) {
+	accepted, rejections := candleValidator.Validate(data)
+
+	var quarantined []database.QuarantinedCandle
+	for _, rejection := range rejections {
+		if rejection.Action != QuarantineAction {
+			continue
+		}
+
+		quarantined = append(quarantined, database.QuarantinedCandle{
+			TickerSymbol: tickerSymbol,
+			Timeframe:    timeframe,
+			Time:         rejection.Time,
+			Price:        rejection.Price,
+			Rule:         rejection.Rule,
+			Reason:       rejection.Reason,
+		})
+	}
+
+	if len(rejections) > 0 {
+		log.Printf("Validation caught %d %s %s candles, %d quarantined", len(rejections), tickerSymbol, timeframe, len(quarantined))
+	}
+
+	if len(quarantined) == 0 {
+		return accepted, rejections, nil
+	}
+
+	ctx, cancel := context.WithTimeout(c, 5*time.Second)
+	defer cancel()
+
+	if err := database.Client.InsertQuarantinedCandles(ctx, quarantined); err != nil {
+		return nil, nil, fmt.Errorf("quarantine candles: %w", err)
+	}
+
+	return accepted, rejections, nil
+}
+
+// getValidationConfig overrides the defaults with VALIDATION_<RULE>_ACTION, VALIDATION_ATR_PERIOD and
+// VALIDATION_MAX_JUMP_ATR when they are set.
+func getValidationConfig() ValidationConfig {
+	config := DefaultValidationConfig()
+
+	for _, rule := range validationRules {
+		key := fmt.Sprintf("VALIDATION_%s_ACTION", strings.ToUpper(rule))
+
+		val := os.Getenv(key)
+		if val == "" {
+			continue
+		}
+
+		if !slices.Contains(AllowedValidationActions, val) {
+			log.Printf("error parsing %s, using default %s: valid actions: %v", key, config.RuleActions[rule], AllowedValidationActions)
+			continue
+		}
+
+		// A price that isn't a number can't be stored, even as suspect
+		if rule == PositivePriceRule && val == FlagAction {
+			log.Printf("%s can't flag candles, using default %s", key, config.RuleActions[rule])
+			continue
+		}
+
+		config.RuleActions[rule] = val
+	}
+
+	if val := os.Getenv("VALIDATION_ATR_PERIOD"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil {
+			log.Printf("error parsing VALIDATION_ATR_PERIOD, using default %d: %v", config.ATRPeriod, err)
+		} else {
+			config.ATRPeriod = parsed
+		}
+	}
+
+	if val := os.Getenv("VALIDATION_MAX_JUMP_ATR"); val != "" {
+		parsed, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			log.Printf("error parsing VALIDATION_MAX_JUMP_ATR, using default %v: %v", config.MaxJumpATR, err)
+		} else {
+			config.MaxJumpATR = parsed
+		}
+	}
+
+	return config
+}
+
+func getQuarantinedCandles(c context.Context, tickerSymbol, timeframe string) ([]database.QuarantinedCandle, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.GetQuarantinedCandles(ctx, tickerSymbol, timeframe)
+}
@@ -0,0 +1,229 @@
+package marketprice
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/database/dbtest"
+	"github.com/signalb/internal/timeframe"
+)
+
+// dailyCandles are candles from 2024-05-01 on, one per day, at the prices.
+func dailyCandles(prices ...float64) []*TickerData {
+	data := make([]*TickerData, 0, len(prices))
+	for i, price := range prices {
+		data = append(data, &TickerData{Time: day(1 + i), Price: price})
+	}
+	return data
+}
+
+func acceptedPrices(data []*TickerData) []float64 {
+	prices := make([]float64, 0, len(data))
+	for _, d := range data {
+		prices = append(prices, d.Price)
+	}
+	return prices
+}
+
+func caughtRules(rejections []*CandleRejection) []string {
+	rules := make([]string, 0, len(rejections))
+	for _, rejection := range rejections {
+		rules = append(rules, rejection.Rule+" "+rejection.Action)
+	}
+	return rules
+}
+
+func TestValidatePositivePrice(t *testing.T) {
+	validator := NewCandleValidator(DefaultValidationConfig())
+
+	data := dailyCandles(1, 0, math.NaN(), -2, math.Inf(1), 3, 4)
+	// An adjusted price yet to be filled in is 0 and passes, a negative one doesn't
+	data[5].AdjustedPrice = 0
+	data[6].AdjustedPrice = -4
+
+	accepted, rejections := validator.Validate(data)
+	if got := acceptedPrices(accepted); !slices.Equal(got, []float64{1, 3}) {
+		t.Errorf("accepted %v, want [1 3]", got)
+	}
+
+	if len(rejections) != 5 || rejections[0].Action != RejectAction || rejections[0].Rule != PositivePriceRule {
+		t.Fatalf("rejections %v, want the 5 non-positive prices rejected", caughtRules(rejections))
+	}
+
+	// A price that isn't a number is reported as 0 so it can be serialized
+	if rejections[1].Price != 0 || rejections[3].Price != 0 {
+		t.Errorf("reported prices %v and %v, want 0 for NaN and Inf", rejections[1].Price, rejections[3].Price)
+	}
+}
+
+func TestValidateDuplicateAndMonotonicTimes(t *testing.T) {
+	validator := NewCandleValidator(DefaultValidationConfig())
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		times    []time.Time
+		accepted []float64
+		caught   []string
+	}{
+		{"in order", []time.Time{day(1), day(2), day(3)}, []float64{1, 2, 3}, nil},
+		{"newest first", []time.Time{day(3), day(2), day(1)}, []float64{1, 2, 3}, nil},
+		// The same instant in another zone is the same candle
+		{"duplicate", []time.Time{day(1), day(2), day(2).In(newYork)}, []float64{1, 2},
+			[]string{"duplicate_time reject"}},
+		{"out of order", []time.Time{day(1), day(3), day(2), day(4)}, []float64{1, 2, 4},
+			[]string{"monotonic_time quarantine"}},
+		{"out of order newest first", []time.Time{day(4), day(2), day(3), day(1)}, []float64{1, 2, 4},
+			[]string{"monotonic_time quarantine"}},
+	}
+
+	for _, tt := range tests {
+		data := make([]*TickerData, 0, len(tt.times))
+		for i, tm := range tt.times {
+			data = append(data, &TickerData{Time: tm, Price: float64(i + 1)})
+		}
+
+		accepted, rejections := validator.Validate(data)
+
+		got := acceptedPrices(accepted)
+		slices.Sort(got)
+		if !slices.Equal(got, tt.accepted) || !slices.Equal(caughtRules(rejections), tt.caught) {
+			t.Errorf("%s: accepted %v caught %v, want %v caught %v",
+				tt.name, got, caughtRules(rejections), tt.accepted, tt.caught)
+		}
+	}
+}
+
+func TestValidateRulesRunInOrder(t *testing.T) {
+	validator := NewCandleValidator(DefaultValidationConfig())
+
+	// The rejected 0 doesn't make the candle after it at the same time a duplicate
+	data := []*TickerData{{Time: day(1), Price: 0}, {Time: day(1), Price: 5}, {Time: day(2), Price: 6}}
+
+	accepted, rejections := validator.Validate(data)
+	if got := acceptedPrices(accepted); !slices.Equal(got, []float64{5, 6}) {
+		t.Errorf("accepted %v, want [5 6]", got)
+	}
+	if got := caughtRules(rejections); !slices.Equal(got, []string{"positive_price reject"}) {
+		t.Errorf("caught %v, want only the 0", got)
+	}
+}
+
+func TestValidateMaxJump(t *testing.T) {
+	validator := NewCandleValidator(DefaultValidationConfig())
+
+	// 14 changes of 1 make an ATR of 1, a move over 10 is a jump
+	steady := func(last ...float64) []*TickerData {
+		prices := make([]float64, 0, 15+len(last))
+		for i := 0; i < 15; i++ {
+			prices = append(prices, 100+float64(i%2))
+		}
+		return dailyCandles(append(prices, last...)...)
+	}
+
+	tests := []struct {
+		name    string
+		data    []*TickerData
+		suspect []float64
+	}{
+		{"within 10 ATRs", steady(110), nil},
+		{"over 10 ATRs", steady(111), []float64{111}},
+		{"down over 10 ATRs", steady(89), []float64{89}},
+		// The caught tick is left out, the next candle is compared to the one before it
+		{"after a bad tick", steady(150, 101), []float64{150}},
+		{"not enough candles for an ATR", dailyCandles(100, 101, 100, 200), nil},
+	}
+
+	for _, tt := range tests {
+		accepted, rejections := validator.Validate(tt.data)
+
+		// Flagged candles are kept and marked suspect
+		var suspect []float64
+		for _, d := range accepted {
+			if d.Suspect {
+				suspect = append(suspect, d.Price)
+			}
+		}
+
+		if len(accepted) != len(tt.data) || !slices.Equal(suspect, tt.suspect) || len(rejections) != len(tt.suspect) {
+			t.Errorf("%s: accepted %d of %d with suspect %v, want %v flagged",
+				tt.name, len(accepted), len(tt.data), suspect, tt.suspect)
+		}
+	}
+
+	// Splits don't jump on the adjusted series
+	split := steady(50.5)
+	for _, d := range split[:15] {
+		d.AdjustedPrice = d.Price / 2
+	}
+	if _, rejections := validator.Validate(split); len(rejections) != 0 {
+		t.Errorf("caught %v on a split, want the adjusted prices compared", caughtRules(rejections))
+	}
+}
+
+func TestValidateCandlesQuarantines(t *testing.T) {
+	db := dbtest.Use(t)
+
+	prev := candleValidator
+	candleValidator = NewCandleValidator(DefaultValidationConfig())
+	t.Cleanup(func() { candleValidator = prev })
+
+	data := []*TickerData{
+		{Time: day(1), Price: 1}, {Time: day(3), Price: 3}, {Time: day(2), Price: 2}, {Time: day(4), Price: -1},
+	}
+
+	accepted, rejections, err := validateCandles(context.Background(), "AAPL", timeframe.Day1, data)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	if len(accepted) != 2 || len(rejections) != 2 {
+		t.Errorf("accepted %d with %d caught, want 2 and 2", len(accepted), len(rejections))
+	}
+
+	// Only the quarantined candle is kept aside, rejected ones are dropped
+	quarantined := db.Quarantined()
+	if len(quarantined) != 1 || !quarantined[0].Time.Equal(day(2)) || quarantined[0].Rule != MonotonicTimeRule {
+		t.Errorf("quarantined %+v, want the 2024-05-02 candle", quarantined)
+	}
+}
+
+func TestGetValidationConfig(t *testing.T) {
+	t.Setenv("VALIDATION_MAX_JUMP_ACTION", QuarantineAction)
+	t.Setenv("VALIDATION_MONOTONIC_TIME_ACTION", OffAction)
+	// A price that isn't a number can't be flagged, an unknown action is ignored
+	t.Setenv("VALIDATION_POSITIVE_PRICE_ACTION", FlagAction)
+	t.Setenv("VALIDATION_DUPLICATE_TIME_ACTION", "drop")
+	t.Setenv("VALIDATION_ATR_PERIOD", "20")
+	t.Setenv("VALIDATION_MAX_JUMP_ATR", "not a number")
+
+	config := getValidationConfig()
+
+	want := map[string]string{
+		PositivePriceRule: RejectAction,
+		DuplicateTimeRule: RejectAction,
+		MonotonicTimeRule: OffAction,
+		MaxJumpRule:       QuarantineAction,
+	}
+	for rule, action := range want {
+		if config.RuleActions[rule] != action {
+			t.Errorf("%s action %q, want %q", rule, config.RuleActions[rule], action)
+		}
+	}
+
+	if config.ATRPeriod != 20 || config.MaxJumpATR != 10 {
+		t.Errorf("ATR period %d and max jump %v, want 20 and the default 10", config.ATRPeriod, config.MaxJumpATR)
+	}
+
+	// An off rule doesn't catch anything
+	data := []*TickerData{{Time: day(2), Price: 2}, {Time: day(1), Price: 1}, {Time: day(3), Price: 3}}
+	if _, rejections := NewCandleValidator(config).Validate(data); len(rejections) != 0 {
+		t.Errorf("caught %v with monotonic_time off", caughtRules(rejections))
+	}
+}
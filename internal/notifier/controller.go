@@ -0,0 +1,265 @@
+package notifier
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	errorsStdLib "errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/errors"
+	"github.com/signalb/internal/timeframe"
+)
+
+// SubscriberResp is a subscriber with its watchlist.
+type SubscriberResp struct {
+	database.Subscriber
+	Subscriptions []database.Subscription `json:"subscriptions"`
+}
+
+// ChannelResp hides channel targets, webhook URLs are credentials themselves.
+type ChannelResp struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Target string `json:"target"`
+}
+
+func UpsertChannelController(c *gin.Context) {
+	var req UpsertChannelReq
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
+		return
+	}
+
+	if req.Name == "" || req.Name == DefaultChannel {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("channel name must be set and can't be %s", DefaultChannel)))
+		return
+	}
+
+	channel := &database.NotificationChannel{
+		Name:    req.Name,
+		Kind:    req.Kind,
+		Target:  req.Target,
+		Secret:  req.Secret,
+		Options: string(req.Options),
+	}
+
+	n, err := NewNotifier(channel, &http.Client{Timeout: webhookTimeout})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(err))
+		return
+	}
+
+	if err := upsertChannel(c.Request.Context(), channel); err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseInsertionError, err)))
+		return
+	}
+
+	NotifierManager.Register(channel.Name, n)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%s channel %s saved successfully", channel.Kind, channel.Name),
+	})
+}
+
+func upsertChannel(c context.Context, channel *database.NotificationChannel) error {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.UpsertNotificationChannel(ctx, channel)
+}
+
+func GetChannelsController(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	channels, err := database.Client.GetNotificationChannels(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	res := []ChannelResp{{Name: DefaultChannel, Kind: TelegramKind, Target: "default chat"}}
+	for _, channel := range channels {
+		target := channel.Target
+		if channel.Kind != TelegramKind {
+			target = maskTarget(target)
+		}
+
+		res = append(res, ChannelResp{
+			Name:   channel.Name,
+			Kind:   channel.Kind,
+			Target: target,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channels": res,
+	})
+}
+
+// maskTarget keeps enough of a webhook URL to recognise it by.
+func maskTarget(target string) string {
+	const visible = 24
+	if len(target) <= visible {
+		return target
+	}
+
+	return target[:visible] + "..."
+}
+
+func AddRouteController(c *gin.Context) {
+	route, ok := bindRoute(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := NotifierManager.GetNotifierByChannel(route.Channel); !ok {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("channel %s is not registered", route.Channel)))
+		return
+	}
+
+	if !isTickerRegistered(c.Request.Context(), route.TickerSymbol) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s is not registered", route.TickerSymbol)))
+		return
+	}
+
+	if err := insertRoute(c.Request.Context(), route); err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseInsertionError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("route %+v added successfully", *route),
+	})
+}
+
+func isTickerRegistered(c context.Context, tickerSymbol string) bool {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.IsTickerRegistered(ctx, tickerSymbol)
+}
+
+func insertRoute(c context.Context, route *database.NotificationRoute) error {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.InsertNotificationRoute(ctx, route)
+}
+
+// DeleteRouteController doesn't check the channel or the ticker, a route outliving either can still be
+// deleted.
+func DeleteRouteController(c *gin.Context) {
+	route, ok := bindRoute(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	err := database.Client.DeleteNotificationRoute(ctx, route)
+	if errorsStdLib.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, errors.NewErrorResp(fmt.Errorf("route %+v not found", *route)))
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errors.NewErrorResp(fmt.Errorf("delete route: %w", err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("route %+v deleted successfully", *route),
+	})
+}
+
+func bindRoute(c *gin.Context) (*database.NotificationRoute, bool) {
+	var req RouteReq
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
+		return nil, false
+	}
+
+	if req.Timeframe != "" && !slices.Contains(timeframe.AllowedTimeframes, req.Timeframe) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return nil, false
+	}
+
+	return &database.NotificationRoute{
+		TickerSymbol: req.TickerSymbol,
+		Timeframe:    req.Timeframe,
+		Strategy:     req.Strategy,
+		Channel:      req.Channel,
+	}, true
+}
+
+func GetRoutesController(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	routes, err := database.Client.GetNotificationRoutes(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"routes":         routes,
+		"defaultChannel": DefaultChannel,
+	})
+}
+
+func GetSubscribersController(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	subscribers, err := database.Client.GetSubscribers(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	subscriptions, err := database.Client.GetSubscriptions(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	chatToSubscriptions := make(map[int64][]database.Subscription)
+	for _, subscription := range subscriptions {
+		chatToSubscriptions[subscription.ChatID] = append(chatToSubscriptions[subscription.ChatID], subscription)
+	}
+
+	res := make([]SubscriberResp, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		res = append(res, SubscriberResp{
+			Subscriber:    subscriber,
+			Subscriptions: chatToSubscriptions[subscriber.ChatID],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subscribers": res,
+	})
+}
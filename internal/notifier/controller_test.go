@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+)
+
+// useChannels makes a manager with Discord notifiers of the channels the NotifierManager until the test ends.
+func useChannels(t *testing.T, channels ...string) {
+	t.Helper()
+
+	manager := NewManager()
+	for _, channel := range channels {
+		manager.Register(channel, NewDiscordNotifier("http://discord.test", http.DefaultClient))
+	}
+
+	prev := NotifierManager
+	NotifierManager = manager
+	t.Cleanup(func() { NotifierManager = prev })
+}
+
+func serveRoute(handler gin.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/notifiers/routes", strings.NewReader(body))
+	handler(c)
+
+	return w
+}
+
+func TestAddRouteController(t *testing.T) {
+	db := dbtest.Use(t)
+	db.SetTickers(database.Ticker{Symbol: "BTC"})
+	useChannels(t, "discord")
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"unregistered ticker", `{"tickerSymbol":"DOGE","channel":"discord"}`, http.StatusBadRequest},
+		{"unregistered channel", `{"tickerSymbol":"BTC","channel":"slack"}`, http.StatusBadRequest},
+		{"invalid timeframe", `{"tickerSymbol":"BTC","timeframe":"M7","channel":"discord"}`, http.StatusBadRequest},
+		{"route", `{"tickerSymbol":"BTC","timeframe":"D1","channel":"discord"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if w := serveRoute(AddRouteController, http.MethodPost, tt.body); w.Code != tt.status {
+			t.Errorf("%s: status %d with %s, want %d", tt.name, w.Code, w.Body, tt.status)
+		}
+	}
+
+	routes, _ := db.GetNotificationRoutes(context.Background())
+	if len(routes) != 1 || routes[0] != (database.NotificationRoute{TickerSymbol: "BTC", Timeframe: "D1", Channel: "discord"}) {
+		t.Errorf("routes %+v, want only the BTC D1 one", routes)
+	}
+}
+
+func TestDeleteRouteControllerSkipsChannelCheck(t *testing.T) {
+	db := dbtest.Use(t)
+	// The channel and the ticker of the route are both gone
+	db.SetRoutes(database.NotificationRoute{TickerSymbol: "DOGE", Channel: "slack"})
+	useChannels(t)
+
+	body := `{"tickerSymbol":"DOGE","channel":"slack"}`
+	if w := serveRoute(DeleteRouteController, http.MethodDelete, body); w.Code != http.StatusOK {
+		t.Fatalf("status %d with %s, want the route deleted", w.Code, w.Body)
+	}
+
+	if w := serveRoute(DeleteRouteController, http.MethodDelete, body); w.Code != http.StatusNotFound {
+		t.Errorf("status %d deleting it again, want %d", w.Code, http.StatusNotFound)
+	}
+}
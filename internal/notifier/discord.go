@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// discordMessageLimit is the most characters Discord accepts in a message's content
+const discordMessageLimit = 2000
+
+// DiscordNotifier posts to a Discord incoming webhook, formatted as Discord markdown.
+type DiscordNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewDiscordNotifier(url string, httpClient *http.Client) *DiscordNotifier {
+	return &DiscordNotifier{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+func (dn *DiscordNotifier) Kind() string {
+	return DiscordKind
+}
+
+// Notify posts the results in as many messages as it takes to stay under Discord's limit.
+func (dn *DiscordNotifier) Notify(ctx context.Context, msg *Message) error {
+	for i, chunk := range splitDiscordMarkdown(msg, discordMessageLimit) {
+		body, err := json.Marshal(map[string]string{"content": chunk})
+		if err != nil {
+			return err
+		}
+
+		if err := postJSON(ctx, dn.httpClient, dn.url, nil, body); err != nil {
+			return fmt.Errorf("message %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// discordMarkdownBlocks returns the timeframe title, one block per ticker and a last block of the failed
+// tickers, each block a list of lines that open and close their own code spans.
+func discordMarkdownBlocks(msg *Message) (string, []string) {
+	tickers, tickerToResults := groupByTicker(msg.Results)
+
+	blocks := make([]string, 0, len(tickers))
+	for _, ticker := range tickers {
+		var blockBuilder strings.Builder
+		blockBuilder.WriteString(fmt.Sprintf("**%s**\n", ticker))
+		for _, result := range tickerToResults[ticker] {
+			blockBuilder.WriteString(fmt.Sprintf("`%s %s: %s`\n", resultLogo(&result), result.Strategy, result.Message))
+		}
+		blockBuilder.WriteString("\n")
+
+		blocks = append(blocks, blockBuilder.String())
+	}
+
+	if len(msg.Failures) > 0 {
+		var blockBuilder strings.Builder
+		blockBuilder.WriteString("**⚠️ Failed tickers**\n")
+		for _, failure := range sortFailures(msg.Failures) {
+			blockBuilder.WriteString(fmt.Sprintf("`%s (%s): %s`\n", failure.TickerSymbol, failure.Category, failure.Error))
+		}
+
+		blocks = append(blocks, blockBuilder.String())
+	}
+
+	title := fmt.Sprintf("__**%s**__\n", msg.Timeframe)
+	return title, blocks
+}
+
+// splitDiscordMarkdown packs the ticker blocks into messages of at most limit characters.
+func splitDiscordMarkdown(msg *Message, limit int) []string {
+	title, blocks := discordMarkdownBlocks(msg)
+	return splitBlocks(title, blocks, limit, utf8.RuneCountInString, truncateDiscordLine)
+}
+
+// truncateDiscordLine shortens the text of a single line that can't fit in a message on its own. Lines
+// are "`text`\n", the text is cut before the closing backtick.
+func truncateDiscordLine(line string, limit int) string {
+	if utf8.RuneCountInString(line) <= limit {
+		return line
+	}
+
+	closeStart := strings.LastIndex(strings.TrimSuffix(line, "\n"), "`")
+	if closeStart <= 0 {
+		closeStart = len(line)
+	}
+	closing := line[closeStart:]
+
+	runes := []rune(line[:closeStart])
+	keep := max(limit-utf8.RuneCountInString(closing)-1, 0)
+
+	return string(runes[:min(keep, len(runes))]) + "…" + closing
+}
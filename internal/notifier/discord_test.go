@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// resultsMessage has perTicker D1 results with the message text for each of the tickers T00, T01 and so on.
+func resultsMessage(tickers, perTicker int, text string) *Message {
+	msg := &Message{Timeframe: "D1"}
+	for i := 0; i < tickers; i++ {
+		for j := 0; j < perTicker; j++ {
+			msg.Results = append(msg.Results, Result{TickerSymbol: fmt.Sprintf("T%02d", i), Timeframe: "D1",
+				Strategy: fmt.Sprintf("rsi%02dbuy", j), Message: text, IsFulfilled: true})
+		}
+	}
+
+	return msg
+}
+
+func TestDiscordNotifySplitsContentUnderTheLimit(t *testing.T) {
+	var contents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		contents = append(contents, body["content"])
+	}))
+	defer server.Close()
+
+	msg := resultsMessage(30, 2, strings.Repeat("x", 40))
+	msg.Failures = []Failure{{TickerSymbol: "BAD", Timeframe: "D1", Category: "provider", Error: "timeout"}}
+
+	if err := NewDiscordNotifier(server.URL, server.Client()).Notify(context.Background(), msg); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if len(contents) < 2 {
+		t.Fatalf("posted %d messages, want the results split", len(contents))
+	}
+
+	title, blocks := discordMarkdownBlocks(msg)
+	var joined strings.Builder
+	for i, content := range contents {
+		if n := utf8.RuneCountInString(content); n > discordMessageLimit {
+			t.Errorf("message %d has %d characters", i+1, n)
+		}
+		if !strings.HasPrefix(content, title) {
+			t.Errorf("message %d starts with %q, want the title", i+1, content[:min(len(content), 20)])
+		}
+		joined.WriteString(strings.TrimPrefix(content, title))
+	}
+
+	// Every block goes out whole and in order
+	if got, want := joined.String(), strings.Join(blocks, ""); got != want {
+		t.Errorf("messages without their titles = %q, want the blocks %q", got, want)
+	}
+}
+
+func TestDiscordNotifySkipsEmptyMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("posted a message without results")
+	}))
+	defer server.Close()
+
+	if err := NewDiscordNotifier(server.URL, server.Client()).Notify(context.Background(), &Message{Timeframe: "D1"}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+}
+
+func TestSplitDiscordMarkdownSplitsOversizedBlockBetweenLines(t *testing.T) {
+	msg := resultsMessage(1, 40, strings.Repeat("y", 80))
+
+	chunks := splitDiscordMarkdown(msg, discordMessageLimit)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d messages, want the block split", len(chunks))
+	}
+
+	lines := 0
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > discordMessageLimit {
+			t.Errorf("message %d has %d characters", i+1, n)
+		}
+		if !strings.HasPrefix(chunk, "__**D1**__\n**T00**\n") {
+			t.Errorf("message %d doesn't repeat the ticker line: %q", i+1, chunk[:min(len(chunk), 30)])
+		}
+		lines += strings.Count(chunk, "`\n")
+	}
+
+	if lines != 40 {
+		t.Errorf("messages have %d result lines, want all 40", lines)
+	}
+}
+
+func TestTruncateDiscordLine(t *testing.T) {
+	// A single result longer than a whole message, with emoji that are one character to Discord
+	msg := resultsMessage(1, 1, strings.Repeat("📈", 3000))
+
+	chunks := splitDiscordMarkdown(msg, discordMessageLimit)
+	if len(chunks) != 1 {
+		t.Fatalf("got %d messages, want 1", len(chunks))
+	}
+
+	chunk := chunks[0]
+	if n := utf8.RuneCountInString(chunk); n != discordMessageLimit {
+		t.Errorf("message has %d characters, want it filled up to %d", n, discordMessageLimit)
+	}
+	if !utf8.ValidString(chunk) || !strings.HasSuffix(chunk, "📈…`\n") {
+		t.Errorf("message ends with %q, want the emoji cut whole before the closing backtick", chunk[len(chunk)-20:])
+	}
+
+	tests := []struct {
+		line  string
+		limit int
+		want  string
+	}{
+		{"`short`\n", 20, "`short`\n"},
+		{"`abcdefgh`\n", 8, "`abcd…`\n"},
+		{"`äöüäöü`\n", 6, "`äö…`\n"},
+		{"no code span here\n", 8, "no code…"},
+	}
+
+	for _, tt := range tests {
+		if got := truncateDiscordLine(tt.line, tt.limit); got != tt.want {
+			t.Errorf("truncateDiscordLine(%q, %d) = %q, want %q", tt.line, tt.limit, got, tt.want)
+		}
+	}
+}
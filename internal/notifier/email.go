@@ -0,0 +1,261 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultSummaryHour = 18
+
+// SMTPConfig is the server every email channel sends through. Pointing Host and Port at a local stand-in
+// like MailHog (localhost:1025, no auth) is enough to try the emails out.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// SummaryHour is the UTC hour the daily summary goes out at
+	SummaryHour int
+}
+
+func (sc *SMTPConfig) addr() string {
+	return net.JoinHostPort(sc.Host, sc.Port)
+}
+
+// auth is nil without a username, local stand-ins don't authenticate.
+func (sc *SMTPConfig) auth() smtp.Auth {
+	if sc.Username == "" {
+		return nil
+	}
+
+	return smtp.PlainAuth("", sc.Username, sc.Password, sc.Host)
+}
+
+func getSMTPConfig() *SMTPConfig {
+	config := &SMTPConfig{
+		Host:        os.Getenv("SMTP_HOST"),
+		Port:        os.Getenv("SMTP_PORT"),
+		Username:    os.Getenv("SMTP_USERNAME"),
+		Password:    os.Getenv("SMTP_PASSWORD"),
+		From:        os.Getenv("SMTP_FROM"),
+		SummaryHour: defaultSummaryHour,
+	}
+
+	if config.Port == "" {
+		config.Port = "587"
+	}
+
+	if val := os.Getenv("SMTP_SUMMARY_HOUR"); val != "" {
+		hour, err := strconv.Atoi(val)
+		if err != nil || hour < 0 || hour > 23 {
+			log.Printf("invalid SMTP_SUMMARY_HOUR %q, using %d", val, defaultSummaryHour)
+		} else {
+			config.SummaryHour = hour
+		}
+	}
+
+	return config
+}
+
+// EmailOptions are the per recipient settings of an email channel, both on unless turned off.
+type EmailOptions struct {
+	// Digest sends an email for every evaluation run
+	Digest bool `json:"digest"`
+	// DailySummary sends one email a day with the signals fulfilled that day
+	DailySummary bool `json:"dailySummary"`
+}
+
+func parseEmailOptions(raw string) (*EmailOptions, error) {
+	options := &EmailOptions{Digest: true, DailySummary: true}
+	if raw == "" {
+		return options, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), options); err != nil {
+		return nil, fmt.Errorf("invalid email options: %w", err)
+	}
+
+	return options, nil
+}
+
+func parseRecipients(target string) ([]string, error) {
+	addresses, err := mail.ParseAddressList(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email recipients %q: %w", target, err)
+	}
+
+	recipients := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		recipients = append(recipients, address.Address)
+	}
+
+	return recipients, nil
+}
+
+// sendMailFunc matches smtp.SendMail.
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailNotifier sends an HTML digest of every evaluation run and keeps the day's results for the daily
+// summary. The summary only holds what was sent since the last one, a restart starts it over.
+type EmailNotifier struct {
+	config     *SMTPConfig
+	recipients []string
+	options    *EmailOptions
+	sendMail   sendMailFunc
+
+	mu      sync.Mutex
+	summary []Result
+}
+
+func NewEmailNotifier(config *SMTPConfig, recipients []string, options *EmailOptions) *EmailNotifier {
+	return &EmailNotifier{
+		config:     config,
+		recipients: recipients,
+		options:    options,
+		sendMail:   smtp.SendMail,
+	}
+}
+
+func (en *EmailNotifier) Kind() string {
+	return EmailKind
+}
+
+func (en *EmailNotifier) Notify(_ context.Context, msg *Message) error {
+	if en.options.DailySummary {
+		en.mu.Lock()
+		en.summary = append(en.summary, msg.Results...)
+		en.mu.Unlock()
+	}
+
+	if !en.options.Digest {
+		return nil
+	}
+
+	content := formatEmailDigest(msg)
+	if content == "" {
+		return nil
+	}
+
+	return en.send(fmt.Sprintf("Signals %s", msg.Timeframe), content)
+}
+
+// SendDailySummary emails the fulfilled results kept since the last summary and starts over. Nothing is
+// sent when none were fulfilled.
+func (en *EmailNotifier) SendDailySummary(day time.Time) error {
+	if !en.options.DailySummary {
+		return nil
+	}
+
+	en.mu.Lock()
+	results := en.summary
+	en.summary = nil
+	en.mu.Unlock()
+
+	content := formatEmailSummary(day, results)
+	if content == "" {
+		return nil
+	}
+
+	return en.send(fmt.Sprintf("Daily signal summary %s", day.Format(time.DateOnly)), content)
+}
+
+func (en *EmailNotifier) send(subject, htmlBody string) error {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("From: %s\r\n", en.config.From))
+	builder.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(en.recipients, ", ")))
+	builder.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject)))
+	builder.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(htmlBody)
+
+	return en.sendMail(en.config.addr(), en.config.auth(), en.config.From, en.recipients, []byte(builder.String()))
+}
+
+// formatEmailDigest wraps the Telegram message in a page, it already is HTML.
+func formatEmailDigest(msg *Message) string {
+	content := formatTelegramHTML(msg)
+	if content == "" {
+		return ""
+	}
+
+	return wrapEmailHTML(strings.ReplaceAll(strings.TrimSpace(content), "\n", "<br>\r\n"))
+}
+
+// formatEmailSummary lists the fulfilled results of the day per timeframe and ticker, with how many
+// evaluations they came out of.
+func formatEmailSummary(day time.Time, results []Result) string {
+	var fulfilled []Result
+	for _, result := range results {
+		if result.IsFulfilled {
+			fulfilled = append(fulfilled, result)
+		}
+	}
+
+	if len(fulfilled) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(fulfilled, func(i, j int) bool {
+		if fulfilled[i].Timeframe != fulfilled[j].Timeframe {
+			return fulfilled[i].Timeframe < fulfilled[j].Timeframe
+		}
+		return fulfilled[i].TickerSymbol < fulfilled[j].TickerSymbol
+	})
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("<h2>Signals on %s</h2>\r\n", day.Format(time.DateOnly)))
+	builder.WriteString(fmt.Sprintf("<p>%d of %d evaluations fulfilled.</p>\r\n", len(fulfilled), len(results)))
+	builder.WriteString("<table border=\"1\" cellpadding=\"4\" cellspacing=\"0\">\r\n")
+	builder.WriteString("<tr><th>Timeframe</th><th>Ticker</th><th>Strategy</th><th>Message</th></tr>\r\n")
+	for _, result := range fulfilled {
+		builder.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\r\n",
+			html.EscapeString(result.Timeframe),
+			html.EscapeString(result.TickerSymbol),
+			html.EscapeString(result.Strategy),
+			html.EscapeString(result.Message),
+		))
+	}
+	builder.WriteString("</table>")
+
+	return wrapEmailHTML(builder.String())
+}
+
+func wrapEmailHTML(content string) string {
+	return "<!DOCTYPE html>\r\n<html><body style=\"font-family: sans-serif\">\r\n" + content + "\r\n</body></html>\r\n"
+}
+
+// runDailySummaries sends the summary of every email channel at the configured hour, for as long as the
+// process runs.
+func runDailySummaries(hour int) {
+	for {
+		now := time.Now().UTC()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		time.Sleep(next.Sub(now))
+
+		for name, n := range NotifierManager.GetNotifiersByKind(EmailKind) {
+			if err := n.(*EmailNotifier).SendDailySummary(next); err != nil {
+				log.Printf("error sending daily summary to %s: %v", name, err)
+			}
+		}
+	}
+}
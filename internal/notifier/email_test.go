@@ -0,0 +1,363 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sentMail is one call of the notifier's sendMail.
+type sentMail struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+	msg  []byte
+}
+
+// newCapturingEmailNotifier swaps sendMail for one that keeps what would have been sent.
+func newCapturingEmailNotifier(options *EmailOptions) (*EmailNotifier, *[]sentMail) {
+	var sent []sentMail
+
+	en := NewEmailNotifier(&SMTPConfig{Host: "smtp.test", Port: "2525", From: "signals@test.dev"},
+		[]string{"a@test.dev", "b@test.dev"}, options)
+	en.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, sentMail{addr: addr, auth: a, from: from, to: to, msg: msg})
+		return nil
+	}
+
+	return en, &sent
+}
+
+func readMail(t *testing.T, raw []byte) (*mail.Message, string, string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("read mail: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	return msg, subject, string(body)
+}
+
+func testMessage() *Message {
+	return &Message{
+		Timeframe: "D1",
+		Results: []Result{
+			{TickerSymbol: "MSFT", Timeframe: "D1", Strategy: "rsi30buy", IsFulfilled: true, Message: "RSI 28 under 30"},
+			{TickerSymbol: "AAPL", Timeframe: "D1", Strategy: "sma200", IsFulfilled: false, Message: "Price above SMA"},
+		},
+		Failures: []Failure{{TickerSymbol: "TSLA", Timeframe: "D1", Category: "provider", Error: "status 502"}},
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	recipients, err := parseRecipients("a@test.dev, Bob <b@test.dev>")
+	if err != nil {
+		t.Fatalf("parseRecipients: %v", err)
+	}
+
+	if want := []string{"a@test.dev", "b@test.dev"}; !slices.Equal(recipients, want) {
+		t.Errorf("recipients = %v, want %v", recipients, want)
+	}
+
+	for _, target := range []string{"", "not an address", "a@test.dev;b@test.dev"} {
+		if _, err := parseRecipients(target); err == nil {
+			t.Errorf("parseRecipients(%q) gave no error", target)
+		}
+	}
+}
+
+func TestParseEmailOptions(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want EmailOptions
+	}{
+		{"", EmailOptions{Digest: true, DailySummary: true}},
+		{`{"digest": false}`, EmailOptions{Digest: false, DailySummary: true}},
+		{`{"dailySummary": false}`, EmailOptions{Digest: true, DailySummary: false}},
+	}
+
+	for _, tt := range tests {
+		options, err := parseEmailOptions(tt.raw)
+		if err != nil {
+			t.Fatalf("parseEmailOptions(%q): %v", tt.raw, err)
+		}
+
+		if *options != tt.want {
+			t.Errorf("parseEmailOptions(%q) = %+v, want %+v", tt.raw, *options, tt.want)
+		}
+	}
+
+	if _, err := parseEmailOptions("{digest"); err == nil {
+		t.Error("invalid options gave no error")
+	}
+}
+
+func TestSMTPConfigAuth(t *testing.T) {
+	if auth := (&SMTPConfig{Host: "localhost"}).auth(); auth != nil {
+		t.Errorf("auth without a username = %v, want nil", auth)
+	}
+
+	if auth := (&SMTPConfig{Host: "smtp.test", Username: "user", Password: "pass"}).auth(); auth == nil {
+		t.Error("auth with a username is nil")
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	en, sent := newCapturingEmailNotifier(&EmailOptions{Digest: true})
+
+	if err := en.Notify(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(*sent))
+	}
+
+	mailSent := (*sent)[0]
+	if mailSent.addr != "smtp.test:2525" || mailSent.from != "signals@test.dev" ||
+		!slices.Equal(mailSent.to, []string{"a@test.dev", "b@test.dev"}) {
+		t.Errorf("sent to %s from %s for %v", mailSent.addr, mailSent.from, mailSent.to)
+	}
+
+	if !strings.Contains(string(mailSent.msg), "\r\n\r\n") {
+		t.Error("headers aren't separated from the body by CRLF")
+	}
+
+	msg, subject, body := readMail(t, mailSent.msg)
+
+	if subject != "Signals D1" {
+		t.Errorf("subject = %q", subject)
+	}
+
+	if got := msg.Header.Get("To"); got != "a@test.dev, b@test.dev" {
+		t.Errorf("To = %q", got)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "text/html" || params["charset"] != "UTF-8" {
+		t.Errorf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+
+	if msg.Header.Get("MIME-Version") != "1.0" {
+		t.Errorf("MIME-Version = %q", msg.Header.Get("MIME-Version"))
+	}
+
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+
+	for _, want := range []string{"<!DOCTYPE html>", "<b>AAPL</b>", "rsi30buy: RSI 28 under 30", "Failed tickers", "TSLA", "<br>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body misses %q:\n%s", want, body)
+		}
+	}
+
+	// AAPL comes before MSFT whatever order the results were in
+	if strings.Index(body, "AAPL") > strings.Index(body, "MSFT") {
+		t.Error("tickers aren't sorted")
+	}
+}
+
+func TestEmailDigestSkipsEmptyMessages(t *testing.T) {
+	en, sent := newCapturingEmailNotifier(&EmailOptions{Digest: true})
+
+	if err := en.Notify(context.Background(), &Message{Timeframe: "D1"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if len(*sent) != 0 {
+		t.Errorf("sent %d emails for an empty run", len(*sent))
+	}
+}
+
+func TestEmailDailySummary(t *testing.T) {
+	en, sent := newCapturingEmailNotifier(&EmailOptions{DailySummary: true})
+
+	msg := testMessage()
+	msg.Results = append([]Result{{TickerSymbol: "BTC", Timeframe: "H4", Strategy: "rsi30buy",
+		IsFulfilled: true, Message: "RSI <30 & falling"}}, msg.Results...)
+
+	if err := en.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if len(*sent) != 0 {
+		t.Fatalf("sent %d digests with the digest turned off", len(*sent))
+	}
+
+	day := time.Date(2024, 5, 6, 18, 0, 0, 0, time.UTC)
+	if err := en.SendDailySummary(day); err != nil {
+		t.Fatalf("SendDailySummary: %v", err)
+	}
+
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d summaries, want 1", len(*sent))
+	}
+
+	_, subject, body := readMail(t, (*sent)[0].msg)
+
+	if subject != "Daily signal summary 2024-05-06" {
+		t.Errorf("subject = %q", subject)
+	}
+
+	for _, want := range []string{"Signals on 2024-05-06", "2 of 3 evaluations fulfilled", "RSI &lt;30 &amp; falling"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body misses %q:\n%s", want, body)
+		}
+	}
+
+	if strings.Contains(body, "AAPL") {
+		t.Error("summary lists a result that wasn't fulfilled")
+	}
+
+	// Rows are sorted by timeframe though BTC's H4 result came in first
+	if strings.Index(body, "<td>H4</td>") < strings.Index(body, "<td>D1</td>") {
+		t.Error("rows aren't sorted by timeframe")
+	}
+
+	// The summary starts over once sent
+	if err := en.SendDailySummary(day.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("SendDailySummary: %v", err)
+	}
+
+	if len(*sent) != 1 {
+		t.Errorf("sent %d emails, the second summary had nothing in it", len(*sent))
+	}
+}
+
+func TestEmailDailySummaryTurnedOff(t *testing.T) {
+	en, sent := newCapturingEmailNotifier(&EmailOptions{Digest: true})
+
+	if err := en.Notify(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if err := en.SendDailySummary(time.Now()); err != nil {
+		t.Fatalf("SendDailySummary: %v", err)
+	}
+
+	if len(*sent) != 1 {
+		t.Errorf("sent %d emails, want only the digest", len(*sent))
+	}
+
+	if len(en.summary) != 0 {
+		t.Errorf("kept %d results for a summary that's turned off", len(en.summary))
+	}
+}
+
+// smtpListener is a bare SMTP server that accepts one message and hands over its envelope and data.
+func smtpListener(t *testing.T) (string, <-chan sentMail) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan sentMail, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var mailReceived sentMail
+
+		reply := func(format string, args ...any) bool {
+			return text.PrintfLine(format, args...) == nil
+		}
+
+		if !reply("220 localhost ESMTP") {
+			return
+		}
+
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case verb == "EHLO" || verb == "HELO":
+				reply("250 localhost")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+				mailReceived.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				mailReceived.to = append(mailReceived.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+				reply("250 OK")
+			case verb == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				data, err := io.ReadAll(bufio.NewReader(text.DotReader()))
+				if err != nil {
+					return
+				}
+				mailReceived.msg = data
+				reply("250 OK")
+			case verb == "QUIT":
+				reply("221 Bye")
+				received <- mailReceived
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestEmailNotifierSendsOverSMTP(t *testing.T) {
+	addr, received := smtpListener(t)
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("split %s: %v", addr, err)
+	}
+
+	en := NewEmailNotifier(&SMTPConfig{Host: host, Port: port, From: "signals@test.dev"},
+		[]string{"a@test.dev", "b@test.dev"}, &EmailOptions{Digest: true})
+
+	if err := en.Notify(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	select {
+	case mailReceived := <-received:
+		if mailReceived.from != "signals@test.dev" || !slices.Equal(mailReceived.to, []string{"a@test.dev", "b@test.dev"}) {
+			t.Errorf("envelope from %s to %v", mailReceived.from, mailReceived.to)
+		}
+
+		_, subject, body := readMail(t, mailReceived.msg)
+		if subject != "Signals D1" || !strings.Contains(body, "rsi30buy") {
+			t.Errorf("received %q with body:\n%s", subject, body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email received")
+	}
+}
@@ -0,0 +1,467 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/telegram"
+)
+
+const (
+	TelegramKind = "telegram"
+	DiscordKind  = "discord"
+	SlackKind    = "slack"
+	WebhookKind  = "webhook"
+	EmailKind    = "email"
+
+	// DefaultChannel is the bot's default chat, where results neither routed nor subscribed to go
+	DefaultChannel = "telegram"
+	// LiveTimeframe is the timeframe of results from streamed trades rather than candles, like price alerts.
+	// Only routes for every timeframe of a ticker match it, and it has no charts.
+	LiveTimeframe = "live"
+
+	webhookTimeout = 10 * time.Second
+)
+
+var AllowedKinds = []string{TelegramKind, DiscordKind, SlackKind, WebhookKind, EmailKind}
+
+var (
+	NotifierManager *Manager
+	smtpConfig      *SMTPConfig
+	// defaultGetsAll sends the default channel the unrouted results that subscribers already get, so the
+	// admins keep seeing everything. Off by default, subscribed results only go to their chats.
+	defaultGetsAll bool
+)
+
+// Result is one strategy evaluation to notify about.
+type Result struct {
+	TickerSymbol string `json:"tickerSymbol"`
+	Timeframe    string `json:"timeframe"`
+	Strategy     string `json:"strategy"`
+	IsFulfilled  bool   `json:"isFulfilled"`
+	Message      string `json:"message"`
+}
+
+// Failure is a ticker that couldn't be evaluated, so its results are missing from the run.
+type Failure struct {
+	TickerSymbol string `json:"tickerSymbol"`
+	Timeframe    string `json:"timeframe"`
+	Category     string `json:"category"`
+	Error        string `json:"error"`
+}
+
+// Message is what a channel receives for one evaluation run, each notifier formats it its own way.
+type Message struct {
+	Timeframe string    `json:"timeframe"`
+	Results   []Result  `json:"results"`
+	Failures  []Failure `json:"failures,omitempty"`
+}
+
+type Notifier interface {
+	Kind() string
+	Notify(ctx context.Context, msg *Message) error
+}
+
+type Manager struct {
+	mu       sync.RWMutex
+	channels map[string]Notifier
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		channels: make(map[string]Notifier),
+	}
+}
+
+func (m *Manager) Register(name string, n Notifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.channels[name] = n
+}
+
+func (m *Manager) GetNotifierByChannel(name string) (Notifier, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n, ok := m.channels[name]
+	return n, ok
+}
+
+func (m *Manager) GetNotifiersByKind(kind string) map[string]Notifier {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	notifiers := make(map[string]Notifier)
+	for name, n := range m.channels {
+		if n.Kind() == kind {
+			notifiers[name] = n
+		}
+	}
+
+	return notifiers
+}
+
+// NewNotifier builds the notifier of a stored channel.
+func NewNotifier(channel *database.NotificationChannel, httpClient *http.Client) (Notifier, error) {
+	switch channel.Kind {
+	case TelegramKind:
+		chatID, err := strconv.ParseInt(channel.Target, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid telegram chat id %q: %w", channel.Target, err)
+		}
+		options, err := parseTelegramOptions(channel.Options)
+		if err != nil {
+			return nil, err
+		}
+		return NewTelegramNotifier(telegram.Bot, chatID, options), nil
+	case DiscordKind:
+		return NewDiscordNotifier(channel.Target, httpClient), nil
+	case SlackKind:
+		return NewSlackNotifier(channel.Target, httpClient), nil
+	case WebhookKind:
+		if channel.Secret == "" {
+			return nil, errors.New("webhook channels need a secret to sign payloads with")
+		}
+		return NewWebhookNotifier(channel.Target, channel.Secret, httpClient), nil
+	case EmailKind:
+		if smtpConfig == nil || smtpConfig.Host == "" || smtpConfig.From == "" {
+			return nil, errors.New("email channels need SMTP_HOST and SMTP_FROM to be set")
+		}
+		recipients, err := parseRecipients(channel.Target)
+		if err != nil {
+			return nil, err
+		}
+		options, err := parseEmailOptions(channel.Options)
+		if err != nil {
+			return nil, err
+		}
+		return NewEmailNotifier(smtpConfig, recipients, options), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier kind %q, valid kinds: %v", channel.Kind, AllowedKinds)
+	}
+}
+
+// InitNotifiers registers the bot's default chat and every stored channel, and schedules the daily email
+// summary when SMTP is configured. Needs the database and the Telegram bot.
+func InitNotifiers() {
+	NotifierManager = NewManager()
+	smtpConfig = getSMTPConfig()
+	defaultGetsAll = os.Getenv("DEFAULT_CHANNEL_ALL_RESULTS") == "true"
+	if smtpConfig.Host != "" {
+		go runDailySummaries(smtpConfig.SummaryHour)
+	}
+	NotifierManager.Register(DefaultChannel, NewTelegramNotifier(telegram.Bot, telegram.Bot.DefaultChatID,
+		&TelegramOptions{
+			FulfilledOnly: os.Getenv("TELEGRAM_FULFILLED_ONLY") == "true",
+			Charts:        os.Getenv("TELEGRAM_CHARTS") != "false",
+		}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	channels, err := database.Client.GetNotificationChannels(ctx)
+	if err != nil {
+		log.Println("error loading notification channels", err)
+		return
+	}
+
+	httpClient := &http.Client{Timeout: webhookTimeout}
+	for i := range channels {
+		n, err := NewNotifier(&channels[i], httpClient)
+		if err != nil {
+			log.Printf("error loading notification channel %s: %v", channels[i].Name, err)
+			continue
+		}
+
+		NotifierManager.Register(channels[i].Name, n)
+	}
+}
+
+// Dispatch sends each channel the results routed to it and each subscriber's chat the results on its
+// watchlist. Results neither routed nor subscribed to go to the default channel, and so do the subscribed ones
+// without a route when DEFAULT_CHANNEL_ALL_RESULTS is true. A failed ticker goes to whoever would have
+// received any of its results. Every channel and chat is tried, the errors of the failing ones are joined.
+func Dispatch(c context.Context, msg *Message) error {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	routes, err := database.Client.GetNotificationRoutes(ctx)
+	if err != nil {
+		return err
+	}
+
+	subscriptions, err := database.Client.GetSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	channelToMessage, chatToMessage := routeResults(routes, subscriptions, msg, defaultGetsAll)
+
+	var errs []error
+	for channel, channelMsg := range channelToMessage {
+		n, ok := NotifierManager.GetNotifierByChannel(channel)
+		if !ok {
+			errs = append(errs, fmt.Errorf("channel %s is not registered", channel))
+			continue
+		}
+
+		if err := n.Notify(c, channelMsg); err != nil {
+			errs = append(errs, fmt.Errorf("notify %s: %w", channel, err))
+		}
+	}
+
+	for chatID, chatMsg := range chatToMessage {
+		n := NewTelegramNotifier(telegram.Bot, chatID, defaultTelegramOptions())
+		if err := n.Notify(c, chatMsg); err != nil {
+			errs = append(errs, fmt.Errorf("notify subscriber %d: %w", chatID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func routeResults(
+	routes []database.NotificationRoute,
+	subscriptions []database.Subscription,
+	msg *Message,
+	defaultGetsAll bool,
+) (map[string]*Message, map[int64]*Message) {
+	var (
+		channelToMessage = make(map[string]*Message)
+		chatToMessage    = make(map[int64]*Message)
+	)
+
+	for _, result := range msg.Results {
+		channels := matchChannels(routes, &result)
+		chatIDs := matchSubscribers(subscriptions, &result)
+		if len(channels) == 0 && (len(chatIDs) == 0 || defaultGetsAll) {
+			channels = []string{DefaultChannel}
+		}
+
+		for _, channel := range channels {
+			keyMsg := keyMessage(channelToMessage, channel, msg.Timeframe)
+			keyMsg.Results = append(keyMsg.Results, result)
+		}
+
+		for _, chatID := range chatIDs {
+			keyMsg := keyMessage(chatToMessage, chatID, msg.Timeframe)
+			keyMsg.Results = append(keyMsg.Results, result)
+		}
+	}
+
+	for _, failure := range msg.Failures {
+		channels, chatIDs := matchFailure(routes, subscriptions, &failure)
+		if len(channels) == 0 && (len(chatIDs) == 0 || defaultGetsAll) {
+			channels = []string{DefaultChannel}
+		}
+
+		for _, channel := range channels {
+			keyMsg := keyMessage(channelToMessage, channel, msg.Timeframe)
+			keyMsg.Failures = append(keyMsg.Failures, failure)
+		}
+
+		for _, chatID := range chatIDs {
+			keyMsg := keyMessage(chatToMessage, chatID, msg.Timeframe)
+			keyMsg.Failures = append(keyMsg.Failures, failure)
+		}
+	}
+
+	return channelToMessage, chatToMessage
+}
+
+// keyMessage returns the message being built for the key, starting it if needed.
+func keyMessage[K comparable](keyToMessage map[K]*Message, key K, timeframe string) *Message {
+	keyMsg, ok := keyToMessage[key]
+	if !ok {
+		keyMsg = &Message{Timeframe: timeframe}
+		keyToMessage[key] = keyMsg
+	}
+
+	return keyMsg
+}
+
+// matchFailure finds the channels routed and the chats subscribed to any strategy of the failed ticker.
+func matchFailure(
+	routes []database.NotificationRoute,
+	subscriptions []database.Subscription,
+	failure *Failure,
+) ([]string, []int64) {
+	var (
+		channels []string
+		chatIDs  []int64
+	)
+
+	for _, route := range routes {
+		if route.TickerSymbol == failure.TickerSymbol &&
+			(route.Timeframe == "" || route.Timeframe == failure.Timeframe) &&
+			!slices.Contains(channels, route.Channel) {
+			channels = append(channels, route.Channel)
+		}
+	}
+
+	for _, subscription := range subscriptions {
+		if subscription.TickerSymbol == failure.TickerSymbol &&
+			subscription.Timeframe == failure.Timeframe &&
+			!slices.Contains(chatIDs, subscription.ChatID) {
+			chatIDs = append(chatIDs, subscription.ChatID)
+		}
+	}
+
+	return channels, chatIDs
+}
+
+func matchSubscribers(subscriptions []database.Subscription, result *Result) []int64 {
+	var chatIDs []int64
+
+	for _, subscription := range subscriptions {
+		if subscription.TickerSymbol == result.TickerSymbol &&
+			subscription.Timeframe == result.Timeframe &&
+			subscription.Strategy == result.Strategy {
+			chatIDs = append(chatIDs, subscription.ChatID)
+		}
+	}
+
+	return chatIDs
+}
+
+func matchChannels(routes []database.NotificationRoute, result *Result) []string {
+	var (
+		channels []string
+		seen     = make(map[string]bool)
+	)
+
+	for _, route := range routes {
+		if route.TickerSymbol != result.TickerSymbol ||
+			(route.Timeframe != "" && route.Timeframe != result.Timeframe) ||
+			(route.Strategy != "" && route.Strategy != result.Strategy) {
+			continue
+		}
+
+		if !seen[route.Channel] {
+			seen[route.Channel] = true
+			channels = append(channels, route.Channel)
+		}
+	}
+
+	return channels
+}
+
+// groupByTicker sorts tickers and each ticker's results by strategy, evaluation results come out of a map
+// and would otherwise be ordered differently on every run.
+func groupByTicker(results []Result) ([]string, map[string][]Result) {
+	var (
+		tickers         []string
+		tickerToResults = make(map[string][]Result)
+	)
+
+	for _, result := range results {
+		if _, ok := tickerToResults[result.TickerSymbol]; !ok {
+			tickers = append(tickers, result.TickerSymbol)
+		}
+
+		tickerToResults[result.TickerSymbol] = append(tickerToResults[result.TickerSymbol], result)
+	}
+
+	sort.Strings(tickers)
+	for _, tickerResults := range tickerToResults {
+		sort.SliceStable(tickerResults, func(i, j int) bool {
+			return tickerResults[i].Strategy < tickerResults[j].Strategy
+		})
+	}
+
+	return tickers, tickerToResults
+}
+
+// splitBlocks packs whole blocks into messages of at most limit, as length counts it, each starting with
+// the title. A block too long for one message is split between its lines, every part keeping the block's
+// first line, and a line too long on its own is shortened by truncate.
+func splitBlocks(
+	title string,
+	blocks []string,
+	limit int,
+	length func(string) int,
+	truncate func(line string, limit int) string,
+) []string {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	var (
+		chunks  []string
+		current strings.Builder
+	)
+
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, title+current.String())
+			current.Reset()
+		}
+	}
+
+	room := limit - length(title)
+	for _, block := range blocks {
+		if length(current.String())+length(block) <= room {
+			current.WriteString(block)
+			continue
+		}
+
+		flush()
+		if length(block) <= room {
+			current.WriteString(block)
+			continue
+		}
+
+		lines := strings.SplitAfter(block, "\n")
+		header := lines[0]
+		current.WriteString(header)
+		for _, line := range lines[1:] {
+			if line == "" {
+				continue
+			}
+
+			if current.Len() > len(header) && length(current.String())+length(line) > room {
+				// The blank line ending the block is only spacing, not worth a message of its own
+				if line == "\n" {
+					continue
+				}
+
+				flush()
+				current.WriteString(header)
+			}
+			current.WriteString(truncate(line, room-length(header)))
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// sortFailures orders failures by ticker, they're collected as the tickers fail.
+func sortFailures(failures []Failure) []Failure {
+	sorted := slices.Clone(failures)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TickerSymbol < sorted[j].TickerSymbol
+	})
+
+	return sorted
+}
+
+func resultLogo(result *Result) string {
+	if result.IsFulfilled {
+		return "✅"
+	}
+
+	return "❌"
+}
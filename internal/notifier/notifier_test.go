@@ -0,0 +1,73 @@
+package notifier
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/signalb/internal/database"
+)
+
+func TestRouteResults(t *testing.T) {
+	routes := []database.NotificationRoute{{TickerSymbol: "BTC", Channel: "discord"}}
+	subscriptions := []database.Subscription{
+		{ChatID: 42, TickerSymbol: "AAPL", Timeframe: "D1", Strategy: "rsi30buy"},
+		{ChatID: 42, TickerSymbol: "BTC", Timeframe: "D1", Strategy: "rsi30buy"},
+	}
+
+	msg := &Message{
+		Timeframe: "D1",
+		Results: []Result{
+			{TickerSymbol: "AAPL", Timeframe: "D1", Strategy: "rsi30buy", IsFulfilled: true},
+			{TickerSymbol: "BTC", Timeframe: "D1", Strategy: "rsi30buy", IsFulfilled: true},
+			{TickerSymbol: "MSFT", Timeframe: "D1", Strategy: "rsi30buy", IsFulfilled: false},
+		},
+		Failures: []Failure{{TickerSymbol: "AAPL", Timeframe: "D1", Category: "provider"}},
+	}
+
+	tests := []struct {
+		name            string
+		defaultGetsAll  bool
+		defaultTickers  []string
+		defaultFailures int
+	}{
+		// AAPL is subscribed to, only its subscriber gets it
+		{"subscribers only", false, []string{"MSFT"}, 0},
+		// AAPL isn't routed, the default channel gets it next to its subscriber
+		{"default gets all", true, []string{"AAPL", "MSFT"}, 1},
+	}
+
+	for _, tt := range tests {
+		channelToMessage, chatToMessage := routeResults(routes, subscriptions, msg, tt.defaultGetsAll)
+
+		if got := tickersOf(channelToMessage[DefaultChannel]); !slices.Equal(got, tt.defaultTickers) {
+			t.Errorf("%s: default channel got %v, want %v", tt.name, got, tt.defaultTickers)
+		}
+
+		if failures := channelToMessage[DefaultChannel].Failures; len(failures) != tt.defaultFailures {
+			t.Errorf("%s: default channel got %d failures, want %d", tt.name, len(failures), tt.defaultFailures)
+		}
+
+		if got := tickersOf(channelToMessage["discord"]); !slices.Equal(got, []string{"BTC"}) {
+			t.Errorf("%s: discord got %v, want [BTC]", tt.name, got)
+		}
+
+		if got := tickersOf(chatToMessage[42]); !slices.Equal(got, []string{"AAPL", "BTC"}) {
+			t.Errorf("%s: subscriber got %v, want [AAPL BTC]", tt.name, got)
+		}
+
+		if failures := chatToMessage[42].Failures; len(failures) != 1 {
+			t.Errorf("%s: subscriber got %d failures, want AAPL's", tt.name, len(failures))
+		}
+	}
+}
+
+func tickersOf(msg *Message) []string {
+	var tickers []string
+	if msg == nil {
+		return nil
+	}
+	for _, result := range msg.Results {
+		tickers = append(tickers, result.TickerSymbol)
+	}
+	return tickers
+}
@@ -0,0 +1,18 @@
+package notifier
+
+import "encoding/json"
+
+type UpsertChannelReq struct {
+	Name    string          `json:"name"`
+	Kind    string          `json:"kind"`
+	Target  string          `json:"target"`  // chat id for telegram, comma separated recipients for email, webhook URL otherwise
+	Secret  string          `json:"secret"`  // required for webhook
+	Options json.RawMessage `json:"options"` // kind specific, see TelegramOptions and EmailOptions
+}
+
+type RouteReq struct {
+	TickerSymbol string `json:"tickerSymbol"`
+	Timeframe    string `json:"timeframe"` // empty matches every timeframe
+	Strategy     string `json:"strategy"`  // empty matches every strategy
+	Channel      string `json:"channel"`
+}
@@ -0,0 +1,71 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SlackNotifier posts to a Slack incoming webhook, formatted as Slack mrkdwn.
+type SlackNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewSlackNotifier(url string, httpClient *http.Client) *SlackNotifier {
+	return &SlackNotifier{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+func (sn *SlackNotifier) Kind() string {
+	return SlackKind
+}
+
+func (sn *SlackNotifier) Notify(ctx context.Context, msg *Message) error {
+	text := formatSlackMrkdwn(msg)
+	if text == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, sn.httpClient, sn.url, nil, body)
+}
+
+// formatSlackMrkdwn escapes &, < and >, which Slack reads as control sequences in message text.
+func formatSlackMrkdwn(msg *Message) string {
+	tickers, tickerToResults := groupByTicker(msg.Results)
+	if len(tickers) == 0 && len(msg.Failures) == 0 {
+		return ""
+	}
+
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("*%s*\n", escape(msg.Timeframe)))
+
+	for _, ticker := range tickers {
+		builder.WriteString(fmt.Sprintf("*%s*\n", escape(ticker)))
+		for _, result := range tickerToResults[ticker] {
+			builder.WriteString(fmt.Sprintf("`%s %s: %s`\n", resultLogo(&result), escape(result.Strategy), escape(result.Message)))
+		}
+		builder.WriteString("\n")
+	}
+
+	if len(msg.Failures) > 0 {
+		builder.WriteString("*⚠️ Failed tickers*\n")
+		for _, failure := range sortFailures(msg.Failures) {
+			builder.WriteString(fmt.Sprintf("`%s (%s): %s`\n",
+				escape(failure.TickerSymbol), failure.Category, escape(failure.Error)))
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,245 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/signalb/internal/chart"
+	"github.com/signalb/internal/telegram"
+)
+
+const (
+	// telegramMessageLimit is the most characters Telegram accepts in one message
+	telegramMessageLimit = 4096
+	// telegramCaptionLimit is the most characters Telegram accepts in a photo caption
+	telegramCaptionLimit = 1024
+)
+
+// TelegramOptions are the settings of a Telegram channel.
+type TelegramOptions struct {
+	// FulfilledOnly leaves out the strategies that weren't fulfilled, and tickers left with none
+	FulfilledOnly bool `json:"fulfilledOnly"`
+	// Charts sends the chart of every ticker with a fulfilled strategy after the results, on unless turned off
+	Charts bool `json:"charts"`
+}
+
+func defaultTelegramOptions() *TelegramOptions {
+	return &TelegramOptions{Charts: true}
+}
+
+func parseTelegramOptions(raw string) (*TelegramOptions, error) {
+	options := defaultTelegramOptions()
+	if raw == "" {
+		return options, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), options); err != nil {
+		return nil, fmt.Errorf("invalid telegram options: %w", err)
+	}
+
+	return options, nil
+}
+
+type TelegramNotifier struct {
+	bot     *telegram.BotClient
+	chatID  int64
+	options *TelegramOptions
+}
+
+func NewTelegramNotifier(bot *telegram.BotClient, chatID int64, options *TelegramOptions) *TelegramNotifier {
+	return &TelegramNotifier{
+		bot:     bot,
+		chatID:  chatID,
+		options: options,
+	}
+}
+
+func (tn *TelegramNotifier) Kind() string {
+	return TelegramKind
+}
+
+// Notify sends the results in as many messages as it takes to stay under Telegram's limit, then the
+// charts of the signals.
+func (tn *TelegramNotifier) Notify(ctx context.Context, msg *Message) error {
+	if tn.options.FulfilledOnly {
+		msg = fulfilledOnly(msg)
+	}
+
+	for i, chunk := range splitTelegramHTML(msg, telegramMessageLimit) {
+		if err := tn.bot.SendMessageByHTML(tn.chatID, chunk); err != nil {
+			return fmt.Errorf("message %d: %w", i+1, err)
+		}
+	}
+
+	if !tn.options.Charts || msg.Timeframe == LiveTimeframe {
+		return nil
+	}
+
+	return tn.sendCharts(ctx, msg)
+}
+
+// sendCharts draws each ticker with the overlays of its fulfilled strategies. A chart that can't be
+// drawn, e.g. for lack of prices, is only logged, the results already went out.
+func (tn *TelegramNotifier) sendCharts(ctx context.Context, msg *Message) error {
+	tickers, tickerToResults := groupByTicker(fulfilledOnly(msg).Results)
+
+	for _, ticker := range tickers {
+		results := tickerToResults[ticker]
+
+		strategies := make([]string, 0, len(results))
+		for _, result := range results {
+			strategies = append(strategies, result.Strategy)
+		}
+
+		spec, err := chart.LoadSignalChart(ctx, ticker, msg.Timeframe, strategies, "", chart.DefaultCandles)
+		if err != nil {
+			log.Printf("error loading chart of %s %s: %v", ticker, msg.Timeframe, err)
+			continue
+		}
+
+		img, err := chart.Render(spec)
+		if err != nil {
+			log.Printf("error rendering chart of %s %s: %v", ticker, msg.Timeframe, err)
+			continue
+		}
+
+		caption := formatTelegramCaption(ticker, msg.Timeframe, results)
+		if err := tn.bot.SendPhotoByHTML(tn.chatID, img, caption); err != nil {
+			return fmt.Errorf("chart of %s: %w", ticker, err)
+		}
+	}
+
+	return nil
+}
+
+// formatTelegramCaption lists the signals under the chart, leaving out the ones past the caption limit.
+func formatTelegramCaption(ticker, timeframe string, results []Result) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("<b>%s</b> %s\n", ticker, timeframe))
+
+	for _, result := range results {
+		line := fmt.Sprintf("<code>%s %s: %s</code>\n", resultLogo(&result), result.Strategy, result.Message)
+		if telegramLength(builder.String())+telegramLength(line) > telegramCaptionLimit {
+			break
+		}
+		builder.WriteString(line)
+	}
+
+	return builder.String()
+}
+
+// fulfilledOnly keeps the failures, a ticker that couldn't be evaluated may have had a signal.
+func fulfilledOnly(msg *Message) *Message {
+	filtered := &Message{Timeframe: msg.Timeframe, Failures: msg.Failures}
+	for _, result := range msg.Results {
+		if result.IsFulfilled {
+			filtered.Results = append(filtered.Results, result)
+		}
+	}
+
+	return filtered
+}
+
+func formatTelegramHTML(msg *Message) string {
+	title, blocks := telegramHTMLBlocks(msg)
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	return title + strings.Join(blocks, "")
+}
+
+// telegramHTMLBlocks returns the timeframe title, one block per ticker and a last block of the failed
+// tickers, each block a list of lines that open and close their own tags.
+func telegramHTMLBlocks(msg *Message) (string, []string) {
+	tickers, tickerToResults := groupByTicker(msg.Results)
+
+	blocks := make([]string, 0, len(tickers))
+	for _, ticker := range tickers {
+		var blockBuilder strings.Builder
+		// Ticker
+		blockBuilder.WriteString(fmt.Sprintf("<b>%s</b>\n", ticker))
+		for _, result := range tickerToResults[ticker] {
+			blockBuilder.WriteString(
+				fmt.Sprintf("<code>%s %s: %s</code>\n",
+					resultLogo(&result),
+					result.Strategy,
+					result.Message,
+				),
+			)
+		}
+		blockBuilder.WriteString("\n")
+
+		blocks = append(blocks, blockBuilder.String())
+	}
+
+	if len(msg.Failures) > 0 {
+		var blockBuilder strings.Builder
+		blockBuilder.WriteString("<b>⚠️ Failed tickers</b>\n")
+		for _, failure := range sortFailures(msg.Failures) {
+			blockBuilder.WriteString(
+				fmt.Sprintf("<code>%s (%s): %s</code>\n",
+					html.EscapeString(failure.TickerSymbol),
+					failure.Category,
+					html.EscapeString(failure.Error),
+				),
+			)
+		}
+		blockBuilder.WriteString("\n")
+
+		blocks = append(blocks, blockBuilder.String())
+	}
+
+	title := fmt.Sprintf("<b><u>%s</u></b>\n", msg.Timeframe)
+	return title, blocks
+}
+
+// splitTelegramHTML packs the ticker blocks into messages of at most limit UTF-16 code units, cutting lines
+// between their tags so no tag is ever cut.
+func splitTelegramHTML(msg *Message, limit int) []string {
+	title, blocks := telegramHTMLBlocks(msg)
+	return splitBlocks(title, blocks, limit, telegramLength, truncateTelegramLine)
+}
+
+// truncateTelegramLine shortens the text of a single line that can't fit in a message on its own. Lines
+// are "<tag>text</tag>\n", the text is cut before the closing tag.
+func truncateTelegramLine(line string, limit int) string {
+	if telegramLength(line) <= limit {
+		return line
+	}
+
+	closeStart := strings.LastIndex(line, "</")
+	if closeStart < 0 {
+		closeStart = len(line)
+	}
+	closing := line[closeStart:]
+
+	budget := limit - telegramLength(closing) - 1
+	end := 0
+	for i, r := range line[:closeStart] {
+		budget -= len(utf16.Encode([]rune{r}))
+		if budget < 0 {
+			break
+		}
+		end = i + utf8.RuneLen(r)
+	}
+
+	// Don't leave a cut entity behind
+	truncated := line[:end]
+	if amp := strings.LastIndex(truncated, "&"); amp >= 0 && !strings.Contains(truncated[amp:], ";") {
+		truncated = truncated[:amp]
+	}
+
+	return truncated + "…" + closing
+}
+
+// telegramLength counts like Telegram does, in UTF-16 code units.
+func telegramLength(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
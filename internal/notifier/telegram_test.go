@@ -0,0 +1,110 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitTelegramHTMLPacksWholeBlocks(t *testing.T) {
+	msg := resultsMessage(60, 2, strings.Repeat("x", 40))
+	msg.Failures = []Failure{{TickerSymbol: "<BAD>", Timeframe: "D1", Category: "provider", Error: "a & b"}}
+
+	chunks := splitTelegramHTML(msg, telegramMessageLimit)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d messages, want the results split", len(chunks))
+	}
+
+	title, blocks := telegramHTMLBlocks(msg)
+	var joined strings.Builder
+	for i, chunk := range chunks {
+		if n := telegramLength(chunk); n > telegramMessageLimit {
+			t.Errorf("message %d has %d code units", i+1, n)
+		}
+		if !strings.HasPrefix(chunk, title) {
+			t.Errorf("message %d doesn't start with the title", i+1)
+		}
+		joined.WriteString(strings.TrimPrefix(chunk, title))
+	}
+
+	if got, want := joined.String(), strings.Join(blocks, ""); got != want {
+		t.Errorf("messages without their titles = %q, want the blocks %q", got, want)
+	}
+
+	if last := chunks[len(chunks)-1]; !strings.Contains(last, "&lt;BAD&gt; (provider): a &amp; b") {
+		t.Errorf("last message %q, want the escaped failure", last)
+	}
+}
+
+func TestSplitTelegramHTMLSplitsOversizedBlockBetweenLines(t *testing.T) {
+	msg := resultsMessage(1, 60, strings.Repeat("y", 80))
+
+	chunks := splitTelegramHTML(msg, telegramMessageLimit)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d messages, want the block split", len(chunks))
+	}
+
+	lines := 0
+	for i, chunk := range chunks {
+		if n := telegramLength(chunk); n > telegramMessageLimit {
+			t.Errorf("message %d has %d code units", i+1, n)
+		}
+		if !strings.HasPrefix(chunk, "<b><u>D1</u></b>\n<b>T00</b>\n") {
+			t.Errorf("message %d doesn't repeat the ticker line: %q", i+1, chunk[:min(len(chunk), 40)])
+		}
+		// No tag is cut, every line opens and closes its code tag
+		if open, closed := strings.Count(chunk, "<code>"), strings.Count(chunk, "</code>"); open != closed {
+			t.Errorf("message %d opens %d code tags and closes %d", i+1, open, closed)
+		}
+		lines += strings.Count(chunk, "</code>\n")
+	}
+
+	if lines != 60 {
+		t.Errorf("messages have %d result lines, want all 60", lines)
+	}
+}
+
+func TestSplitTelegramHTMLTruncatesLineLongerThanTheLimit(t *testing.T) {
+	// Emoji outside the BMP are two UTF-16 code units each
+	msg := resultsMessage(1, 1, strings.Repeat("📈", 3000))
+
+	chunks := splitTelegramHTML(msg, telegramMessageLimit)
+	if len(chunks) != 1 {
+		t.Fatalf("got %d messages, want 1", len(chunks))
+	}
+
+	chunk := chunks[0]
+	if n := telegramLength(chunk); n > telegramMessageLimit || n < telegramMessageLimit-1 {
+		t.Errorf("message has %d code units, want it filled up to %d", n, telegramMessageLimit)
+	}
+	if !utf8.ValidString(chunk) || !strings.HasSuffix(strings.TrimSuffix(chunk, "\n"), "📈…</code>\n") {
+		t.Errorf("message ends with %q, want the emoji cut whole before the closing tag", chunk[len(chunk)-20:])
+	}
+}
+
+func TestTruncateTelegramLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		limit int
+		want  string
+	}{
+		{"fitting", "<code>short</code>\n", 20, "<code>short</code>\n"},
+		{"cut before the closing tag", "<code>abcdefgh</code>\n", 18, "<code>abc…</code>\n"},
+		{"multibyte", "<code>äöüäöü</code>\n", 18, "<code>äöü…</code>\n"},
+		// 📈 takes two code units, only one of them fits
+		{"emoji", "<code>📈📈📈</code>\n", 17, "<code>📈…</code>\n"},
+		{"entity", "<code>a &amp; b</code>\n", 19, "<code>a …</code>\n"},
+		{"no tag", "plain text\n", 6, "plain…"},
+	}
+
+	for _, tt := range tests {
+		got := truncateTelegramLine(tt.line, tt.limit)
+		if got != tt.want {
+			t.Errorf("%s: truncateTelegramLine(%q, %d) = %q, want %q", tt.name, tt.line, tt.limit, got, tt.want)
+		}
+		if telegramLength(got) > tt.limit && got != tt.line {
+			t.Errorf("%s: %q is longer than %d", tt.name, got, tt.limit)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader carries sha256=<hex HMAC-SHA256 of "<timestamp>.<body>"> keyed with the channel secret
+	SignatureHeader = "X-Signal-Signature"
+	// TimestampHeader carries the unix seconds the payload was signed at, receivers should reject stale ones
+	TimestampHeader = "X-Signal-Timestamp"
+)
+
+// WebhookNotifier posts the results as JSON to any endpoint, signed so the receiver can verify the sender.
+type WebhookNotifier struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+func NewWebhookNotifier(url, secret string, httpClient *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		secret:     secret,
+		httpClient: httpClient,
+	}
+}
+
+func (wn *WebhookNotifier) Kind() string {
+	return WebhookKind
+}
+
+type webhookPayload struct {
+	Timeframe string    `json:"timeframe"`
+	Results   []Result  `json:"results"`
+	Failures  []Failure `json:"failures,omitempty"`
+	SentAt    int64     `json:"sentAt"`
+}
+
+func (wn *WebhookNotifier) Notify(ctx context.Context, msg *Message) error {
+	if len(msg.Results) == 0 && len(msg.Failures) == 0 {
+		return nil
+	}
+
+	now := time.Now().Unix()
+
+	body, err := json.Marshal(&webhookPayload{
+		Timeframe: msg.Timeframe,
+		Results:   msg.Results,
+		Failures:  msg.Failures,
+		SentAt:    now,
+	})
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(now, 10)
+	headers := map[string]string{
+		TimestampHeader: timestamp,
+		SignatureHeader: "sha256=" + Sign(wn.secret, timestamp, body),
+	}
+
+	return postJSON(ctx, wn.httpClient, wn.url, headers, body)
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>", what receivers recompute to verify a payload.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func postJSON(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("status %d: %s", res.StatusCode, resBody)
+	}
+
+	return nil
+}
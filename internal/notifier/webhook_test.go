@@ -0,0 +1,67 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		// HMAC-SHA256 of `1700000000.{"timeframe":"D1"}` keyed with "secret"
+		{"known payload", "secret", "1700000000", `{"timeframe":"D1"}`,
+			"50874421995f12f19b66895774bbea2838493fdbb8289f1ab7eb2dcd10711474"},
+	}
+
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("%s: Sign = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	base := Sign("secret", "1700000000", []byte("{}"))
+	if Sign("other", "1700000000", []byte("{}")) == base {
+		t.Error("the signature doesn't depend on the secret")
+	}
+	if Sign("secret", "1700000001", []byte("{}")) == base {
+		t.Error("the signature doesn't depend on the timestamp")
+	}
+}
+
+func TestWebhookNotifySignsTheBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read body: %v", err)
+		}
+
+		// What a receiver does to verify the sender
+		want := "sha256=" + Sign("secret", r.Header.Get(TimestampHeader), body)
+		if got := r.Header.Get(SignatureHeader); got != want {
+			t.Errorf("signature %q, want %q", got, want)
+		}
+
+		var payload webhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if payload.Timeframe != "D1" || len(payload.Results) != 1 {
+			t.Errorf("payload %+v, want the D1 result", payload)
+		}
+	}))
+	defer server.Close()
+
+	msg := resultsMessage(1, 1, "RSI 25.00")
+	if err := NewWebhookNotifier(server.URL, "secret", server.Client()).Notify(context.Background(), msg); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/errors"
+	"github.com/signalb/internal/timeframe"
+)
+
+func SetRetentionPolicyController(c *gin.Context) {
+	var req SetRetentionPolicyReq
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
+		return
+	}
+
+	if !slices.Contains(timeframe.AllowedTimeframes, req.Timeframe) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return
+	}
+
+	if !slices.Contains(AllowedModes, req.Mode) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid modes: %v", AllowedModes)))
+		return
+	}
+
+	if req.Mode != KeepForever && req.Value < 1 {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("value must be at least 1 for mode %s", req.Mode)))
+		return
+	}
+
+	if req.TickerSymbol != "" && !isTickerRegistered(c.Request.Context(), req.TickerSymbol) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s is not registered", req.TickerSymbol)))
+		return
+	}
+
+	policy := &database.RetentionPolicy{
+		TickerSymbol: req.TickerSymbol,
+		Timeframe:    req.Timeframe,
+		Mode:         req.Mode,
+		Value:        req.Value,
+		Archive:      req.Archive,
+	}
+
+	if err := upsertPolicy(c.Request.Context(), policy); err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseInsertionError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("retention policy %+v set successfully", req),
+	})
+}
+
+func isTickerRegistered(c context.Context, tickerSymbol string) bool {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.IsTickerRegistered(ctx, tickerSymbol)
+}
+
+func upsertPolicy(c context.Context, policy *database.RetentionPolicy) error {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.UpsertRetentionPolicy(ctx, policy)
+}
+
+func GetRetentionPoliciesController(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	policies, err := database.Client.GetRetentionPolicies(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"default":  DefaultPolicy("", ""),
+	})
+}
@@ -0,0 +1,9 @@
+package retention
+
+type SetRetentionPolicyReq struct {
+	TickerSymbol string `json:"tickerSymbol"` // empty applies to the whole timeframe
+	Timeframe    string `json:"timeframe"`
+	Mode         string `json:"mode"`
+	Value        int    `json:"value"`
+	Archive      bool   `json:"archive"`
+}
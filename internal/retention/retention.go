@@ -0,0 +1,81 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/database"
+)
+
+const (
+	KeepCandles = "keep_candles"
+	KeepDays    = "keep_days"
+	KeepForever = "keep_forever"
+)
+
+var AllowedModes = []string{KeepCandles, KeepDays, KeepForever}
+
+// DefaultPolicy applies when neither the ticker nor its timeframe has a policy. It keeps everything, so
+// imported history is only ever trimmed by a policy someone set.
+func DefaultPolicy(tickerSymbol, timeframe string) *database.RetentionPolicy {
+	return &database.RetentionPolicy{
+		TickerSymbol: tickerSymbol,
+		Timeframe:    timeframe,
+		Mode:         KeepForever,
+		Archive:      false,
+	}
+}
+
+func GetPolicy(ctx context.Context, tickerSymbol, timeframe string) (*database.RetentionPolicy, error) {
+	policy, err := database.Client.GetRetentionPolicy(ctx, tickerSymbol, timeframe)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultPolicy(tickerSymbol, timeframe), nil
+	}
+
+	return policy, err
+}
+
+// Apply trims the ticker's stored candles in the timeframe down to what its policy keeps, returning
+// how many candles were removed (and archived, if the policy says so).
+func Apply(c context.Context, tickerSymbol, class, timeframe string) (int64, error) {
+	return apply(c, tickerSymbol, class, timeframe, time.Now())
+}
+
+func apply(c context.Context, tickerSymbol, class, timeframe string, now time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(c, 10*time.Second)
+	defer cancel()
+
+	policy, err := GetPolicy(ctx, tickerSymbol, timeframe)
+	if err != nil {
+		return 0, err
+	}
+
+	switch policy.Mode {
+	case KeepForever:
+		return 0, nil
+	case KeepCandles:
+		return database.Client.TrimPriceData(ctx, tickerSymbol, timeframe, policy.Value, nil, policy.Archive)
+	case KeepDays:
+		cutoff := keepDaysCutoff(class, policy.Value, now)
+		return database.Client.TrimPriceData(ctx, tickerSymbol, timeframe, 0, &cutoff, policy.Archive)
+	default:
+		return 0, fmt.Errorf("unknown retention mode %q for %s %s", policy.Mode, tickerSymbol, timeframe)
+	}
+}
+
+// keepDaysCutoff is the stored time the candles before are older than days. Stored times are wall clock
+// times in the market's location, so the days are counted there, in UTC for classes without a calendar.
+func keepDaysCutoff(class string, days int, now time.Time) time.Time {
+	now = now.UTC()
+	if cal, ok := calendar.CalendarManager.GetCalendarByClass(class); ok {
+		now = now.In(cal.Location())
+	}
+
+	wallClock := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC)
+
+	return wallClock.AddDate(0, 0, -days)
+}
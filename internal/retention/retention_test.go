@@ -0,0 +1,116 @@
+package retention
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/calendar"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+	"github.com/signalb/internal/ticker"
+)
+
+func useUSEquityCalendar(t *testing.T) {
+	t.Helper()
+
+	usEquity, err := calendar.NewUSEquityCalendar()
+	if err != nil {
+		t.Fatalf("load calendar: %v", err)
+	}
+
+	prev := calendar.CalendarManager
+	calendar.CalendarManager = calendar.NewCalendarManager(map[string]calendar.Calendar{ticker.StockClass: usEquity})
+	t.Cleanup(func() { calendar.CalendarManager = prev })
+}
+
+// useDailyPrices stores daily candles of the ticker from 2024-05-01 on.
+func useDailyPrices(t *testing.T, tickerSymbol string, days int) *dbtest.DB {
+	t.Helper()
+
+	data := make([]database.PriceData, 0, days)
+	for i := 0; i < days; i++ {
+		data = append(data, database.PriceData{TickerSymbol: tickerSymbol,
+			Time: time.Date(2024, 5, 1+i, 0, 0, 0, 0, time.UTC), Price: float64(i + 1)})
+	}
+
+	db := dbtest.Use(t)
+	db.SetPrices(tickerSymbol, "D1", data)
+
+	return db
+}
+
+func daysOf(data []database.PriceData) []int {
+	days := make([]int, 0, len(data))
+	for _, d := range data {
+		days = append(days, d.Time.Day())
+	}
+	return days
+}
+
+func TestApplyKeepsEverythingWithoutPolicy(t *testing.T) {
+	useUSEquityCalendar(t)
+	db := useDailyPrices(t, "AAPL", 400)
+
+	trimmed, err := Apply(context.Background(), "AAPL", ticker.StockClass, "D1")
+	if err != nil || trimmed != 0 || len(db.Prices("AAPL", "D1")) != 400 {
+		t.Errorf("trimmed %d with error %v, want all 400 candles kept", trimmed, err)
+	}
+}
+
+func TestApplyKeepCandles(t *testing.T) {
+	useUSEquityCalendar(t)
+	db := useDailyPrices(t, "AAPL", 9)
+	db.SetRetentionPolicies(
+		database.RetentionPolicy{Timeframe: "D1", Mode: KeepCandles, Value: 5},
+		database.RetentionPolicy{TickerSymbol: "AAPL", Timeframe: "D1", Mode: KeepCandles, Value: 3, Archive: true},
+	)
+
+	// The ticker's own policy wins over the timeframe's
+	trimmed, err := Apply(context.Background(), "AAPL", ticker.StockClass, "D1")
+	if err != nil || trimmed != 6 {
+		t.Fatalf("trimmed %d with error %v, want 6", trimmed, err)
+	}
+
+	if kept := daysOf(db.Prices("AAPL", "D1")); !slices.Equal(kept, []int{7, 8, 9}) {
+		t.Errorf("kept days %v, want [7 8 9]", kept)
+	}
+
+	if archived := db.Archived("AAPL", "D1"); len(archived) != 6 {
+		t.Errorf("archived %d candles, want the 6 trimmed", len(archived))
+	}
+}
+
+func TestApplyKeepDaysCountsInTheMarketZone(t *testing.T) {
+	useUSEquityCalendar(t)
+
+	// 02:00 UTC on the 10th is still 22:00 on the 9th in New York
+	now := time.Date(2024, 5, 10, 2, 0, 0, 0, time.UTC)
+	policy := database.RetentionPolicy{Timeframe: "D1", Mode: KeepDays, Value: 3}
+
+	tests := []struct {
+		name         string
+		tickerSymbol string
+		class        string
+		kept         []int
+	}{
+		// From 22:00 on the 6th on, wall clock in New York
+		{"stock", "AAPL", ticker.StockClass, []int{7, 8, 9}},
+		// From 02:00 on the 7th on, UTC
+		{"crypto", "BTC", ticker.CryptoClass, []int{8, 9}},
+	}
+
+	for _, tt := range tests {
+		db := useDailyPrices(t, tt.tickerSymbol, 9)
+		db.SetRetentionPolicies(policy)
+
+		if _, err := apply(context.Background(), tt.tickerSymbol, tt.class, "D1", now); err != nil {
+			t.Fatalf("%s: apply: %v", tt.name, err)
+		}
+
+		if kept := daysOf(db.Prices(tt.tickerSymbol, "D1")); !slices.Equal(kept, tt.kept) {
+			t.Errorf("%s: kept days %v, want %v", tt.name, kept, tt.kept)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/signalb/internal/chart"
+	"github.com/signalb/internal/indicators"
+)
+
+const (
+	// bandTouchZone is how close to a band, in %B, the price counts as touching it
+	bandTouchZone = 0.05
+	// squeezeLookback is how many candles a band width is compared against to call it a squeeze
+	squeezeLookback = 120
+	// squeezeTolerance is how far above the narrowest width of the lookback, in percent, is still a squeeze
+	squeezeTolerance float64 = 5
+	// breakoutWindow is how many candles after a squeeze a close outside the bands counts as its breakout
+	breakoutWindow = 5
+)
+
+// Bollinger fires on the lower band for Buy and the upper band for Sell, when the price touches it or
+// closes outside of it. Notify fires on a squeeze, the bands being about their narrowest of recent history.
+type Bollinger struct {
+	Length     int
+	Multiplier float64
+	Strength   Strength
+	Type       Type
+}
+
+func NewBollinger(length int, multiplier float64, strength Strength, typ Type) *Bollinger {
+	return &Bollinger{
+		Length:     length,
+		Multiplier: multiplier,
+		Strength:   strength,
+		Type:       typ,
+	}
+}
+
+// GetName is bb20buy, bb20sell or bb20squeeze, a multiplier other than 2 is part of it like bb20x2.5buy.
+func (s *Bollinger) GetName() string {
+	name := fmt.Sprintf("bb%d", s.Length)
+	if s.Multiplier != 2 {
+		name += fmt.Sprintf("x%g", s.Multiplier)
+	}
+
+	if s.Type == Notify {
+		return name + "squeeze"
+	}
+
+	return name + strings.ToLower(string(s.Type))
+}
+
+func (s *Bollinger) GetOverlays() chart.Overlays {
+	return chart.Overlays{Bands: []chart.BollingerBands{{Length: s.Length, Multiplier: s.Multiplier}}}
+}
+
+func (s *Bollinger) GetWhitelistedTickerSymbols() []string {
+	return nil
+}
+
+func (s *Bollinger) Evaluate(data []float64) *EvaluationResult {
+	if len(data) < s.Length {
+		return NewEvaluationResult(false, fmt.Sprintf("lack %d data", s.Length))
+	}
+
+	bands := indicators.Bollinger(data, s.Length, s.Multiplier)
+	widths := bandWidths(bands)
+
+	last := len(data) - 1
+	price, upper, lower := data[last], bands.Upper[last], bands.Lower[last]
+
+	if s.Type == Notify {
+		isSqueezed := isSqueezed(widths, last)
+		return NewEvaluationResult(isSqueezed, s.getSqueezeMessage(widths[last], isSqueezed))
+	}
+
+	// %B is 0 on the lower band and 1 on the upper one, flat prices sit in the middle
+	percentB := 0.5
+	if upper > lower {
+		percentB = (price - lower) / (upper - lower)
+	}
+
+	var isTouched, isOutside bool
+	switch s.Type {
+	case Buy:
+		isTouched, isOutside = percentB <= bandTouchZone, percentB < 0
+	case Sell:
+		isTouched, isOutside = percentB >= 1-bandTouchZone, percentB > 1
+	}
+
+	isBreakout := false
+	if isOutside {
+		for i := max(last-breakoutWindow, 0); i < last; i++ {
+			isBreakout = isBreakout || isSqueezed(widths, i)
+		}
+	}
+
+	return NewEvaluationResult(isTouched, s.getEvaluationMessage(price, upper, lower, isTouched, isOutside, isBreakout))
+}
+
+func (s *Bollinger) getEvaluationMessage(price, upper, lower float64, isTouched, isOutside, isBreakout bool) string {
+	if !isTouched {
+		return fmt.Sprintf("Price %s within %s bands(%s - %s)",
+			formatPrice(price), s.GetName(), formatPrice(lower), formatPrice(upper))
+	}
+
+	band, side, level := "lower", "below", lower
+	if s.Type == Sell {
+		band, side, level = "upper", "above", upper
+	}
+
+	switch {
+	case isBreakout:
+		return fmt.Sprintf("%s %s! Price %s broke out %s the %s band(%s) of %s after a squeeze",
+			s.Strength, s.Type, formatPrice(price), side, band, formatPrice(level), s.GetName())
+	case isOutside:
+		return fmt.Sprintf("%s %s! Price %s closed %s the %s band(%s) of %s",
+			s.Strength, s.Type, formatPrice(price), side, band, formatPrice(level), s.GetName())
+	default:
+		return fmt.Sprintf("%s %s! Price %s touched the %s band(%s) of %s",
+			s.Strength, s.Type, formatPrice(price), band, formatPrice(level), s.GetName())
+	}
+}
+
+func (s *Bollinger) getSqueezeMessage(width float64, isSqueezed bool) string {
+	if !isSqueezed {
+		return fmt.Sprintf("Band width of %0.2f%% not at %s levels", width*100, s.GetName())
+	}
+
+	return fmt.Sprintf("%s squeeze! Band width of %0.2f%% is about the narrowest in %d candles",
+		s.Strength, width*100, squeezeLookback)
+}
+
+// bandWidths are the distance between the bands relative to the middle one, so they compare across prices.
+func bandWidths(bands *indicators.BollingerSeries) []float64 {
+	widths := make([]float64, len(bands.Middle))
+	for i := range widths {
+		widths[i] = (bands.Upper[i] - bands.Lower[i]) / bands.Middle[i]
+	}
+
+	return widths
+}
+
+// isSqueezed reports whether the width at i is within squeezeTolerance of the narrowest of the lookback
+// before it. Without a lookback worth of widths nothing is a squeeze.
+func isSqueezed(widths []float64, i int) bool {
+	start := i - squeezeLookback + 1
+	if start < 0 || math.IsNaN(widths[start]) || math.IsNaN(widths[i]) {
+		return false
+	}
+
+	narrowest := widths[i]
+	for _, width := range widths[start:i] {
+		narrowest = math.Min(narrowest, width)
+	}
+
+	return widths[i] <= narrowest*(100+squeezeTolerance)/100
+}
@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+)
+
+// alternating is n prices going back and forth between a and b, starting with a.
+func alternating(a, b float64, n int) []float64 {
+	prices := make([]float64, n)
+	for i := range prices {
+		prices[i] = a
+		if i%2 == 1 {
+			prices[i] = b
+		}
+	}
+	return prices
+}
+
+// swinging is n prices around 100 whose swings go from the first amplitude to the last one.
+func swinging(n int, from, to float64) []float64 {
+	prices := make([]float64, n)
+	for i := range prices {
+		amplitude := from + (to-from)*float64(i)/float64(n-1)
+		if i%2 == 1 {
+			amplitude = -amplitude
+		}
+		prices[i] = 100 + amplitude
+	}
+	return prices
+}
+
+func TestBollingerBands(t *testing.T) {
+	buy, sell := NewBollinger(20, 2, Strong, Buy), NewBollinger(20, 2, Strong, Sell)
+
+	// 19 prices of 10 and 12 put the bands at about 9 and 13 once the last price joins
+	lowSide, highSide := alternating(12, 10, 19), alternating(10, 12, 19)
+
+	tests := []struct {
+		name      string
+		strategy  *Bollinger
+		data      []float64
+		fulfilled bool
+		message   string
+	}{
+		{"lacking data", buy, alternating(10, 12, 19), false, "lack 20 data"},
+		{"touching the lower band", buy, append(lowSide, 9), true, "touched the lower band"},
+		{"closing below the lower band", buy, append(lowSide, 8), true, "closed below the lower band"},
+		{"in the middle", buy, append(lowSide, 11), false, "within"},
+		{"touching the lower band for Sell", sell, append(lowSide, 9), false, "within"},
+		{"touching the upper band", sell, append(highSide, 13), true, "touched the upper band"},
+		{"closing above the upper band", sell, append(highSide, 14), true, "closed above the upper band"},
+		{"flat prices", buy, alternating(10, 10, 30), false, "within"},
+		{"breaking out up after a squeeze", sell, append(swinging(160, 10, 0.5), 110), true, "broke out above"},
+		{"breaking out down after a squeeze", buy, append(swinging(160, 10, 0.5), 90), true, "broke out below"},
+		// Widening swings are no squeeze to break out of
+		{"closing outside without a squeeze", sell, append(swinging(160, 0.5, 1), 110), true, "closed above"},
+	}
+
+	for _, tt := range tests {
+		res := tt.strategy.Evaluate(tt.data)
+		if res.IsFulfilled != tt.fulfilled || !strings.Contains(res.EvaluationMessage, tt.message) {
+			t.Errorf("%s: fulfilled %t with %q, want %t with %q",
+				tt.name, res.IsFulfilled, res.EvaluationMessage, tt.fulfilled, tt.message)
+		}
+	}
+}
+
+func TestBollingerSqueeze(t *testing.T) {
+	squeeze := NewBollinger(20, 2, Key, Notify)
+
+	tests := []struct {
+		name      string
+		data      []float64
+		fulfilled bool
+	}{
+		{"narrowing swings", swinging(160, 10, 0.5), true},
+		{"widening swings", swinging(160, 0.5, 10), false},
+		// The first widths come after 19 candles, a squeeze needs 120 of them
+		{"shorter than the lookback", swinging(138, 10, 0.5), false},
+		{"just the lookback", swinging(139, 10, 0.5), true},
+	}
+
+	for _, tt := range tests {
+		if res := squeeze.Evaluate(tt.data); res.IsFulfilled != tt.fulfilled {
+			t.Errorf("%s: fulfilled %t with %q, want %t", tt.name, res.IsFulfilled, res.EvaluationMessage, tt.fulfilled)
+		}
+	}
+}
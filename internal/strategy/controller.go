@@ -1,14 +1,15 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"slices"
-	"strings"
+	"sort"
 
 	"github.com/gin-gonic/gin"
 	"github.com/signalb/internal/errors"
-	"github.com/signalb/internal/telegram"
+	"github.com/signalb/internal/notifier"
 	"github.com/signalb/internal/timeframe"
 )
 
@@ -18,65 +19,160 @@ func GetStrategiesController(c *gin.Context) {
 	})
 }
 
+// EvaluateTickerStrategiesByTimeframeController only evaluates, ?ticker and ?strategy narrow it down.
+// Nothing is sent, see NotifyTickerStrategiesByTimeframeController for that. Tickers that failed are
+// listed next to the results with 207, or 500 when none could be evaluated.
 func EvaluateTickerStrategiesByTimeframeController(c *gin.Context) {
-	tf := c.Param("timeframe")
+	var req EvaluateReq
 
-	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest,
-			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
 		return
 	}
 
-	res, err := evaluateTickersStrategiesByTimeframe(c.Request.Context(), tf)
+	tf, ok := validateEvaluateReq(c, &req)
+	if !ok {
+		return
+	}
+
+	res, failures, err := evaluateTickersStrategiesByTimeframe(c.Request.Context(), tf, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError,
 			errors.NewErrorResp(fmt.Errorf("error evaluating strategies for each ticker in the given timeframe: %w", err)))
 		return
 	}
 
-	formattedOutput := formatTickersStrategiesOutput(tf, res)
-	err = telegram.Bot.SendMessageByHTML(telegram.Bot.DefaultChatID, formattedOutput)
+	c.JSON(errors.BatchStatus(len(res), len(failures)), gin.H{
+		"timeframe": tf,
+		"results":   newEvaluationResps(tf, res),
+		"failures":  failures,
+	})
+}
+
+// NotifyTickerStrategiesByTimeframeController evaluates like the evaluate endpoint, with the filter in an
+// optional JSON body, and sends the results to their channels, the failed tickers included. A failed
+// delivery is reported next to the results with 502, otherwise the status is the evaluation's.
+func NotifyTickerStrategiesByTimeframeController(c *gin.Context) {
+	var req EvaluateReq
+
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest,
+				errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
+			return
+		}
+	}
+
+	tf, ok := validateEvaluateReq(c, &req)
+	if !ok {
+		return
+	}
+
+	res, failures, err := evaluateTickersStrategiesByTimeframe(c.Request.Context(), tf, &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError,
-			errors.NewErrorResp(fmt.Errorf("send updates to Telegram: %w", err)))
+			errors.NewErrorResp(fmt.Errorf("error evaluating strategies for each ticker in the given timeframe: %w", err)))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"results": res,
+	status := errors.BatchStatus(len(res), len(failures))
+	notification := NotificationResp{Delivered: true}
+	if err := notifier.Dispatch(c.Request.Context(), newNotification(tf, res, failures)); err != nil {
+		status = http.StatusBadGateway
+		notification = NotificationResp{Error: fmt.Sprintf("send notifications: %v", err)}
+	}
+
+	c.JSON(status, gin.H{
+		"timeframe":    tf,
+		"results":      newEvaluationResps(tf, res),
+		"failures":     failures,
+		"notification": notification,
 	})
 }
 
-func formatTickersStrategiesOutput(timeframe string, results map[string][]*Resp) string {
-	// Build out the content message
-	var resultContentBuilder strings.Builder
+func validateEvaluateReq(c *gin.Context, req *EvaluateReq) (string, bool) {
+	tf := c.Param("timeframe")
+
+	if !slices.Contains(timeframe.AllowedTimeframes, tf) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid timeframes: %v", timeframe.AllowedTimeframes)))
+		return "", false
+	}
+
+	if _, ok := StrategyManager.NameToStrategyMap[req.Strategy]; req.Strategy != "" && !ok {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid strategies: %v", StrategyManager.GetStrategies())))
+		return "", false
+	}
+
+	return tf, true
+}
+
+// newEvaluationResps flattens the results, sorted by ticker then strategy.
+func newEvaluationResps(timeframe string, results map[string][]*Resp) []EvaluationResp {
+	resps := make([]EvaluationResp, 0, len(results))
+
 	for ticker, strategyResps := range results {
-		// Ticker
-		resultContentBuilder.WriteString(fmt.Sprintf("<b>%s</b>\n", ticker))
 		for _, strategyResp := range strategyResps {
-			var resultLogo string
-			if strategyResp.IsFulfilled {
-				resultLogo = "✅"
-			} else {
-				resultLogo = "❌"
-			}
-
-			resultContentBuilder.WriteString(
-				fmt.Sprintf("<code>%s %s: %s</code>\n",
-					resultLogo,
-					strategyResp.Strategy.GetName(),
-					strategyResp.EvaluationMessage,
-				),
-			)
+			resps = append(resps, EvaluationResp{
+				TickerSymbol: ticker,
+				Timeframe:    timeframe,
+				Strategy:     strategyResp.Strategy.GetName(),
+				IsFulfilled:  strategyResp.IsFulfilled,
+				Message:      strategyResp.EvaluationMessage,
+			})
 		}
-		resultContentBuilder.WriteString("\n")
 	}
 
-	resultContent := resultContentBuilder.String()
-	if resultContent == "" {
-		return ""
+	sort.Slice(resps, func(i, j int) bool {
+		if resps[i].TickerSymbol != resps[j].TickerSymbol {
+			return resps[i].TickerSymbol < resps[j].TickerSymbol
+		}
+		return resps[i].Strategy < resps[j].Strategy
+	})
+
+	return resps
+}
+
+// EvaluateAndNotify evaluates the bindings of the timeframe and sends the results and failed tickers to
+// their channels.
+func EvaluateAndNotify(c context.Context, tf string) (map[string][]*Resp, []*errors.TickerFailure, error) {
+	res, failures, err := evaluateTickersStrategiesByTimeframe(c, tf, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := notifier.Dispatch(c, newNotification(tf, res, failures)); err != nil {
+		return res, failures, fmt.Errorf("send notifications: %w", err)
+	}
+
+	return res, failures, nil
+}
+
+func newNotification(timeframe string, results map[string][]*Resp, failures []*errors.TickerFailure) *notifier.Message {
+	msg := &notifier.Message{Timeframe: timeframe}
+
+	for _, failure := range failures {
+		msg.Failures = append(msg.Failures, notifier.Failure{
+			TickerSymbol: failure.TickerSymbol,
+			Timeframe:    timeframe,
+			Category:     failure.Category,
+			Error:        failure.Error,
+		})
+	}
+
+	for ticker, strategyResps := range results {
+		for _, strategyResp := range strategyResps {
+			msg.Results = append(msg.Results, notifier.Result{
+				TickerSymbol: ticker,
+				Timeframe:    timeframe,
+				Strategy:     strategyResp.Strategy.GetName(),
+				IsFulfilled:  strategyResp.IsFulfilled,
+				Message:      strategyResp.EvaluationMessage,
+			})
+		}
 	}
 
-	title := fmt.Sprintf("<b><u>%s</u></b>\n", timeframe)
-	return title + resultContent
+	return msg
 }
@@ -0,0 +1,206 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/signalb/internal/chart"
+	"github.com/signalb/internal/indicators"
+)
+
+const (
+	SMAAverage = "sma"
+	EMAAverage = "ema"
+
+	// crossRecency is how many candles ago a cross or an alignment may have happened to still be a signal
+	crossRecency = 5
+)
+
+// Crossover fires when the fast moving average recently crossed the slow one, above it for Buy (a golden
+// cross) and below it for Sell (a death cross).
+type Crossover struct {
+	Average  string
+	Fast     int
+	Slow     int
+	Strength Strength
+	Type     Type
+}
+
+func NewCrossover(average string, fast, slow int, strength Strength, typ Type) *Crossover {
+	return &Crossover{
+		Average:  average,
+		Fast:     fast,
+		Slow:     slow,
+		Strength: strength,
+		Type:     typ,
+	}
+}
+
+// GetName is like sma50x200golden or ema12x26death.
+func (s *Crossover) GetName() string {
+	return fmt.Sprintf("%s%dx%d%s", s.Average, s.Fast, s.Slow, s.crossName())
+}
+
+func (s *Crossover) GetOverlays() chart.Overlays {
+	if s.Average == EMAAverage {
+		return chart.Overlays{EMALengths: []int{s.Fast, s.Slow}}
+	}
+
+	return chart.Overlays{SMALengths: []int{s.Fast, s.Slow}}
+}
+
+func (s *Crossover) GetWhitelistedTickerSymbols() []string {
+	return nil
+}
+
+func (s *Crossover) Evaluate(data []float64) *EvaluationResult {
+	if len(data) <= s.Slow {
+		return NewEvaluationResult(false, fmt.Sprintf("lack %d data", s.Slow+1))
+	}
+
+	fast, slow := movingAverage(s.Average, data, s.Fast), movingAverage(s.Average, data, s.Slow)
+
+	last := len(data) - 1
+	isAbove := fast[last] > slow[last]
+
+	// Walk back to the last candle the fast average was on the other side of the slow one
+	candlesAgo := -1
+	for i := last; i > 0 && !math.IsNaN(fast[i-1]-slow[i-1]); i-- {
+		if (fast[i-1] > slow[i-1]) != isAbove {
+			candlesAgo = last - i
+			break
+		}
+	}
+
+	isCrossed := isAbove == (s.Type == Buy) && candlesAgo >= 0 && candlesAgo < crossRecency
+
+	return NewEvaluationResult(isCrossed, s.getEvaluationMessage(fast[last], slow[last], isAbove, candlesAgo, isCrossed))
+}
+
+func (s *Crossover) getEvaluationMessage(fast, slow float64, isAbove bool, candlesAgo int, isCrossed bool) string {
+	fastName, slowName := fmt.Sprintf("%s%d", s.Average, s.Fast), fmt.Sprintf("%s%d", s.Average, s.Slow)
+
+	side, cross := "below", "death"
+	if isAbove {
+		side, cross = "above", "golden"
+	}
+
+	since := "no cross in the data"
+	if candlesAgo >= 0 {
+		since = fmt.Sprintf("%s cross %s", cross, formatCandlesAgo(candlesAgo))
+	}
+
+	if !isCrossed {
+		return fmt.Sprintf("%s(%s) %s %s(%s), %s",
+			fastName, formatPrice(fast), side, slowName, formatPrice(slow), since)
+	}
+
+	return fmt.Sprintf("%s %s! %s(%s) crossed %s %s(%s), %s",
+		s.Strength, s.Type, fastName, formatPrice(fast), side, slowName, formatPrice(slow), since)
+}
+
+func (s *Crossover) crossName() string {
+	if s.Type == Sell {
+		return "death"
+	}
+
+	return "golden"
+}
+
+// Ribbon fires when a ribbon of EMAs recently lined up, each shorter one above the longer ones for Buy and
+// below them for Sell.
+type Ribbon struct {
+	Lengths  []int
+	Strength Strength
+	Type     Type
+}
+
+// NewRibbon takes the lengths shortest first.
+func NewRibbon(lengths []int, strength Strength, typ Type) *Ribbon {
+	return &Ribbon{
+		Lengths:  lengths,
+		Strength: strength,
+		Type:     typ,
+	}
+}
+
+// GetName is like ribbon8to55buy, after the shortest and longest EMA.
+func (s *Ribbon) GetName() string {
+	return fmt.Sprintf("ribbon%dto%d%s", s.Lengths[0], s.Lengths[len(s.Lengths)-1], strings.ToLower(string(s.Type)))
+}
+
+func (s *Ribbon) GetOverlays() chart.Overlays {
+	return chart.Overlays{EMALengths: s.Lengths}
+}
+
+func (s *Ribbon) GetWhitelistedTickerSymbols() []string {
+	return nil
+}
+
+func (s *Ribbon) Evaluate(data []float64) *EvaluationResult {
+	longest := s.Lengths[len(s.Lengths)-1]
+	if len(data) <= longest {
+		return NewEvaluationResult(false, fmt.Sprintf("lack %d data", longest+1))
+	}
+
+	emas := make([][]float64, 0, len(s.Lengths))
+	for _, length := range s.Lengths {
+		emas = append(emas, indicators.EMA(data, length))
+	}
+
+	last := len(data) - 1
+	if !s.isAligned(emas, last) {
+		return NewEvaluationResult(false, fmt.Sprintf("EMAs of %s not lined up", s.GetName()))
+	}
+
+	candlesAgo := -1
+	for i := last; i > 0 && !math.IsNaN(emas[len(emas)-1][i-1]); i-- {
+		if !s.isAligned(emas, i-1) {
+			candlesAgo = last - i
+			break
+		}
+	}
+
+	if candlesAgo < 0 || candlesAgo >= crossRecency {
+		since := "for all of the data"
+		if candlesAgo >= 0 {
+			since = "since " + formatCandlesAgo(candlesAgo)
+		}
+
+		return NewEvaluationResult(false, fmt.Sprintf("EMAs of %s lined up %s", s.GetName(), since))
+	}
+
+	return NewEvaluationResult(true, fmt.Sprintf("%s %s! EMAs of %s lined up %s",
+		s.Strength, s.Type, s.GetName(), formatCandlesAgo(candlesAgo)))
+}
+
+// isAligned reports whether each EMA is above the next longer one at i for Buy, below it for Sell.
+func (s *Ribbon) isAligned(emas [][]float64, i int) bool {
+	for j := 1; j < len(emas); j++ {
+		if (s.Type == Buy && !(emas[j-1][i] > emas[j][i])) || (s.Type == Sell && !(emas[j-1][i] < emas[j][i])) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func movingAverage(average string, data []float64, length int) []float64 {
+	if average == EMAAverage {
+		return indicators.EMA(data, length)
+	}
+
+	return indicators.SMA(data, length)
+}
+
+func formatCandlesAgo(candlesAgo int) string {
+	switch candlesAgo {
+	case 0:
+		return "on the latest candle"
+	case 1:
+		return "1 candle ago"
+	default:
+		return fmt.Sprintf("%d candles ago", candlesAgo)
+	}
+}
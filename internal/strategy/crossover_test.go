@@ -0,0 +1,83 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+)
+
+// turning is prices falling from 20 to 13, then rising by one for each of the up candles.
+func turning(up int) []float64 {
+	prices := []float64{20, 19, 18, 17, 16, 15, 14, 13}
+	for i := 1; i <= up; i++ {
+		prices = append(prices, 13+float64(i))
+	}
+	return prices
+}
+
+func rising(n int) []float64 {
+	prices := make([]float64, n)
+	for i := range prices {
+		prices[i] = 10 + float64(i)
+	}
+	return prices
+}
+
+func TestCrossover(t *testing.T) {
+	golden, death := NewCrossover(SMAAverage, 2, 4, Strong, Buy), NewCrossover(SMAAverage, 2, 4, Strong, Sell)
+
+	tests := []struct {
+		name      string
+		strategy  *Crossover
+		data      []float64
+		fulfilled bool
+		message   string
+	}{
+		{"lacking data", golden, rising(4), false, "lack 5 data"},
+		{"still below", golden, turning(1), false, "below sma4"},
+		{"crossing on the latest candle", golden, turning(2), true, "golden cross on the latest candle"},
+		{"crossing 1 candle ago", golden, turning(3), true, "golden cross 1 candle ago"},
+		{"crossing 4 candles ago", golden, turning(6), true, "golden cross 4 candles ago"},
+		{"crossing too long ago", golden, turning(7), false, "golden cross 5 candles ago"},
+		{"above for all of the data", golden, rising(10), false, "no cross in the data"},
+		{"golden cross for Sell", death, turning(2), false, "above sma4"},
+		{"falling for all of the data", death, turning(0), false, "no cross in the data"},
+	}
+
+	for _, tt := range tests {
+		res := tt.strategy.Evaluate(tt.data)
+		if res.IsFulfilled != tt.fulfilled || !strings.Contains(res.EvaluationMessage, tt.message) {
+			t.Errorf("%s: fulfilled %t with %q, want %t with %q",
+				tt.name, res.IsFulfilled, res.EvaluationMessage, tt.fulfilled, tt.message)
+		}
+	}
+}
+
+func TestRibbon(t *testing.T) {
+	buy, sell := NewRibbon([]int{2, 3, 4}, Strong, Buy), NewRibbon([]int{2, 3, 4}, Strong, Sell)
+
+	tests := []struct {
+		name      string
+		strategy  *Ribbon
+		data      []float64
+		fulfilled bool
+		message   string
+	}{
+		{"lacking data", buy, rising(4), false, "lack 5 data"},
+		// The shortest EMA crosses the longer ones a candle before they line up
+		{"partly lined up", buy, turning(2), false, "not lined up"},
+		{"lining up on the latest candle", buy, turning(3), true, "lined up on the latest candle"},
+		{"lining up 4 candles ago", buy, turning(7), true, "lined up 4 candles ago"},
+		{"lining up too long ago", buy, turning(8), false, "lined up since 5 candles ago"},
+		{"lined up for all of the data", buy, rising(10), false, "lined up for all of the data"},
+		{"falling for all of the data", sell, turning(0), false, "lined up for all of the data"},
+		{"turning up for Sell", sell, turning(2), false, "not lined up"},
+	}
+
+	for _, tt := range tests {
+		res := tt.strategy.Evaluate(tt.data)
+		if res.IsFulfilled != tt.fulfilled || !strings.Contains(res.EvaluationMessage, tt.message) {
+			t.Errorf("%s: fulfilled %t with %q, want %t with %q",
+				tt.name, res.IsFulfilled, res.EvaluationMessage, tt.fulfilled, tt.message)
+		}
+	}
+}
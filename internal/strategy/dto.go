@@ -0,0 +1,16 @@
+package strategy
+
+// EvaluationResp is the outcome of one binding.
+type EvaluationResp struct {
+	TickerSymbol string `json:"tickerSymbol"`
+	Timeframe    string `json:"timeframe"`
+	Strategy     string `json:"strategy"`
+	IsFulfilled  bool   `json:"isFulfilled"`
+	Message      string `json:"message"`
+}
+
+// NotificationResp is how sending the results went, apart from how evaluating them went.
+type NotificationResp struct {
+	Delivered bool   `json:"delivered"`
+	Error     string `json:"error,omitempty"`
+}
@@ -2,11 +2,14 @@ package strategy
 
 import (
 	"context"
-	"errors"
+	"sort"
 	"sync"
 	"time"
 
+	errorsStdLib "errors"
+
 	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/errors"
 )
 
 type Resp struct {
@@ -15,21 +18,35 @@ type Resp struct {
 	EvaluationMessage string   `json:"evaluationMessage"`
 }
 
+// boundStrategy is a strategy bound to a ticker together with the price series it evaluates.
+type boundStrategy struct {
+	strategy    Strategy
+	priceSeries string
+}
+
 type tickerStrategiesResult struct {
 	tickerSymbol     string
 	strategiesResult []*Resp
 }
 
-func evaluateTickersStrategiesByTimeframe(c context.Context, timeframe string) (map[string][]*Resp, error) {
-	tickersStrategiesMap, err := getTickersAndStrategyByTimeframe(c, timeframe)
+// evaluateTickersStrategiesByTimeframe evaluates the timeframe's bindings matching the filter, without
+// notifying anyone. A ticker failing doesn't stop the others, it's returned in the failures next to their
+// results. The error is only for not getting the bindings at all.
+func evaluateTickersStrategiesByTimeframe(
+	c context.Context,
+	timeframe string,
+	filter *EvaluateReq,
+) (map[string][]*Resp, []*errors.TickerFailure, error) {
+	tickersStrategiesMap, err := getTickersAndStrategyByTimeframe(c, timeframe, filter)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var (
 		chRes      = make(chan tickerStrategiesResult, len(tickersStrategiesMap))
-		chErr      = make(chan error, len(tickersStrategiesMap))
+		chErr      = make(chan *errors.TickerFailure, len(tickersStrategiesMap))
 		result     = make(map[string][]*Resp)
+		failures   = []*errors.TickerFailure{}
 		wgEvaluate sync.WaitGroup
 		wgCollect  sync.WaitGroup
 	)
@@ -43,17 +60,17 @@ func evaluateTickersStrategiesByTimeframe(c context.Context, timeframe string) (
 		}
 	}()
 
-	// collect error
+	// collect failures
 	go func() {
 		defer wgCollect.Done()
-		for newErr := range chErr {
-			err = newErr
+		for failure := range chErr {
+			failures = append(failures, failure)
 		}
 	}()
 
 	for tickerSymbol, strategies := range tickersStrategiesMap {
 		wgEvaluate.Add(1)
-		go func(c context.Context, tickerSymbol, timeframe string, strategies []Strategy) {
+		go func(c context.Context, tickerSymbol, timeframe string, strategies []boundStrategy) {
 			defer wgEvaluate.Done()
 
 			ctx, cancel := context.WithTimeout(c, 4*time.Second)
@@ -61,13 +78,13 @@ func evaluateTickersStrategiesByTimeframe(c context.Context, timeframe string) (
 
 			data, err := getPriceByTicker(ctx, tickerSymbol, timeframe)
 			if err != nil {
-				chErr <- err
+				chErr <- errors.NewTickerFailure(tickerSymbol, errors.WithCategory(errors.DatabaseCategory, err))
 				return
 			}
 
 			err = evaluateStrategiesForTicker(ctx, tickerSymbol, strategies, data, chRes)
 			if err != nil {
-				chErr <- err
+				chErr <- errors.NewTickerFailure(tickerSymbol, errors.WithCategory(errors.EvaluationCategory, err))
 			}
 		}(c, tickerSymbol, timeframe, strategies)
 	}
@@ -77,14 +94,16 @@ func evaluateTickersStrategiesByTimeframe(c context.Context, timeframe string) (
 	close(chErr)
 	wgCollect.Wait()
 
-	if err != nil {
-		return nil, err
-	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].TickerSymbol < failures[j].TickerSymbol })
 
-	return result, nil
+	return result, failures, nil
 }
 
-func getTickersAndStrategyByTimeframe(c context.Context, timeframe string) (map[string][]Strategy, error) {
+func getTickersAndStrategyByTimeframe(
+	c context.Context,
+	timeframe string,
+	filter *EvaluateReq,
+) (map[string][]boundStrategy, error) {
 	ctx, cancel := context.WithTimeout(c, 2*time.Second)
 	defer cancel()
 
@@ -93,15 +112,22 @@ func getTickersAndStrategyByTimeframe(c context.Context, timeframe string) (map[
 		return nil, err
 	}
 
-	tickerToStrategiesMap := make(map[string][]Strategy)
+	tickerToStrategiesMap := make(map[string][]boundStrategy)
 
 	for _, binding := range bindings {
+		if !filter.matches(&binding) {
+			continue
+		}
+
 		strategy, err := StrategyManager.GetStrategyByName(binding.Strategy)
 		if err != nil {
 			return nil, err
 		}
 
-		tickerToStrategiesMap[binding.TickerSymbol] = append(tickerToStrategiesMap[binding.TickerSymbol], strategy)
+		tickerToStrategiesMap[binding.TickerSymbol] = append(tickerToStrategiesMap[binding.TickerSymbol], boundStrategy{
+			strategy:    strategy,
+			priceSeries: binding.PriceSeries,
+		})
 	}
 
 	return tickerToStrategiesMap, nil
@@ -110,8 +136,8 @@ func getTickersAndStrategyByTimeframe(c context.Context, timeframe string) (map[
 func evaluateStrategiesForTicker(
 	c context.Context,
 	tickerSymbol string,
-	strategies []Strategy,
-	data []float64,
+	strategies []boundStrategy,
+	data map[string][]float64,
 	chRes chan<- tickerStrategiesResult,
 ) error {
 	select {
@@ -135,9 +161,9 @@ func evaluateStrategiesForTicker(
 		}
 	}()
 
-	for _, strategy := range strategies {
+	for _, bound := range strategies {
 		wgEvaluate.Add(1)
-		evaluateStrategy(c, data, strategy, chStrategyResp, &wgEvaluate)
+		evaluateStrategy(c, data[bound.priceSeries], bound.strategy, chStrategyResp, &wgEvaluate)
 	}
 
 	wgEvaluate.Wait()
@@ -145,24 +171,36 @@ func evaluateStrategiesForTicker(
 	wgCollect.Wait()
 
 	if len(strategyResps) != len(strategies) {
-		return errors.New("something went wrong")
+		return errorsStdLib.New("something went wrong")
 	}
 
+	// Once sent the results count, a deadline passing now doesn't make the ticker fail
 	chRes <- tickerStrategiesResult{
 		tickerSymbol:     tickerSymbol,
 		strategiesResult: strategyResps,
 	}
 
-	select {
-	case <-c.Done():
-		return c.Err()
-	default:
-		return nil
-	}
+	return nil
 }
 
-func getPriceByTicker(ctx context.Context, tickerSymbol, timeframe string) ([]float64, error) {
-	return database.Client.GetPriceByTicker(ctx, tickerSymbol, timeframe)
+// getPriceByTicker returns the ticker's stored prices keyed by price series.
+func getPriceByTicker(ctx context.Context, tickerSymbol, timeframe string) (map[string][]float64, error) {
+	data, err := database.Client.GetPriceByTicker(ctx, tickerSymbol, timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]float64, 0, len(data))
+	adjusted := make([]float64, 0, len(data))
+	for _, d := range data {
+		raw = append(raw, d.Price)
+		adjusted = append(adjusted, d.AdjPrice)
+	}
+
+	return map[string][]float64{
+		database.RawSeries:      raw,
+		database.AdjustedSeries: adjusted,
+	}, nil
 }
 
 func evaluateStrategy(
@@ -2,6 +2,8 @@ package strategy
 
 import (
 	"fmt"
+
+	"github.com/signalb/internal/chart"
 )
 
 type (
@@ -41,6 +43,37 @@ type Strategy interface {
 	Evaluate(data []float64) *EvaluationResult
 }
 
+// OverlayStrategy is a strategy whose indicators are drawn on the charts of its signals.
+type OverlayStrategy interface {
+	Strategy
+	GetOverlays() chart.Overlays
+}
+
+// Candle is a period's prices. High and Low are 0 when only the close is known.
+type Candle struct {
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// CandleStrategy is a strategy that uses highs and lows when candles have them. Its Evaluate treats each
+// close as its own high and low, which is what evaluation gets while only closes are stored.
+type CandleStrategy interface {
+	Strategy
+	EvaluateCandles(candles []Candle) *EvaluationResult
+}
+
+// closeCandles are the candles of close-only data.
+func closeCandles(data []float64) []Candle {
+	candles := make([]Candle, 0, len(data))
+	for _, price := range data {
+		candles = append(candles, Candle{Close: price})
+	}
+
+	return candles
+}
+
 var (
 	StrategyManager *Manager
 )
@@ -89,15 +122,51 @@ func InitStrategies() {
 		NewRSI(70, Strong, Sell),
 		NewRSI(80, VeryStrong, Sell)
 
+	// RSI divergence
+	rsiDiv40, rsiDiv60, rsiHiddenDiv50Buy, rsiHiddenDiv50Sell := NewRSIDivergence(40, Strong, Buy),
+		NewRSIDivergence(60, Strong, Sell),
+		NewHiddenRSIDivergence(50, Neutral, Buy),
+		NewHiddenRSIDivergence(50, Neutral, Sell)
+
 	// SMA
 	sma200 := newSMA(200, VeryStrong)
 
+	// Bollinger Bands
+	bb20buy, bb20sell, bb20squeeze := NewBollinger(20, 2, Strong, Buy),
+		NewBollinger(20, 2, Strong, Sell),
+		NewBollinger(20, 2, Key, Notify)
+
+	// Moving average crosses
+	goldenCross, deathCross := NewCrossover(SMAAverage, 50, 200, VeryStrong, Buy),
+		NewCrossover(SMAAverage, 50, 200, VeryStrong, Sell)
+	ema12x26golden, ema12x26death := NewCrossover(EMAAverage, 12, 26, Strong, Buy),
+		NewCrossover(EMAAverage, 12, 26, Strong, Sell)
+	ribbonBuy, ribbonSell := NewRibbon([]int{8, 13, 21, 34, 55}, Strong, Buy),
+		NewRibbon([]int{8, 13, 21, 34, 55}, Strong, Sell)
+
+	// Stochastic
+	stoch20, stoch80, stochRSI20, stochRSI80 := NewStochastic(20, Strong, Buy),
+		NewStochastic(80, Strong, Sell),
+		NewStochRSI(20, Strong, Buy),
+		NewStochRSI(80, Strong, Sell)
+
 	// FNG
 	fng := newFearNGreedIdx()
 
 	StrategyManager = NewStrategyManager(
 		rsi20, rsi30, rsi40, rsi70, rsi80,
+		rsiDiv40, rsiDiv60, rsiHiddenDiv50Buy, rsiHiddenDiv50Sell,
 		sma200,
+		bb20buy, bb20sell, bb20squeeze,
+		goldenCross, deathCross, ema12x26golden, ema12x26death,
+		ribbonBuy, ribbonSell,
+		stoch20, stoch80, stochRSI20, stochRSI80,
 		fng,
 	)
+
+	for _, strategy := range StrategyManager.NameToStrategyMap {
+		if s, ok := strategy.(OverlayStrategy); ok {
+			chart.RegisterOverlays(s.GetName(), s.GetOverlays())
+		}
+	}
 }
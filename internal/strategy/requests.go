@@ -0,0 +1,18 @@
+package strategy
+
+import "github.com/signalb/internal/database"
+
+// EvaluateReq narrows an evaluation down to a ticker or a strategy, empty fields match every binding.
+type EvaluateReq struct {
+	TickerSymbol string `json:"tickerSymbol" form:"ticker"`
+	Strategy     string `json:"strategy" form:"strategy"`
+}
+
+func (req *EvaluateReq) matches(binding *database.Binding) bool {
+	if req == nil {
+		return true
+	}
+
+	return (req.TickerSymbol == "" || req.TickerSymbol == binding.TickerSymbol) &&
+		(req.Strategy == "" || req.Strategy == binding.Strategy)
+}
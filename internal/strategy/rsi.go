@@ -3,7 +3,10 @@ package strategy
 import (
 	"fmt"
 	"log"
+	"math"
 
+	"github.com/signalb/internal/chart"
+	"github.com/signalb/internal/indicators"
 	"github.com/signalb/internal/marketprice"
 )
 
@@ -30,16 +33,24 @@ func (s *RSI) GetName() string {
 	return fmt.Sprintf("rsi%0.f", s.Level)
 }
 
+func (s *RSI) GetOverlays() chart.Overlays {
+	return chart.Overlays{RSILevels: []float64{s.Level}}
+}
+
 func (s *RSI) GetWhitelistedTickerSymbols() []string {
 	return nil
 }
 
 func (s *RSI) Evaluate(data []float64) *EvaluationResult {
-	if len(data) != marketprice.RefreshAllDataLength {
-		log.Printf("Number of data should be %d", marketprice.RefreshAllDataLength)
+	// Retention policies may keep more history than a refresh fetches
+	if len(data) < marketprice.RefreshAllDataLength {
+		log.Printf("Number of data should be at least %d", marketprice.RefreshAllDataLength)
 	}
 
-	rsi := calculateRSI(data, length)
+	rsi := indicators.Last(indicators.RSI(data, length))
+	if math.IsNaN(rsi) {
+		return NewEvaluationResult(false, fmt.Sprintf("lack %d data", length+1))
+	}
 
 	isSuccess := s.isRSIReachedLevel(rsi)
 
@@ -80,40 +91,3 @@ func (s *RSI) isRSIReachedLevel(rsi float64) bool {
 
 	return res
 }
-
-// Reference https://blog.quantinsti.com/rsi-indicator/
-func calculateRSI(data []float64, length int) float64 {
-	prices := data
-	period := length
-	var averageGain, averageLoss, rs float64
-
-	for i := 1; i <= period; i++ {
-		diff := prices[i] - prices[i-1]
-		if diff > 0 {
-			averageGain += diff
-		} else {
-			averageLoss -= diff
-		}
-	}
-
-	averageGain /= float64(period)
-	averageLoss /= float64(period)
-
-	for i := period; i < len(prices); i++ {
-		currentGain := 0.0
-		currentLoss := 0.0
-
-		diff := prices[i] - prices[i-1]
-		if diff > 0 {
-			currentGain = diff
-		} else {
-			currentLoss = -diff
-		}
-
-		averageGain = (averageGain*(float64(period-1)) + currentGain) / float64(period)
-		averageLoss = (averageLoss*(float64(period-1)) + currentLoss) / float64(period)
-	}
-
-	rs = averageGain / averageLoss
-	return 100 - (100 / (1 + rs))
-}
@@ -0,0 +1,183 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/signalb/internal/chart"
+	"github.com/signalb/internal/indicators"
+)
+
+const (
+	// divergenceLookback is how many candles back swings are looked for
+	divergenceLookback = 60
+	// swingWidth is how many candles on each side a swing low must be under, or a swing high over
+	swingWidth = 3
+)
+
+// RSIDivergence compares the last two swings of the price with the RSI at the same candles. For Buy a
+// regular divergence is a lower low of the price with a higher low of the RSI, for Sell a higher high with
+// a lower high. Hidden divergences are the other way around and signal the trend going on. The RSI of the
+// latest swing has to be at or under the level for Buy, at or over it for Sell.
+type RSIDivergence struct {
+	Level    float64
+	Strength Strength
+	Type     Type
+	Hidden   bool
+}
+
+func NewRSIDivergence(level float64, strength Strength, typ Type) *RSIDivergence {
+	return &RSIDivergence{
+		Level:    level,
+		Strength: strength,
+		Type:     typ,
+	}
+}
+
+func NewHiddenRSIDivergence(level float64, strength Strength, typ Type) *RSIDivergence {
+	return &RSIDivergence{
+		Level:    level,
+		Strength: strength,
+		Type:     typ,
+		Hidden:   true,
+	}
+}
+
+// GetName is like rsidiv40buy or rsihiddendiv60sell.
+func (s *RSIDivergence) GetName() string {
+	name := "rsidiv"
+	if s.Hidden {
+		name = "rsihiddendiv"
+	}
+
+	if s.Type == Sell {
+		return fmt.Sprintf("%s%0.fsell", name, s.Level)
+	}
+
+	return fmt.Sprintf("%s%0.fbuy", name, s.Level)
+}
+
+func (s *RSIDivergence) GetOverlays() chart.Overlays {
+	return chart.Overlays{RSILevels: []float64{s.Level}}
+}
+
+func (s *RSIDivergence) GetWhitelistedTickerSymbols() []string {
+	return nil
+}
+
+func (s *RSIDivergence) Evaluate(data []float64) *EvaluationResult {
+	if len(data) < length+divergenceLookback {
+		return NewEvaluationResult(false, fmt.Sprintf("lack %d data", length+divergenceLookback))
+	}
+
+	rsi := indicators.RSI(data, length)
+
+	last := len(data) - 1
+	swings := findSwings(data, len(data)-divergenceLookback, s.Type == Buy)
+	if len(swings) < 2 {
+		return NewEvaluationResult(false, fmt.Sprintf("No two swing %ss in the last %d candles for %s",
+			s.swingKind(), divergenceLookback, s.GetName()))
+	}
+
+	first, second := swings[len(swings)-2], swings[len(swings)-1]
+	priceRise, rsiRise := data[second] > data[first], rsi[second] > rsi[first]
+
+	// The swings go opposite ways, which way the price went tells a regular divergence from a hidden one
+	isDivergence := priceRise != rsiRise && data[second] != data[first] && rsi[second] != rsi[first]
+	if s.Hidden {
+		isDivergence = isDivergence && priceRise == (s.Type == Buy)
+	} else {
+		isDivergence = isDivergence && priceRise == (s.Type == Sell)
+	}
+
+	isInZone := rsi[second] <= s.Level
+	if s.Type == Sell {
+		isInZone = rsi[second] >= s.Level
+	}
+
+	// The second swing is only known swingWidth candles after it, from then on the divergence is recent
+	isRecent := last-second-swingWidth < crossRecency
+
+	isFulfilled := isDivergence && isInZone && isRecent
+
+	return NewEvaluationResult(isFulfilled, s.getEvaluationMessage(data, rsi, first, second, last, isFulfilled))
+}
+
+func (s *RSIDivergence) getEvaluationMessage(data, rsi []float64, first, second, last int, isFulfilled bool) string {
+	swings := fmt.Sprintf("price %s %s(%d candles ago) to %s(%d candles ago), RSI %s %0.2f to %0.2f",
+		s.describeSwing(data[first], data[second]), formatPrice(data[first]), last-first,
+		formatPrice(data[second]), last-second,
+		s.describeSwing(rsi[first], rsi[second]), rsi[first], rsi[second])
+
+	if !isFulfilled {
+		return fmt.Sprintf("No %s at %s levels, %s", s.divergenceName(), s.GetName(), swings)
+	}
+
+	return fmt.Sprintf("%s %s! %s at %s levels, %s", s.Strength, s.Type, s.divergenceName(), s.GetName(), swings)
+}
+
+func (s *RSIDivergence) divergenceName() string {
+	name := "bullish"
+	if s.Type == Sell {
+		name = "bearish"
+	}
+
+	if s.Hidden {
+		return name + " hidden divergence"
+	}
+
+	return name + " divergence"
+}
+
+func (s *RSIDivergence) swingKind() string {
+	if s.Type == Sell {
+		return "high"
+	}
+
+	return "low"
+}
+
+// describeSwing is like "higher low" or "lower high".
+func (s *RSIDivergence) describeSwing(from, to float64) string {
+	switch {
+	case to > from:
+		return "higher " + s.swingKind()
+	case to < from:
+		return "lower " + s.swingKind()
+	default:
+		return "equal " + s.swingKind()
+	}
+}
+
+// findSwings returns, oldest first, the candles from start on whose value is under (lows) or over (highs)
+// the swingWidth values on each side. Of equal values in a row only the first is a swing.
+func findSwings(values []float64, start int, lows bool) []int {
+	var swings []int
+
+	for i := max(start, swingWidth); i < len(values)-swingWidth; i++ {
+		if math.IsNaN(values[i]) {
+			continue
+		}
+
+		isSwing := true
+		for j := i - swingWidth; j <= i+swingWidth && isSwing; j++ {
+			switch {
+			case j == i:
+			case lows && j < i:
+				isSwing = values[i] < values[j]
+			case lows:
+				isSwing = values[i] <= values[j]
+			case j < i:
+				isSwing = values[i] > values[j]
+			default:
+				isSwing = values[i] >= values[j]
+			}
+		}
+
+		if isSwing {
+			swings = append(swings, i)
+		}
+	}
+
+	return swings
+}
@@ -0,0 +1,98 @@
+package strategy
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// divergingLows is prices going sideways, crashing to a low of 80, then drifting to a lower low of 79 with
+// a higher RSI, then rising by one for each of the after candles.
+func divergingLows(after int) []float64 {
+	prices := alternating(100, 101, 60)
+	prices = append(prices, 90, 80, 85, 90, 95, 93, 91, 89, 87, 85, 83, 81, 79)
+	for i := 1; i <= after; i++ {
+		prices = append(prices, 79+float64(i))
+	}
+	return prices
+}
+
+// hiddenDivergingLows is prices rallying into a low of 97, then crashing to a higher low of 99 with a lower
+// RSI, then rising for 3 candles.
+func hiddenDivergingLows() []float64 {
+	prices := alternating(90, 90.1, 60)
+	return append(prices, 92, 94, 96, 98, 100, 99, 97, 100, 102, 104, 101, 99, 100, 101, 102)
+}
+
+// mirrored flips prices around 100, turning lows into highs and the RSI into 100 minus it.
+func mirrored(prices []float64) []float64 {
+	flipped := make([]float64, 0, len(prices))
+	for _, price := range prices {
+		flipped = append(flipped, 200-price)
+	}
+	return flipped
+}
+
+func TestRSIDivergence(t *testing.T) {
+	buy, sell := NewRSIDivergence(40, Strong, Buy), NewRSIDivergence(60, Strong, Sell)
+
+	tests := []struct {
+		name      string
+		strategy  *RSIDivergence
+		data      []float64
+		fulfilled bool
+		message   string
+	}{
+		{"lacking data", buy, divergingLows(0)[:73], false, "lack 74 data"},
+		{"no swings", buy, rising(80), false, "No two swing lows"},
+		{"known on the latest candle", buy, divergingLows(3), true, "price lower low 80.00(14 candles ago) to 79.00(3 candles ago), RSI higher low 19.41 to 30.37"},
+		{"known 4 candles ago", buy, divergingLows(7), true, "bullish divergence"},
+		{"known too long ago", buy, divergingLows(8), false, "No bullish divergence"},
+		// The RSI of the second low is 30.37
+		{"out of the zone", NewRSIDivergence(25, Strong, Buy), divergingLows(3), false, "No bullish divergence"},
+		{"regular for hidden", NewHiddenRSIDivergence(40, Strong, Buy), divergingLows(3), false, "No bullish hidden divergence"},
+		{"bearish", sell, mirrored(divergingLows(3)), true, "price higher high 120.00(14 candles ago) to 121.00(3 candles ago), RSI lower high 80.59 to 69.63"},
+		{"bullish for Sell", sell, divergingLows(3), false, "No two swing highs"},
+		{"hidden", NewHiddenRSIDivergence(70, Strong, Buy), hiddenDivergingLows(), true, "price higher low 97.00(8 candles ago) to 99.00(3 candles ago), RSI lower low 70.20 to 60.76"},
+		{"hidden out of the zone", NewHiddenRSIDivergence(50, Strong, Buy), hiddenDivergingLows(), false, "No bullish hidden divergence"},
+		{"hidden for regular", NewRSIDivergence(70, Strong, Buy), hiddenDivergingLows(), false, "No bullish divergence"},
+		{"hidden bearish", NewHiddenRSIDivergence(30, Strong, Sell), mirrored(hiddenDivergingLows()), true, "bearish hidden divergence"},
+	}
+
+	for _, tt := range tests {
+		res := tt.strategy.Evaluate(tt.data)
+		if res.IsFulfilled != tt.fulfilled || !strings.Contains(res.EvaluationMessage, tt.message) {
+			t.Errorf("%s: fulfilled %t with %q, want %t with %q",
+				tt.name, res.IsFulfilled, res.EvaluationMessage, tt.fulfilled, tt.message)
+		}
+	}
+}
+
+func TestFindSwings(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name   string
+		values []float64
+		start  int
+		lows   bool
+		swings []int
+	}{
+		{"a low", []float64{5, 4, 3, 2, 3, 4, 5}, 0, true, []int{3}},
+		{"no high in a valley", []float64{5, 4, 3, 2, 3, 4, 5}, 0, false, nil},
+		{"a high", []float64{1, 2, 3, 4, 3, 2, 1}, 0, false, []int{3}},
+		{"the first of equal lows", []float64{5, 4, 3, 1, 1, 3, 4, 5}, 0, true, []int{3}},
+		{"too close to the end", []float64{5, 4, 3, 2, 1, 0, 1}, 0, true, nil},
+		{"too close to the start", []float64{1, 0, 1, 2, 3, 4, 5}, 0, true, nil},
+		{"two lows", []float64{5, 4, 3, 2, 3, 4, 5, 4, 3, 1, 3, 4, 5}, 0, true, []int{3, 9}},
+		{"lows from start on", []float64{5, 4, 3, 2, 3, 4, 5, 4, 3, 1, 3, 4, 5}, 4, true, []int{9}},
+		{"next to NaN", []float64{nan, nan, nan, 2, 3, 4, 5, 4, 3, 1, 3, 4, 5}, 0, true, []int{9}},
+	}
+
+	for _, tt := range tests {
+		if swings := findSwings(tt.values, tt.start, tt.lows); !slices.Equal(swings, tt.swings) {
+			t.Errorf("%s: swings at %v, want %v", tt.name, swings, tt.swings)
+		}
+	}
+}
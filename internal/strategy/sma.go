@@ -1,6 +1,13 @@
 package strategy
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/signalb/internal/chart"
+	"github.com/signalb/internal/indicators"
+)
 
 const (
 	tolerancePercentage float64 = 10
@@ -22,6 +29,10 @@ func (s *SMA) GetName() string {
 	return fmt.Sprintf("sma%d", s.Length)
 }
 
+func (s *SMA) GetOverlays() chart.Overlays {
+	return chart.Overlays{SMALengths: []int{s.Length}}
+}
+
 func (s *SMA) GetWhitelistedTickerSymbols() []string {
 	return nil
 }
@@ -31,14 +42,7 @@ func (s *SMA) Evaluate(data []float64) *EvaluationResult {
 		return NewEvaluationResult(false, fmt.Sprintf("lack %d data", s.Length))
 	}
 
-	var sum float64
-
-	for i := 0; i < s.Length; i++ {
-		idx := len(data) - 1 - i
-		sum += data[idx]
-	}
-
-	sma := sum / float64(s.Length)
+	sma := indicators.Last(indicators.SMA(data, s.Length))
 	latestPrice := data[len(data)-1]
 	upperZone := sma * ((100 + tolerancePercentage) / 100)
 	lowerZone := sma * ((100 - tolerancePercentage) / 100)
@@ -49,8 +53,22 @@ func (s *SMA) Evaluate(data []float64) *EvaluationResult {
 
 func (s *SMA) getEvaluationMessage(sma float64, isSuccess bool) string {
 	if !isSuccess {
-		return fmt.Sprintf("Price not at %s levels(%0.2f)", s.GetName(), sma)
+		return fmt.Sprintf("Price not at %s levels(%s)", s.GetName(), formatPrice(sma))
+	}
+
+	return fmt.Sprintf("%s zone! Price at %s levels(%s)", s.Strength, s.GetName(), formatPrice(sma))
+}
+
+// formatPrice keeps 4 significant digits for prices under 1 so sub-cent prices don't show as 0.00.
+func formatPrice(price float64) string {
+	if math.Abs(price) >= 1 {
+		return fmt.Sprintf("%0.2f", price)
+	}
+
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(price, 'g', 4, 64), 64)
+	if err != nil {
+		return fmt.Sprintf("%g", price)
 	}
 
-	return fmt.Sprintf("%s zone! Price at %s levels(%0.2f)", s.Strength, s.GetName(), sma)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
 }
@@ -0,0 +1,140 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/signalb/internal/indicators"
+)
+
+const (
+	stochasticLength    = 14
+	stochasticSmoothing = 3
+	stochasticSignal    = 3
+)
+
+// Stochastic fires when %K crosses %D in the oversold zone, above it under the level for Buy, or in the
+// overbought zone, below it over the level for Sell. As StochRSI the oscillator is computed over the RSI.
+type Stochastic struct {
+	Level    float64
+	Strength Strength
+	Type     Type
+	// OfRSI computes the stochastic of the RSI instead of the prices
+	OfRSI bool
+}
+
+func NewStochastic(level float64, strength Strength, typ Type) *Stochastic {
+	return &Stochastic{
+		Level:    level,
+		Strength: strength,
+		Type:     typ,
+	}
+}
+
+func NewStochRSI(level float64, strength Strength, typ Type) *Stochastic {
+	return &Stochastic{
+		Level:    level,
+		Strength: strength,
+		Type:     typ,
+		OfRSI:    true,
+	}
+}
+
+// GetName is like stoch20buy or stochrsi80sell.
+func (s *Stochastic) GetName() string {
+	name := "stoch"
+	if s.OfRSI {
+		name = "stochrsi"
+	}
+
+	if s.Type == Sell {
+		return fmt.Sprintf("%s%0.fsell", name, s.Level)
+	}
+
+	return fmt.Sprintf("%s%0.fbuy", name, s.Level)
+}
+
+func (s *Stochastic) GetWhitelistedTickerSymbols() []string {
+	return nil
+}
+
+func (s *Stochastic) Evaluate(data []float64) *EvaluationResult {
+	return s.EvaluateCandles(closeCandles(data))
+}
+
+// EvaluateCandles ranges the closes between the highs and lows, a candle without them counts its close as
+// both. StochRSI only looks at the closes.
+func (s *Stochastic) EvaluateCandles(candles []Candle) *EvaluationResult {
+	closes := make([]float64, 0, len(candles))
+	highs := make([]float64, 0, len(candles))
+	lows := make([]float64, 0, len(candles))
+	for _, candle := range candles {
+		closes = append(closes, candle.Close)
+		if candle.High == 0 && candle.Low == 0 {
+			highs, lows = append(highs, candle.Close), append(lows, candle.Close)
+		} else {
+			highs, lows = append(highs, candle.High), append(lows, candle.Low)
+		}
+	}
+
+	var stoch *indicators.StochasticSeries
+	if s.OfRSI {
+		stoch = indicators.StochRSI(closes, length, stochasticLength, stochasticSmoothing, stochasticSignal)
+	} else {
+		stoch = indicators.Stochastic(highs, lows, closes, stochasticLength, stochasticSmoothing, stochasticSignal)
+	}
+
+	last := len(closes) - 1
+	if len(closes) < s.minData() {
+		return NewEvaluationResult(false, fmt.Sprintf("lack %d data", s.minData()))
+	}
+
+	k, d := stoch.K[last], stoch.D[last]
+
+	// The latest cross still holding, within the recent candles
+	candlesAgo := -1
+	if (k > d) == (s.Type == Buy) {
+		for i := last; i > 0 && i > last-crossRecency && !math.IsNaN(stoch.D[i-1]); i-- {
+			if (stoch.K[i-1] > stoch.D[i-1]) != (k > d) {
+				if s.isInZone(stoch.D[i]) {
+					candlesAgo = last - i
+				}
+				break
+			}
+		}
+	}
+
+	return NewEvaluationResult(candlesAgo >= 0, s.getEvaluationMessage(k, d, candlesAgo))
+}
+
+// minData is enough candles for %D and the one before it, to tell a cross.
+func (s *Stochastic) minData() int {
+	n := stochasticLength + stochasticSmoothing + stochasticSignal - 1
+	if s.OfRSI {
+		n += length
+	}
+
+	return n
+}
+
+func (s *Stochastic) isInZone(value float64) bool {
+	if s.Type == Sell {
+		return value >= s.Level
+	}
+
+	return value <= s.Level
+}
+
+func (s *Stochastic) getEvaluationMessage(k, d float64, candlesAgo int) string {
+	if candlesAgo < 0 {
+		return fmt.Sprintf("%%K of %0.2f and %%D of %0.2f not crossing in %s zone", k, d, s.GetName())
+	}
+
+	side := "above"
+	if s.Type == Sell {
+		side = "below"
+	}
+
+	return fmt.Sprintf("%s %s! %%K of %0.2f crossed %s %%D of %0.2f in %s zone %s",
+		s.Strength, s.Type, k, side, d, s.GetName(), formatCandlesAgo(candlesAgo))
+}
@@ -0,0 +1,99 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+)
+
+// bottoming is prices falling from 40 to 21, then rising by one for each of the up candles.
+func bottoming(up int) []float64 {
+	prices := make([]float64, 0, 20+up)
+	for i := 0; i < 20; i++ {
+		prices = append(prices, 40-float64(i))
+	}
+	for i := 1; i <= up; i++ {
+		prices = append(prices, 21+float64(i))
+	}
+	return prices
+}
+
+// topping is prices rising to 44 with a dip on the way, then falling by two for each of the down candles.
+func topping(down int) []float64 {
+	prices := make([]float64, 0, 21+down)
+	for i := 0; i < 16; i++ {
+		prices = append(prices, 20+float64(i))
+	}
+	prices = append(prices, 33, 32, 36, 40, 44)
+	for i := 1; i <= down; i++ {
+		prices = append(prices, 44-2*float64(i))
+	}
+	return prices
+}
+
+// rsiBottoming is prices going sideways, falling for 10 candles, then rising by one for each of the up candles.
+func rsiBottoming(up int) []float64 {
+	prices := alternating(100, 102, 30)
+	for i := 1; i <= 10; i++ {
+		prices = append(prices, 100-float64(i))
+	}
+	for i := 1; i <= up; i++ {
+		prices = append(prices, 90+float64(i))
+	}
+	return prices
+}
+
+func TestStochastic(t *testing.T) {
+	buy, sell := NewStochastic(20, Strong, Buy), NewStochastic(80, Strong, Sell)
+	stochRSI := NewStochRSI(20, Strong, Buy)
+
+	tests := []struct {
+		name      string
+		strategy  *Stochastic
+		data      []float64
+		fulfilled bool
+		message   string
+	}{
+		{"lacking data", buy, bottoming(0)[:18], false, "lack 19 data"},
+		{"still falling", buy, bottoming(0), false, "not crossing"},
+		{"crossing up on the latest candle", buy, bottoming(1), true, "crossed above %D of 0.93 in stoch20buy zone on the latest candle"},
+		{"crossing up 4 candles ago", buy, bottoming(5), true, "4 candles ago"},
+		{"crossing up too long ago", buy, bottoming(6), false, "not crossing"},
+		{"crossing up for Sell", sell, bottoming(1), false, "not crossing"},
+		{"flat prices", buy, alternating(10, 10, 30), false, "not crossing"},
+		{"crossing down in the zone", sell, topping(2), true, "crossed below %D of 94.26 in stoch80sell zone on the latest candle"},
+		// %D is at 94.26 where %K crosses it, under the 95 zone
+		{"crossing down outside the zone", NewStochastic(95, Strong, Sell), topping(2), false, "not crossing"},
+		{"crossing down 4 candles ago", sell, topping(6), true, "4 candles ago"},
+		{"lacking data for StochRSI", stochRSI, rsiBottoming(0)[:32], false, "lack 33 data"},
+		{"StochRSI crossing up", stochRSI, rsiBottoming(1), true, "in stochrsi20buy zone on the latest candle"},
+		{"StochRSI crossing up too long ago", stochRSI, rsiBottoming(6), false, "not crossing"},
+	}
+
+	for _, tt := range tests {
+		res := tt.strategy.Evaluate(tt.data)
+		if res.IsFulfilled != tt.fulfilled || !strings.Contains(res.EvaluationMessage, tt.message) {
+			t.Errorf("%s: fulfilled %t with %q, want %t with %q",
+				tt.name, res.IsFulfilled, res.EvaluationMessage, tt.fulfilled, tt.message)
+		}
+	}
+}
+
+func TestStochasticCandles(t *testing.T) {
+	buy := NewStochastic(20, Strong, Buy)
+	closes := bottoming(1)
+
+	// Candles without highs and lows are the closes
+	if res := buy.EvaluateCandles(closeCandles(closes)); !res.IsFulfilled {
+		t.Errorf("close-only candles: %q, want the cross of the closes", res.EvaluationMessage)
+	}
+
+	// Ranges 10 wide around the closes keep %D out of the oversold zone
+	candles := make([]Candle, 0, len(closes))
+	for _, price := range closes {
+		candles = append(candles, Candle{Open: price, High: price + 10, Low: price - 10, Close: price})
+	}
+
+	if res := buy.EvaluateCandles(candles); res.IsFulfilled {
+		t.Errorf("wide candles: %q, want no cross in the zone", res.EvaluationMessage)
+	}
+}
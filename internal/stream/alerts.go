@@ -0,0 +1,129 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/signalb/internal/database"
+)
+
+const (
+	AboveCondition = "above"
+	BelowCondition = "below"
+)
+
+var AllowedConditions = []string{AboveCondition, BelowCondition}
+
+// NotifyFunc delivers a triggered alert and the price that triggered it, e.g. through the notifier routes.
+type NotifyFunc func(ctx context.Context, alert database.PriceAlert, price float64) error
+
+// AlertManager holds the active price alerts in memory so every streamed trade can be checked without
+// a database round trip. Alerts fire once, when the price reaches their level.
+type AlertManager struct {
+	mu     sync.Mutex
+	alerts map[string][]database.PriceAlert
+	notify NotifyFunc
+}
+
+func NewAlertManager(notify NotifyFunc) *AlertManager {
+	return &AlertManager{
+		alerts: make(map[string][]database.PriceAlert),
+		notify: notify,
+	}
+}
+
+// Load replaces the in-memory alerts with the active ones stored in the database.
+func (am *AlertManager) Load(c context.Context) error {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	alerts, err := database.Client.GetPriceAlerts(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	byTicker := make(map[string][]database.PriceAlert)
+	for _, alert := range alerts {
+		byTicker[alert.TickerSymbol] = append(byTicker[alert.TickerSymbol], alert)
+	}
+
+	am.mu.Lock()
+	am.alerts = byTicker
+	am.mu.Unlock()
+
+	return nil
+}
+
+func (am *AlertManager) Add(alert database.PriceAlert) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	am.alerts[alert.TickerSymbol] = append(am.alerts[alert.TickerSymbol], alert)
+}
+
+func (am *AlertManager) Remove(id int64) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	for symbol, alerts := range am.alerts {
+		for i, alert := range alerts {
+			if alert.ID == id {
+				am.alerts[symbol] = append(alerts[:i:i], alerts[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Check fires the ticker's alerts the price has reached and takes them out of the active set.
+// The fired alerts are returned for the caller to persist.
+func (am *AlertManager) Check(tickerSymbol string, price float64, t time.Time) []database.PriceAlert {
+	am.mu.Lock()
+
+	var (
+		fired     []database.PriceAlert
+		remaining []database.PriceAlert
+	)
+
+	for _, alert := range am.alerts[tickerSymbol] {
+		reached := (alert.Condition == AboveCondition && price >= alert.Price) ||
+			(alert.Condition == BelowCondition && price <= alert.Price)
+
+		if !reached {
+			remaining = append(remaining, alert)
+			continue
+		}
+
+		triggeredAt := t
+		alert.TriggeredAt = &triggeredAt
+		fired = append(fired, alert)
+	}
+
+	if len(fired) > 0 {
+		am.alerts[tickerSymbol] = remaining
+	}
+
+	am.mu.Unlock()
+
+	// Sending happens off the stream's read loop, a slow chat API shouldn't hold up trades
+	for _, alert := range fired {
+		if am.notify == nil {
+			continue
+		}
+
+		go func(alert database.PriceAlert) {
+			if err := am.notify(context.Background(), alert, price); err != nil {
+				log.Printf("error notifying price alert %d for %s: %v", alert.ID, alert.TickerSymbol, err)
+			}
+		}(alert)
+	}
+
+	return fired
+}
+
+func formatAlertMessage(alert database.PriceAlert, price float64) string {
+	return fmt.Sprintf("🔔 Price %v is %s %v", price, alert.Condition, alert.Price)
+}
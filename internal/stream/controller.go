@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	errorsStdLib "errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/errors"
+)
+
+func GetStreamPricesController(c *gin.Context) {
+	var status *StreamStatus
+	if Streamer != nil {
+		status = Streamer.Status()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": Streamer != nil,
+		"status":  status,
+		"prices":  StreamPrices.Snapshots(),
+	})
+}
+
+func GetStreamPriceByTickerController(c *gin.Context) {
+	tickerSymbol := c.Param("ticker")
+
+	snapshot, ok := StreamPrices.Snapshot(tickerSymbol)
+	if !ok {
+		c.JSON(http.StatusNotFound,
+			errors.NewErrorResp(fmt.Errorf("no streamed price for %s", tickerSymbol)))
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+func CreatePriceAlertController(c *gin.Context) {
+	var req CreatePriceAlertReq
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.RequestDeserializationError, err)))
+		return
+	}
+
+	if !slices.Contains(AllowedConditions, req.Condition) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("valid conditions: %v", AllowedConditions)))
+		return
+	}
+
+	if req.Price <= 0 {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(errorsStdLib.New("price must be positive")))
+		return
+	}
+
+	if !isTickerRegistered(c.Request.Context(), req.TickerSymbol) {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("%s is not registered", req.TickerSymbol)))
+		return
+	}
+
+	alert, err := insertPriceAlert(c.Request.Context(), &database.PriceAlert{
+		TickerSymbol: req.TickerSymbol,
+		Condition:    req.Condition,
+		Price:        req.Price,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseInsertionError, err)))
+		return
+	}
+
+	Alerts.Add(*alert)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"alert": alert,
+	})
+}
+
+func isTickerRegistered(c context.Context, tickerSymbol string) bool {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.IsTickerRegistered(ctx, tickerSymbol)
+}
+
+func insertPriceAlert(c context.Context, alert *database.PriceAlert) (*database.PriceAlert, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	id, err := database.Client.InsertPriceAlert(ctx, alert)
+	if err != nil {
+		return nil, err
+	}
+
+	alert.ID = id
+	alert.CreatedAt = time.Now().UTC()
+
+	return alert, nil
+}
+
+func GetPriceAlertsController(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	activeOnly := c.Query("active") == "true"
+
+	alerts, err := database.Client.GetPriceAlerts(ctx, activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": alerts,
+	})
+}
+
+func DeletePriceAlertController(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest,
+			errors.NewErrorResp(fmt.Errorf("invalid alert id: %w", err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	err = database.Client.DeletePriceAlert(ctx, id)
+	if errorsStdLib.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound,
+			errors.NewErrorResp(fmt.Errorf("alert %d not found", id)))
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError,
+			errors.NewErrorResp(fmt.Errorf("delete alert: %w", err)))
+		return
+	}
+
+	Alerts.Remove(id)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("alert %d deleted successfully", id),
+	})
+}
@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/signalb/internal/timeframe"
+)
+
+// LivePrice is the latest streamed trade of a ticker.
+type LivePrice struct {
+	TickerSymbol string    `json:"tickerSymbol"`
+	Price        float64   `json:"price"`
+	Time         time.Time `json:"time"`
+}
+
+// FormingCandle is the candle the streamed trades are currently building, it isn't final until Start
+// plus the timeframe's length has passed.
+type FormingCandle struct {
+	Timeframe string    `json:"timeframe"`
+	Start     time.Time `json:"start"`
+	Open      float64   `json:"open"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Close     float64   `json:"close"`
+	Trades    int       `json:"trades"`
+}
+
+// TickerSnapshot is a copy of a ticker's streamed state, safe to hand out.
+type TickerSnapshot struct {
+	LivePrice
+	Candles []FormingCandle `json:"candles"`
+}
+
+// PriceBook keeps the last price and the forming candle of each timeframe for every streamed ticker.
+type PriceBook struct {
+	mu      sync.RWMutex
+	prices  map[string]LivePrice
+	candles map[string]map[string]*FormingCandle
+}
+
+func NewPriceBook() *PriceBook {
+	return &PriceBook{
+		prices:  make(map[string]LivePrice),
+		candles: make(map[string]map[string]*FormingCandle),
+	}
+}
+
+// Update records a trade, returning the price it replaced and whether there was one. Trades older than
+// the last one are ignored since feeds don't guarantee ordering across reconnects.
+func (pb *PriceBook) Update(tickerSymbol string, price float64, t time.Time) (float64, bool) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	prev, hadPrev := pb.prices[tickerSymbol]
+	if hadPrev && t.Before(prev.Time) {
+		return prev.Price, true
+	}
+
+	pb.prices[tickerSymbol] = LivePrice{
+		TickerSymbol: tickerSymbol,
+		Price:        price,
+		Time:         t,
+	}
+
+	candles, ok := pb.candles[tickerSymbol]
+	if !ok {
+		candles = make(map[string]*FormingCandle, len(timeframe.AllowedTimeframes))
+		pb.candles[tickerSymbol] = candles
+	}
+
+	for _, tf := range timeframe.AllowedTimeframes {
+		start := candleStart(tf, t)
+
+		candle, ok := candles[tf]
+		if !ok || !candle.Start.Equal(start) {
+			candles[tf] = &FormingCandle{
+				Timeframe: tf,
+				Start:     start,
+				Open:      price,
+				High:      price,
+				Low:       price,
+				Close:     price,
+				Trades:    1,
+			}
+			continue
+		}
+
+		candle.High = max(candle.High, price)
+		candle.Low = min(candle.Low, price)
+		candle.Close = price
+		candle.Trades++
+	}
+
+	return prev.Price, hadPrev
+}
+
+func (pb *PriceBook) Snapshot(tickerSymbol string) (*TickerSnapshot, bool) {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+
+	price, ok := pb.prices[tickerSymbol]
+	if !ok {
+		return nil, false
+	}
+
+	snapshot := &TickerSnapshot{
+		LivePrice: price,
+		Candles:   make([]FormingCandle, 0, len(timeframe.AllowedTimeframes)),
+	}
+
+	for _, tf := range timeframe.AllowedTimeframes {
+		if candle, ok := pb.candles[tickerSymbol][tf]; ok {
+			snapshot.Candles = append(snapshot.Candles, *candle)
+		}
+	}
+
+	return snapshot, true
+}
+
+func (pb *PriceBook) Snapshots() []*TickerSnapshot {
+	pb.mu.RLock()
+	symbols := make([]string, 0, len(pb.prices))
+	for symbol := range pb.prices {
+		symbols = append(symbols, symbol)
+	}
+	pb.mu.RUnlock()
+
+	slices.Sort(symbols)
+
+	snapshots := make([]*TickerSnapshot, 0, len(symbols))
+	for _, symbol := range symbols {
+		if snapshot, ok := pb.Snapshot(symbol); ok {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots
+}
+
+// candleStart buckets crypto trades, which trade around the clock, into UTC candles: 4 hour blocks from
+// midnight, days, and weeks starting Monday.
+func candleStart(tf string, t time.Time) time.Time {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch tf {
+	case timeframe.Hour4:
+		return midnight.Add(time.Duration(t.Hour()/4) * 4 * time.Hour)
+	case timeframe.Week1:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return midnight.AddDate(0, 0, -daysSinceMonday)
+	default:
+		return midnight
+	}
+}
@@ -0,0 +1,7 @@
+package stream
+
+type CreatePriceAlertReq struct {
+	TickerSymbol string  `json:"tickerSymbol"`
+	Condition    string  `json:"condition"` // above or below
+	Price        float64 `json:"price"`
+}
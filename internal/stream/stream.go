@@ -0,0 +1,396 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/notifier"
+	"github.com/signalb/internal/ticker"
+	"nhooyr.io/websocket"
+)
+
+const (
+	defaultReloadInterval = time.Minute
+	minReconnectBackoff   = time.Second
+	maxReconnectBackoff   = time.Minute
+)
+
+var (
+	Streamer     *TradeStreamer
+	StreamPrices *PriceBook
+	Alerts       *AlertManager
+)
+
+// tradeMessage is a trade event of a Binance style feed. Combined streams wrap it as
+// {"stream": "btcusdt@trade", "data": {...}}.
+type tradeMessage struct {
+	Event     string `json:"e"`
+	Symbol    string `json:"s"`
+	Price     string `json:"p"`
+	TradeTime int64  `json:"T"`
+}
+
+type combinedMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// TradeStreamer subscribes to the trade feeds of every crypto ticker and feeds the trades into the price book
+// and the price alerts. It reconnects with backoff when the feed drops, and resubscribes when tickers change.
+type TradeStreamer struct {
+	baseURL        string
+	book           *PriceBook
+	alerts         *AlertManager
+	reloadInterval time.Duration
+
+	mu             sync.RWMutex
+	streamToTicker map[string]string
+	connected      bool
+	lastMessageAt  time.Time
+}
+
+func NewTradeStreamer(baseURL string, book *PriceBook, alerts *AlertManager, reloadInterval time.Duration) *TradeStreamer {
+	return &TradeStreamer{
+		baseURL:        baseURL,
+		book:           book,
+		alerts:         alerts,
+		reloadInterval: reloadInterval,
+		streamToTicker: make(map[string]string),
+	}
+}
+
+// StreamStatus reports what the streamer is subscribed to and whether the feed is up.
+type StreamStatus struct {
+	Connected     bool      `json:"connected"`
+	Tickers       []string  `json:"tickers"`
+	LastMessageAt time.Time `json:"lastMessageAt,omitempty"`
+}
+
+func (s *TradeStreamer) Status() *StreamStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tickers := make([]string, 0, len(s.streamToTicker))
+	for _, tickerSymbol := range s.streamToTicker {
+		tickers = append(tickers, tickerSymbol)
+	}
+	slices.Sort(tickers)
+
+	return &StreamStatus{
+		Connected:     s.connected,
+		Tickers:       tickers,
+		LastMessageAt: s.lastMessageAt,
+	}
+}
+
+// SetTickers subscribes to the given stream symbols, keyed to the tickers they stand for, reporting whether
+// the subscription changed.
+func (s *TradeStreamer) SetTickers(streamToTicker map[string]string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(streamToTicker) == len(s.streamToTicker) {
+		same := true
+		for stream, tickerSymbol := range streamToTicker {
+			if s.streamToTicker[stream] != tickerSymbol {
+				same = false
+				break
+			}
+		}
+
+		if same {
+			return false
+		}
+	}
+
+	s.streamToTicker = streamToTicker
+	return true
+}
+
+// Run keeps a connection to the feed until ctx is done. Tickers are reloaded from the database every reload
+// interval, a changed subscription reconnects with the new streams.
+func (s *TradeStreamer) Run(ctx context.Context) {
+	backoff := minReconnectBackoff
+
+	for ctx.Err() == nil {
+		if _, err := s.reloadTickers(ctx); err != nil {
+			log.Println("error loading stream tickers", err)
+		}
+
+		if len(s.Status().Tickers) == 0 {
+			if err := sleepCtx(ctx, s.reloadInterval); err != nil {
+				return
+			}
+			continue
+		}
+
+		err := s.connectAndRead(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, errResubscribe) {
+			backoff = minReconnectBackoff
+			continue
+		}
+
+		// Full jitter keeps reconnecting instances from hammering the feed together
+		wait := time.Duration(rand.Int63n(int64(backoff))) //nolint:gosec // jitter doesn't need crypto/rand
+		log.Printf("Trade stream disconnected, reconnecting in %s: %v", wait, err)
+
+		if err := sleepCtx(ctx, wait); err != nil {
+			return
+		}
+
+		backoff = min(2*backoff, maxReconnectBackoff)
+	}
+}
+
+var errResubscribe = errors.New("stream tickers changed")
+
+func (s *TradeStreamer) connectAndRead(c context.Context) error {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	endpoint, err := s.streamURL()
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.Dial(ctx, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("dial trade stream: %w", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	s.setConnected(true)
+	defer s.setConnected(false)
+
+	log.Printf("Connected to trade stream for %v", s.Status().Tickers)
+
+	// Reloading runs next to the read loop, which only returns on errors
+	var resubscribe atomic.Bool
+	go func() {
+		reloadTicker := time.NewTicker(s.reloadInterval)
+		defer reloadTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reloadTicker.C:
+				changed, err := s.reloadTickers(ctx)
+				if err != nil {
+					log.Println("error reloading stream tickers", err)
+					continue
+				}
+
+				if changed {
+					resubscribe.Store(true)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, msg, err := conn.Read(ctx)
+		if err != nil {
+			if resubscribe.Load() {
+				return errResubscribe
+			}
+			return err
+		}
+
+		if err := s.handleMessage(ctx, msg); err != nil {
+			log.Println("error handling trade stream message", err)
+		}
+	}
+}
+
+// streamURL adds the subscribed trade streams to the base URL, e.g. /stream?streams=btcusdt@trade/ethusdt@trade.
+func (s *TradeStreamer) streamURL() (string, error) {
+	parsed, err := url.Parse(s.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("parse stream url: %w", err)
+	}
+
+	s.mu.RLock()
+	streams := make([]string, 0, len(s.streamToTicker))
+	for stream := range s.streamToTicker {
+		streams = append(streams, stream+"@trade")
+	}
+	s.mu.RUnlock()
+
+	slices.Sort(streams)
+
+	// Feeds expect the separators unescaped
+	streamsQuery := "streams=" + strings.Join(streams, "/")
+	if parsed.RawQuery != "" {
+		streamsQuery = parsed.RawQuery + "&" + streamsQuery
+	}
+	parsed.RawQuery = streamsQuery
+
+	return parsed.String(), nil
+}
+
+func (s *TradeStreamer) handleMessage(ctx context.Context, msg []byte) error {
+	var combined combinedMessage
+	if err := json.Unmarshal(msg, &combined); err != nil {
+		return err
+	}
+
+	if combined.Data != nil {
+		msg = combined.Data
+	}
+
+	var trade tradeMessage
+	if err := json.Unmarshal(msg, &trade); err != nil {
+		return err
+	}
+
+	// Subscription acks and other events carry no trade
+	if trade.Event != "trade" {
+		return nil
+	}
+
+	price, err := strconv.ParseFloat(trade.Price, 64)
+	if err != nil {
+		return fmt.Errorf("parse trade price %q: %w", trade.Price, err)
+	}
+
+	s.mu.Lock()
+	tickerSymbol, ok := s.streamToTicker[strings.ToLower(trade.Symbol)]
+	s.lastMessageAt = time.Now()
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	tradeTime := time.UnixMilli(trade.TradeTime).UTC()
+	s.book.Update(tickerSymbol, price, tradeTime)
+
+	for _, alert := range s.alerts.Check(tickerSymbol, price, tradeTime) {
+		if err := markAlertTriggered(ctx, alert); err != nil {
+			log.Printf("error marking price alert %d triggered: %v", alert.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *TradeStreamer) setConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connected = connected
+}
+
+func (s *TradeStreamer) reloadTickers(c context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	tickers, err := database.Client.GetTickers(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	streamToTicker := make(map[string]string)
+	for _, t := range tickers {
+		if t.Class != ticker.CryptoClass {
+			continue
+		}
+
+		streamToTicker[getStreamSymbol(t)] = t.Symbol
+	}
+
+	return s.SetTickers(streamToTicker), nil
+}
+
+// getStreamSymbol prefers the registered stream symbol, then pairs the CoinAPI asset with USDT.
+func getStreamSymbol(t database.Ticker) string {
+	if symbol, ok := t.ProviderSymbols[ticker.StreamSymbol]; ok {
+		return strings.ToLower(symbol)
+	}
+
+	if asset, ok := t.ProviderSymbols[ticker.CoinAPIAsset]; ok {
+		return strings.ToLower(asset) + "usdt"
+	}
+
+	return strings.ToLower(t.Symbol) + "usdt"
+}
+
+func markAlertTriggered(c context.Context, alert database.PriceAlert) error {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.MarkPriceAlertTriggered(ctx, alert.ID, *alert.TriggeredAt)
+}
+
+// dispatchAlert sends a triggered alert as a result of the live timeframe, so it goes to the channels
+// routed the whole ticker, and to the default channel like other results.
+func dispatchAlert(ctx context.Context, alert database.PriceAlert, price float64) error {
+	return notifier.Dispatch(ctx, &notifier.Message{
+		Timeframe: notifier.LiveTimeframe,
+		Results: []notifier.Result{{
+			TickerSymbol: alert.TickerSymbol,
+			Timeframe:    notifier.LiveTimeframe,
+			Strategy:     "alert",
+			IsFulfilled:  true,
+			Message:      formatAlertMessage(alert, price),
+		}},
+	})
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// InitStream starts streaming when STREAM_WS_URL is set, e.g. wss://stream.binance.com:9443/stream.
+// Pointing it at a local WebSocket server replays test trades. Needs the database and the notifiers.
+func InitStream() {
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		log.Println("Failed to load .env file", err)
+	}
+
+	StreamPrices = NewPriceBook()
+	Alerts = NewAlertManager(dispatchAlert)
+
+	if err := Alerts.Load(context.Background()); err != nil {
+		log.Println("error loading price alerts", err)
+	}
+
+	baseURL := os.Getenv("STREAM_WS_URL")
+	if baseURL == "" {
+		log.Println("STREAM_WS_URL not set, price streaming disabled")
+		return
+	}
+
+	Streamer = NewTradeStreamer(baseURL, StreamPrices, Alerts, defaultReloadInterval)
+	go Streamer.Run(context.Background())
+}
@@ -0,0 +1,255 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+	"github.com/signalb/internal/ticker"
+	"github.com/signalb/internal/timeframe"
+	"nhooyr.io/websocket"
+)
+
+func useTickers(t *testing.T, tickers ...database.Ticker) *dbtest.DB {
+	t.Helper()
+
+	db := dbtest.Use(t)
+	db.SetTickers(tickers...)
+
+	return db
+}
+
+// feedServer is a trade feed that hands each connection to the next script, the last one is reused. It
+// records the streams every connection subscribed to.
+type feedServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	scripts []func(ctx context.Context, conn *websocket.Conn)
+	streams []string
+}
+
+func newFeedServer(t *testing.T, scripts ...func(ctx context.Context, conn *websocket.Conn)) *feedServer {
+	t.Helper()
+
+	fs := &feedServer{scripts: scripts}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("accept: %v", err)
+			return
+		}
+		defer conn.Close(websocket.StatusInternalError, "")
+
+		fs.mu.Lock()
+		fs.streams = append(fs.streams, r.URL.Query().Get("streams"))
+		script := fs.scripts[min(len(fs.streams), len(fs.scripts))-1]
+		fs.mu.Unlock()
+
+		script(r.Context(), conn)
+	}))
+
+	return fs
+}
+
+func (fs *feedServer) url() string {
+	return "ws" + strings.TrimPrefix(fs.URL, "http") + "/stream"
+}
+
+func (fs *feedServer) subscriptions() []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return append([]string(nil), fs.streams...)
+}
+
+func writeTrade(ctx context.Context, t *testing.T, conn *websocket.Conn, symbol string, price float64, at time.Time) {
+	t.Helper()
+
+	msg := fmt.Sprintf(`{"stream":"%s@trade","data":{"e":"trade","s":"%s","p":"%v","T":%d}}`,
+		strings.ToLower(symbol), symbol, price, at.UnixMilli())
+	if err := conn.Write(ctx, websocket.MessageText, []byte(msg)); err != nil {
+		t.Errorf("write trade: %v", err)
+	}
+}
+
+// holdOpen keeps the connection up until the client goes away.
+func holdOpen(ctx context.Context, conn *websocket.Conn) {
+	<-conn.CloseRead(ctx).Done()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// runStreamer runs the streamer until the test ends, waiting for it to stop before the feed shuts down.
+func runStreamer(t *testing.T, s *TradeStreamer) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestTradeStreamerReconnectsAndUpdatesPrices(t *testing.T) {
+	db := useTickers(t,
+		database.Ticker{Symbol: "BTC", Class: ticker.CryptoClass,
+			ProviderSymbols: map[string]string{ticker.StreamSymbol: "BTCUSDT"}},
+		database.Ticker{Symbol: "AAPL", Class: ticker.StockClass},
+	)
+
+	start := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+
+	feed := newFeedServer(t,
+		func(ctx context.Context, conn *websocket.Conn) {
+			// A subscription ack carries no trade and is skipped
+			if err := conn.Write(ctx, websocket.MessageText, []byte(`{"result":null,"id":1}`)); err != nil {
+				t.Errorf("write ack: %v", err)
+			}
+			writeTrade(ctx, t, conn, "BTCUSDT", 100, start)
+			writeTrade(ctx, t, conn, "BTCUSDT", 105, start.Add(time.Minute))
+			conn.Close(websocket.StatusGoingAway, "feed restarting")
+		},
+		func(ctx context.Context, conn *websocket.Conn) {
+			writeTrade(ctx, t, conn, "BTCUSDT", 99, start.Add(2*time.Minute))
+			holdOpen(ctx, conn)
+		},
+	)
+	defer feed.Close()
+
+	type notification struct {
+		alert database.PriceAlert
+		price float64
+	}
+	notified := make(chan notification, 1)
+
+	book := NewPriceBook()
+	alerts := NewAlertManager(func(_ context.Context, alert database.PriceAlert, price float64) error {
+		notified <- notification{alert: alert, price: price}
+		return nil
+	})
+	alerts.Add(database.PriceAlert{ID: 1, TickerSymbol: "BTC", Condition: AboveCondition, Price: 104})
+	alerts.Add(database.PriceAlert{ID: 2, TickerSymbol: "BTC", Condition: BelowCondition, Price: 90})
+
+	runStreamer(t, NewTradeStreamer(feed.url(), book, alerts, time.Hour))
+
+	waitFor(t, "the trade after reconnecting", func() bool {
+		snapshot, ok := book.Snapshot("BTC")
+		return ok && snapshot.Price == 99
+	})
+
+	if streams := feed.subscriptions(); len(streams) != 2 || streams[0] != "btcusdt@trade" || streams[1] != "btcusdt@trade" {
+		t.Errorf("subscriptions = %q, want btcusdt@trade on both connections", streams)
+	}
+
+	snapshot, _ := book.Snapshot("BTC")
+	if !snapshot.Time.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("last trade time = %s, want %s", snapshot.Time, start.Add(2*time.Minute))
+	}
+
+	var daily *FormingCandle
+	for i := range snapshot.Candles {
+		if snapshot.Candles[i].Timeframe == timeframe.Day1 {
+			daily = &snapshot.Candles[i]
+		}
+	}
+	if daily == nil {
+		t.Fatal("no forming daily candle")
+	}
+
+	want := FormingCandle{Timeframe: timeframe.Day1, Start: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		Open: 100, High: 105, Low: 99, Close: 99, Trades: 3}
+	if *daily != want {
+		t.Errorf("daily candle = %+v, want %+v", *daily, want)
+	}
+
+	select {
+	case n := <-notified:
+		if n.alert.ID != 1 || n.price != 105 {
+			t.Errorf("notified alert %d at %v, want alert 1 at 105", n.alert.ID, n.price)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert wasn't notified")
+	}
+
+	triggeredAt, ok := db.TriggeredAt(1)
+	if !ok || !triggeredAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("alert 1 triggered at %v (marked %t), want %s", triggeredAt, ok, start.Add(time.Minute))
+	}
+
+	if _, ok := db.TriggeredAt(2); ok {
+		t.Error("alert 2 triggered, the price never went below 90")
+	}
+}
+
+func TestTradeStreamerResubscribesWhenTickersChange(t *testing.T) {
+	db := useTickers(t, database.Ticker{Symbol: "BTC", Class: ticker.CryptoClass})
+
+	feed := newFeedServer(t, holdOpen)
+	defer feed.Close()
+
+	streamer := NewTradeStreamer(feed.url(), NewPriceBook(), NewAlertManager(nil), 20*time.Millisecond)
+	runStreamer(t, streamer)
+
+	waitFor(t, "the first connection", func() bool { return len(feed.subscriptions()) == 1 })
+
+	db.SetTickers(
+		database.Ticker{Symbol: "BTC", Class: ticker.CryptoClass},
+		database.Ticker{Symbol: "ETH", Class: ticker.CryptoClass,
+			ProviderSymbols: map[string]string{ticker.CoinAPIAsset: "ETH"}},
+	)
+
+	waitFor(t, "the resubscription", func() bool { return len(feed.subscriptions()) == 2 })
+
+	if streams := feed.subscriptions(); streams[0] != "btcusdt@trade" || streams[1] != "btcusdt@trade/ethusdt@trade" {
+		t.Errorf("subscriptions = %q, want btcusdt@trade then btcusdt@trade/ethusdt@trade", streams)
+	}
+
+	waitFor(t, "the status to list both tickers", func() bool {
+		status := streamer.Status()
+		return status.Connected && len(status.Tickers) == 2
+	})
+}
+
+func TestCandleStart(t *testing.T) {
+	// A Wednesday afternoon
+	trade := time.Date(2024, 5, 8, 14, 25, 0, 0, time.UTC)
+
+	tests := []struct {
+		timeframe string
+		want      time.Time
+	}{
+		{timeframe.Hour4, time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)},
+		{timeframe.Day1, time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC)},
+		{timeframe.Week1, time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := candleStart(tt.timeframe, trade); !got.Equal(tt.want) {
+			t.Errorf("candleStart(%s) = %s, want %s", tt.timeframe, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"sync"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
@@ -13,7 +14,12 @@ var Bot *BotClient
 
 type BotClient struct {
 	DefaultChatID int64
-	BotAPI        *tgbotapi.BotAPI
+	// AdminChatIDs are the chats allowed to run the registered commands
+	AdminChatIDs []int64
+	BotAPI       *tgbotapi.BotAPI
+
+	mu       sync.RWMutex
+	commands map[string]command
 }
 
 func InitBot() {
@@ -36,6 +42,8 @@ func InitBot() {
 	Bot = &BotClient{
 		BotAPI:        bot,
 		DefaultChatID: chatID,
+		AdminChatIDs:  getAdminChatIDs(chatID),
+		commands:      make(map[string]command),
 	}
 
 	log.Printf("Authorized on account %s", bot.Self.UserName)
@@ -49,6 +57,19 @@ func (b *BotClient) listenForUpdates() {
 	updates := b.BotAPI.GetUpdatesChan(u)
 
 	for update := range updates {
+		// Choices of an inline keyboard carry the command they run
+		if query := update.CallbackQuery; query != nil {
+			if _, err := b.BotAPI.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+				log.Println("error answering callback query", err)
+			}
+
+			if query.Message != nil {
+				log.Printf("%s chose %s", query.From.FirstName, query.Data)
+				go b.runCommand(query.Message.Chat, query.Data)
+			}
+			continue
+		}
+
 		message := update.Message
 		if update.Message == nil { // ignore any non-Message updates
 			continue
@@ -60,18 +81,7 @@ func (b *BotClient) listenForUpdates() {
 
 		log.Printf("%s wrote %s", message.From.FirstName, message.Text)
 
-		var text string
-
-		switch update.Message.Command() {
-		case "register":
-			text = "Multi-user registration not allowed at the moment."
-		default:
-			text = "Invalid command. /register command would be supported in the future."
-		}
-
-		if err := b.SendMessageByHTML(update.Message.Chat.ID, text); err != nil {
-			log.Println("error sending message to user", err)
-		}
+		go b.runCommand(message.Chat, message.Text)
 	}
 }
 
@@ -94,3 +104,13 @@ func (b *BotClient) SendMessageByHTML(chatID int64, message string) error {
 	_, err := b.BotAPI.Send(msg)
 	return err
 }
+
+// SendPhotoByHTML sends a PNG with an HTML caption.
+func (b *BotClient) SendPhotoByHTML(chatID int64, png []byte, caption string) error {
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: png})
+	photo.Caption = caption
+	photo.ParseMode = tgbotapi.ModeHTML
+
+	_, err := b.BotAPI.Send(photo)
+	return err
+}
@@ -0,0 +1,242 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	commandTimeout = 30 * time.Second
+	// callbackDataLimit is the most bytes Telegram keeps as the data of an inline keyboard button
+	callbackDataLimit = 64
+	keyboardRowSize   = 3
+)
+
+// CommandRequest is a command sent in a chat, Args are the words that followed it.
+type CommandRequest struct {
+	ChatID   int64
+	ChatName string
+	Args     []string
+}
+
+type CommandHandler func(ctx context.Context, req *CommandRequest) (*Reply, error)
+
+// Access decides which chats a command answers.
+type Access int
+
+const (
+	// AdminAccess commands only answer the admin chats
+	AdminAccess Access = iota
+	// PublicAccess commands answer any chat, they check what the chat may do themselves
+	PublicAccess
+)
+
+// Reply is the HTML answer to a command. Choices are shown as an inline keyboard under it, tapping one
+// runs its command as if it was typed.
+type Reply struct {
+	Text    string
+	Choices []Choice
+}
+
+type Choice struct {
+	Label   string
+	Command string
+}
+
+type command struct {
+	description string
+	access      Access
+	handler     CommandHandler
+}
+
+// HandleCommand registers the handler of /name.
+func (b *BotClient) HandleCommand(name, description string, access Access, handler CommandHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.commands[name] = command{
+		description: description,
+		access:      access,
+		handler:     handler,
+	}
+}
+
+func (b *BotClient) getCommand(name string) (command, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	cmd, ok := b.commands[name]
+	return cmd, ok
+}
+
+func (b *BotClient) IsAdmin(chatID int64) bool {
+	for _, adminChatID := range b.AdminChatIDs {
+		if adminChatID == chatID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getAdminChatIDs reads the comma separated TELEGRAM_ADMIN_CHAT_IDS, the default chat is the only admin without it.
+func getAdminChatIDs(defaultChatID int64) []int64 {
+	val := os.Getenv("TELEGRAM_ADMIN_CHAT_IDS")
+	if val == "" {
+		return []int64{defaultChatID}
+	}
+
+	var chatIDs []int64
+	for _, field := range strings.Split(val, ",") {
+		chatID, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			log.Printf("invalid admin chat id %q: %v", field, err)
+			continue
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+
+	return chatIDs
+}
+
+// parseCommandLine splits "/bind@signalbot BTC D1" into "bind" and its arguments.
+func parseCommandLine(line string) (string, []string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil
+	}
+
+	name, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	return name, fields[1:]
+}
+
+// runCommand answers a typed command or a tapped choice in the chat it came from.
+func (b *BotClient) runCommand(chat *tgbotapi.Chat, line string) {
+	name, args := parseCommandLine(line)
+
+	req := &CommandRequest{
+		ChatID:   chat.ID,
+		ChatName: getChatName(chat),
+		Args:     args,
+	}
+
+	reply, err := b.answerCommand(name, req)
+	if err != nil {
+		reply = &Reply{Text: "⚠️ " + html.EscapeString(err.Error())}
+	}
+
+	if err := b.SendReply(chat.ID, reply); err != nil {
+		log.Println("error sending message to user", err)
+	}
+}
+
+func getChatName(chat *tgbotapi.Chat) string {
+	switch {
+	case chat.Title != "":
+		return chat.Title
+	case chat.UserName != "":
+		return "@" + chat.UserName
+	default:
+		return strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+	}
+}
+
+func (b *BotClient) answerCommand(name string, req *CommandRequest) (*Reply, error) {
+	if name == "help" || name == "start" {
+		return b.helpReply(req.ChatID), nil
+	}
+
+	cmd, ok := b.getCommand(name)
+	if !ok {
+		return &Reply{Text: "Invalid command, see /help for the supported ones."}, nil
+	}
+
+	if cmd.access == AdminAccess && !b.IsAdmin(req.ChatID) {
+		return &Reply{Text: "This chat is not allowed to manage the bot."}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	return cmd.handler(ctx, req)
+}
+
+// helpReply lists the commands the chat may run.
+func (b *BotClient) helpReply(chatID int64) *Reply {
+	isAdmin := b.IsAdmin(chatID)
+
+	b.mu.RLock()
+	names := make([]string, 0, len(b.commands))
+	for name, cmd := range b.commands {
+		if cmd.access == PublicAccess || isAdmin {
+			names = append(names, name)
+		}
+	}
+	b.mu.RUnlock()
+
+	sort.Strings(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		cmd, _ := b.getCommand(name)
+		builder.WriteString(fmt.Sprintf("/%s - %s\n", name, html.EscapeString(cmd.description)))
+	}
+
+	return &Reply{Text: builder.String()}
+}
+
+// SendReply sends the reply text with its choices as an inline keyboard.
+func (b *BotClient) SendReply(chatID int64, reply *Reply) error {
+	if reply == nil || reply.Text == "" {
+		return nil
+	}
+
+	msg := tgbotapi.NewMessage(chatID, reply.Text)
+	msg.ParseMode = tgbotapi.ModeHTML
+
+	if keyboard, ok := newChoiceKeyboard(reply.Choices); ok {
+		msg.ReplyMarkup = keyboard
+	}
+
+	_, err := b.BotAPI.Send(msg)
+	return err
+}
+
+func newChoiceKeyboard(choices []Choice) (tgbotapi.InlineKeyboardMarkup, bool) {
+	var (
+		rows [][]tgbotapi.InlineKeyboardButton
+		row  []tgbotapi.InlineKeyboardButton
+	)
+
+	for _, choice := range choices {
+		if len(choice.Command) > callbackDataLimit {
+			log.Printf("skipping choice %s, command %q is too long for a button", choice.Label, choice.Command)
+			continue
+		}
+
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(choice.Label, choice.Command))
+		if len(row) == keyboardRowSize {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), true
+}
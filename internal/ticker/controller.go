@@ -2,16 +2,24 @@ package ticker
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"slices"
 	"time"
 
+	errorsStdLib "errors"
+
 	"github.com/gin-gonic/gin"
 	"github.com/signalb/internal/database"
 	"github.com/signalb/internal/errors"
 )
 
+// ErrClassMismatch is registering a ticker again with another class than the one it's stored with.
+var ErrClassMismatch = errorsStdLib.New("ticker is registered with another class")
+
+// RegisterTicker creates a ticker or updates the provider symbols of a registered one, whose class can
+// be left out but not changed.
 func RegisterTicker(c *gin.Context) {
 	var req RegisterTickerReq
 
@@ -20,26 +28,90 @@ func RegisterTicker(c *gin.Context) {
 		return
 	}
 
-	if !slices.Contains(AllowedClasses, req.Class) {
-		c.JSON(http.StatusBadRequest, errors.NewErrorResp(fmt.Errorf("valid classes: %s", AllowedClasses)))
+	err := ResolveTickerClass(c.Request.Context(), &req)
+	if errorsStdLib.Is(err, ErrClassMismatch) {
+		c.JSON(http.StatusConflict, errors.NewErrorResp(err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
 		return
 	}
 
-	if err := insertTicker(c.Request.Context(), req.Symbol, req.Class); err != nil {
+	if err := ValidateRegisterTickerReq(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errors.NewErrorResp(err))
+		return
+	}
+
+	created, err := UpsertTicker(c.Request.Context(), req.Symbol, req.Class, req.ProviderSymbols)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseInsertionError, err)))
 		return
 	}
 
+	if !created {
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Provider symbols of ticker %s updated successfully", req.Symbol),
+		})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": fmt.Sprintf("Ticker %s of class %s created successfully", req.Symbol, req.Class),
 	})
 }
 
-func insertTicker(c context.Context, symbol, class string) error {
+// ResolveTickerClass sets the class of a registered ticker to the stored one when left out, so its provider
+// symbols are validated against the class its prices are fetched for. Asking for another class is ErrClassMismatch.
+func ResolveTickerClass(c context.Context, req *RegisterTickerReq) error {
 	ctx, cancel := context.WithTimeout(c, 2*time.Second)
 	defer cancel()
 
-	return database.Client.InsertTicker(ctx, symbol, class)
+	storedClass, err := database.Client.GetTickerClassBySymbol(ctx, req.Symbol)
+	if errorsStdLib.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if req.Class != "" && req.Class != storedClass {
+		return fmt.Errorf("%w: %s is %s, not %s", ErrClassMismatch, req.Symbol, storedClass, req.Class)
+	}
+
+	req.Class = storedClass
+	return nil
+}
+
+// ValidateRegisterTickerReq checks the class and provider symbols of a ticker to register.
+func ValidateRegisterTickerReq(req *RegisterTickerReq) error {
+	if !slices.Contains(AllowedClasses, req.Class) {
+		return fmt.Errorf("valid classes: %s", AllowedClasses)
+	}
+
+	for provider := range req.ProviderSymbols {
+		if !slices.Contains(ClassProviderSymbols[req.Class], provider) {
+			return fmt.Errorf("valid provider symbols for class %s: %s", req.Class, ClassProviderSymbols[req.Class])
+		}
+	}
+
+	return validateClassSymbol(req.Symbol, req.Class, req.ProviderSymbols)
+}
+
+// UpsertTicker registers the ticker if it is new and sets its provider symbols, reporting whether it was created.
+func UpsertTicker(c context.Context, symbol, class string, providerSymbols map[string]string) (bool, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	created := false
+	if !database.Client.IsTickerRegistered(ctx, symbol) {
+		if err := database.Client.InsertTicker(ctx, symbol, class); err != nil {
+			return false, err
+		}
+		created = true
+	}
+
+	return created, database.Client.UpsertTickerProviderSymbols(ctx, symbol, providerSymbols)
 }
 
 func GetTickers(c *gin.Context) {
@@ -59,3 +131,24 @@ func getTickers(c context.Context) ([]database.Ticker, error) {
 
 	return database.Client.GetTickers(ctx)
 }
+
+func GetCorporateActions(c *gin.Context) {
+	tickerSymbol := c.Param("ticker")
+
+	results, err := getCorporateActions(c.Request.Context(), tickerSymbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errors.NewErrorResp(fmt.Errorf("%s: %w", errors.DatabaseQueryError, err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"corporateActions": results,
+	})
+}
+
+func getCorporateActions(c context.Context, tickerSymbol string) ([]database.CorporateAction, error) {
+	ctx, cancel := context.WithTimeout(c, 2*time.Second)
+	defer cancel()
+
+	return database.Client.GetCorporateActions(ctx, tickerSymbol)
+}
@@ -0,0 +1,34 @@
+package ticker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/signalb/internal/database"
+	"github.com/signalb/internal/database/dbtest"
+)
+
+func TestResolveTickerClass(t *testing.T) {
+	dbtest.Use(t).SetTickers(database.Ticker{Symbol: "GOLD", Class: CommodityClass})
+
+	// The provider symbols of a registered ticker are checked against its stored class
+	req := &RegisterTickerReq{Symbol: "GOLD", ProviderSymbols: map[string]string{TokenInsightSlug: "gold"}}
+	if err := ResolveTickerClass(context.Background(), req); err != nil || req.Class != CommodityClass {
+		t.Fatalf("class = %q with error %v, want the stored %s", req.Class, err, CommodityClass)
+	}
+
+	if err := ValidateRegisterTickerReq(req); err == nil {
+		t.Error("a crypto provider symbol was accepted for a commodity")
+	}
+
+	mismatch := &RegisterTickerReq{Symbol: "GOLD", Class: CryptoClass}
+	if err := ResolveTickerClass(context.Background(), mismatch); !errors.Is(err, ErrClassMismatch) {
+		t.Errorf("error = %v, want ErrClassMismatch", err)
+	}
+
+	unregistered := &RegisterTickerReq{Symbol: "EURUSD", Class: ForexClass}
+	if err := ResolveTickerClass(context.Background(), unregistered); err != nil || unregistered.Class != ForexClass {
+		t.Errorf("class = %q with error %v, want the requested %s", unregistered.Class, err, ForexClass)
+	}
+}
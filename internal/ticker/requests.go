@@ -1,6 +1,7 @@
 package ticker
 
 type RegisterTickerReq struct {
-	Symbol string
-	Class  string
+	Symbol          string
+	Class           string
+	ProviderSymbols map[string]string
 }
@@ -1,8 +1,58 @@
 package ticker
 
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	StockClass     = "stock"
+	CryptoClass    = "crypto"
+	ForexClass     = "forex"     // currency pairs, e.g. EURUSD
+	CommodityClass = "commodity" // futures, e.g. gold
+	IndexClass     = "index"     // e.g. the S&P 500
+)
+
+var AllowedClasses = []string{StockClass, CryptoClass, ForexClass, CommodityClass, IndexClass}
+
+// Keys for the symbols a ticker is known by at each data provider.
 const (
-	StockClass  = "stock"
-	CryptoClass = "crypto"
+	TokenInsightSlug = "tokeninsight"  // e.g. bitcoin
+	CoinAPIAsset     = "coinapi"       // e.g. BTC
+	ExchangePair     = "exchange_pair" // CoinAPI symbol id, e.g. BITSTAMP_SPOT_BTC_USD
+	StreamSymbol     = "stream"        // WebSocket trade feed symbol, e.g. btcusdt
+	RapidAPISymbol   = "rapidapi"      // e.g. EURUSD=X, GC=F or ^GSPC
 )
 
-var AllowedClasses = []string{StockClass, CryptoClass}
+var AllowedProviderSymbols = []string{TokenInsightSlug, CoinAPIAsset, ExchangePair, StreamSymbol, RapidAPISymbol}
+
+// ClassProviderSymbols are the provider symbols that apply to each class's data providers.
+var ClassProviderSymbols = map[string][]string{
+	StockClass:     {RapidAPISymbol},
+	CryptoClass:    {TokenInsightSlug, CoinAPIAsset, ExchangePair, StreamSymbol},
+	ForexClass:     {RapidAPISymbol},
+	CommodityClass: {RapidAPISymbol},
+	IndexClass:     {RapidAPISymbol},
+}
+
+// currencyPairPattern matches a base and quote ISO 4217 code, e.g. EURUSD.
+var currencyPairPattern = regexp.MustCompile(`^[A-Z]{6}$`)
+
+// validateClassSymbol checks the ticker can be fetched for its class, the fetchers derive provider
+// symbols from currency pairs and index symbols but can't guess a commodity's futures symbol.
+func validateClassSymbol(symbol, class string, providerSymbols map[string]string) error {
+	_, hasRapidAPISymbol := providerSymbols[RapidAPISymbol]
+
+	switch class {
+	case ForexClass:
+		if !hasRapidAPISymbol && !currencyPairPattern.MatchString(symbol) {
+			return fmt.Errorf("forex symbol %s must be a currency pair like EURUSD, or come with a %s symbol", symbol, RapidAPISymbol)
+		}
+	case CommodityClass:
+		if !hasRapidAPISymbol {
+			return fmt.Errorf("commodity %s needs a %s symbol, e.g. GC=F for gold", symbol, RapidAPISymbol)
+		}
+	}
+
+	return nil
+}